@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/ngageoint/seed-silo/database"
+	"github.com/ngageoint/seed-silo/models"
+)
+
+const commandUsage = `usage:
+  silo catalog export [-o file]
+  silo catalog import [-mode merge|replace] [file]`
+
+//runCommand runs a silo subcommand against the configured database instead of starting the server
+func runCommand(args []string) error {
+	if len(args) < 2 || args[0] != "catalog" {
+		return errors.New(commandUsage)
+	}
+
+	db := database.GetDB()
+	switch args[1] {
+	case "export":
+		flags := flag.NewFlagSet("catalog export", flag.ContinueOnError)
+		output := flags.String("o", "", "file to write the catalog to instead of stdout")
+		if err := flags.Parse(args[2:]); err != nil {
+			return err
+		}
+
+		var w io.Writer = os.Stdout
+		if *output != "" {
+			f, err := os.Create(*output)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		return models.ExportCatalog(db, w)
+	case "import":
+		flags := flag.NewFlagSet("catalog import", flag.ContinueOnError)
+		mode := flags.String("mode", models.CatalogMerge, "merge or replace")
+		if err := flags.Parse(args[2:]); err != nil {
+			return err
+		}
+
+		var r io.Reader = os.Stdin
+		if flags.NArg() > 0 {
+			f, err := os.Open(flags.Arg(0))
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+		result, err := models.ImportCatalog(db, database.GetDbType(), r, *mode)
+		if err != nil {
+			return err
+		}
+		return json.NewEncoder(os.Stdout).Encode(result)
+	}
+	return errors.New(commandUsage)
+}
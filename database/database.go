@@ -32,6 +32,12 @@ func InitSqliteDB(filepath, admin, password string) *sql.DB {
 	models.CreateUser(db, dbType, admin, password)
 	models.CreateJobTable(db, dbType)
 	models.CreateJobVersionTable(db, dbType)
+	models.CreateInterfaceTables(db, dbType)
+	models.CreateResourceTable(db, dbType)
+	models.CreateNodeProfileTable(db, dbType)
+	models.CreatePolicyTable(db, dbType)
+	models.CreateTagHistoryTable(db, dbType)
+	models.CreateOperationTable(db, dbType)
 
 	return db
 }
@@ -74,6 +80,12 @@ func InitPostgresDB(url, admin, password string) *sql.DB {
 	models.CreateJobTable(db, dbType)
 	models.CreateJobVersionTable(db, dbType)
 	models.CreateImageTable(db, dbType)
+	models.CreateInterfaceTables(db, dbType)
+	models.CreateResourceTable(db, dbType)
+	models.CreateNodeProfileTable(db, dbType)
+	models.CreatePolicyTable(db, dbType)
+	models.CreateTagHistoryTable(db, dbType)
+	models.CreateOperationTable(db, dbType)
 	models.CreateUser(db, dbType, admin, password)
 
 	return db
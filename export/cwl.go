@@ -0,0 +1,279 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//CwlExport is a CWL document along with the parts of the seed manifest it could not represent exactly
+type CwlExport struct {
+	Document CwlTool
+	Warnings []string
+}
+
+type CwlTool struct {
+	CwlVersion         string         `json:"cwlVersion"`
+	Class              string         `json:"class"`
+	Id                 string         `json:"id"`
+	Label              string         `json:"label,omitempty"`
+	Doc                string         `json:"doc,omitempty"`
+	Requirements       []interface{}  `json:"requirements"`
+	Arguments          []CwlArgument  `json:"arguments,omitempty"`
+	Inputs             []CwlParameter `json:"inputs"`
+	Outputs            []CwlParameter `json:"outputs"`
+	PermanentFailCodes []int          `json:"permanentFailCodes,omitempty"`
+}
+
+type CwlParameter struct {
+	Id            string            `json:"id"`
+	Type          interface{}       `json:"type"`
+	Doc           string            `json:"doc,omitempty"`
+	OutputBinding *CwlOutputBinding `json:"outputBinding,omitempty"`
+}
+
+type CwlOutputBinding struct {
+	Glob         string `json:"glob"`
+	LoadContents bool   `json:"loadContents,omitempty"`
+	OutputEval   string `json:"outputEval,omitempty"`
+}
+
+type CwlArgument struct {
+	ValueFrom  string `json:"valueFrom"`
+	ShellQuote bool   `json:"shellQuote"`
+}
+
+type cwlRequirement struct {
+	Class string `json:"class"`
+}
+
+type cwlDockerRequirement struct {
+	Class      string `json:"class"`
+	DockerPull string `json:"dockerPull"`
+}
+
+type cwlResourceRequirement struct {
+	Class     string  `json:"class"`
+	CoresMin  float64 `json:"coresMin,omitempty"`
+	RamMin    float64 `json:"ramMin,omitempty"`
+	OutdirMin float64 `json:"outdirMin,omitempty"`
+}
+
+type cwlEnvDef struct {
+	EnvName  string `json:"envName"`
+	EnvValue string `json:"envValue"`
+}
+
+type cwlEnvVarRequirement struct {
+	Class  string      `json:"class"`
+	EnvDef []cwlEnvDef `json:"envDef"`
+}
+
+type cwlToolTimeLimit struct {
+	Class     string `json:"class"`
+	Timelimit int    `json:"timelimit"`
+}
+
+//seedResultsManifest is the file seed jobs write json outputs to
+const seedResultsManifest = "results_manifest.json"
+
+var seedVariable = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+//cwlSymbol matches input ids that can be referenced as inputs.id; others need the inputs['id'] form
+var cwlSymbol = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+//inputRef returns the CWL parameter reference to an input without the surrounding $()
+func inputRef(id string) string {
+	if cwlSymbol.MatchString(id) {
+		return "inputs." + id
+	}
+	return "inputs['" + id + "']"
+}
+
+//cwlJsonTypes maps seed json input and output types to CWL types
+var cwlJsonTypes = map[string]string{
+	"string":  "string",
+	"integer": "long",
+	"number":  "double",
+	"boolean": "boolean",
+}
+
+//Cwl converts a seed image into a CWL v1.2 CommandLineTool. The seed command is run with ShellCommandRequirement
+//so that it is interpreted the same way as by seed, with seed variables replaced by CWL parameter references.
+func Cwl(src Source) CwlExport {
+	job := src.Seed.Job
+	iface := job.Interface
+	result := CwlExport{Warnings: []string{}}
+	warn := func(format string, args ...interface{}) {
+		result.Warnings = append(result.Warnings, fmt.Sprintf(format, args...))
+	}
+
+	tool := CwlTool{CwlVersion: "v1.2", Class: "CommandLineTool", Id: job.Name, Label: job.Title, Doc: job.Description,
+		Inputs: []CwlParameter{}, Outputs: []CwlParameter{}}
+	tool.Requirements = append(tool.Requirements, cwlDockerRequirement{Class: "DockerRequirement", DockerPull: src.Reference})
+
+	// values of the seed environment variables as CWL parameter references
+	values := map[string]string{"OUTPUT_DIR": "$(runtime.outdir)", "ALLOCATED_CPUS": "$(runtime.cores)",
+		"ALLOCATED_MEM": "$(runtime.ram)", "ALLOCATED_DISK": "$(runtime.outdirSize)"}
+	envDefs := []cwlEnvDef{{EnvName: "OUTPUT_DIR", EnvValue: "$(runtime.outdir)"}}
+	mediaTypesDropped := false
+	needsJavascript := false
+
+	for _, in := range iface.Inputs.Files {
+		param := CwlParameter{Id: in.Name, Type: "File"}
+		ref := inputRef(in.Name)
+		value := "$(" + ref + ".path)"
+		if in.Multiple {
+			param.Type = "File[]"
+			value = "$(" + ref + "[0].dirname)"
+			warn("Input %s accepts multiple files. Seed provides a directory of files while CWL stages each file "+
+				"separately; the directory of the first file is used", in.Name)
+		}
+		if !in.Required {
+			param.Type = param.Type.(string) + "?"
+			if in.Multiple {
+				value = ""
+			} else {
+				value = "$(" + ref + " ? " + ref + ".path : '')"
+				needsJavascript = true
+			}
+		}
+		if in.Partial {
+			warn("Input %s is marked partial. CWL has no equivalent and will stage the whole file", in.Name)
+		}
+		if len(in.MediaTypes) > 0 {
+			param.Doc = "Media types: " + strings.Join(in.MediaTypes, ", ")
+			mediaTypesDropped = true
+		}
+		tool.Inputs = append(tool.Inputs, param)
+		if value != "" {
+			values[EnvName(in.Name)] = value
+			envDefs = append(envDefs, cwlEnvDef{EnvName: EnvName(in.Name), EnvValue: value})
+		}
+	}
+
+	for _, in := range iface.Inputs.Json {
+		cwlType, ok := cwlJsonTypes[in.Type]
+		if !ok {
+			cwlType = "Any"
+			warn("JSON input %s has type %s which is passed to CWL as Any without validation", in.Name, in.Type)
+		}
+		if !in.Required {
+			if cwlType == "Any" {
+				cwlType = "Any?"
+			} else {
+				cwlType += "?"
+			}
+		}
+		tool.Inputs = append(tool.Inputs, CwlParameter{Id: in.Name, Type: cwlType})
+		values[EnvName(in.Name)] = "$(" + inputRef(in.Name) + ")"
+		envDefs = append(envDefs, cwlEnvDef{EnvName: EnvName(in.Name), EnvValue: values[EnvName(in.Name)]})
+	}
+
+	for _, s := range iface.Settings {
+		tool.Inputs = append(tool.Inputs, CwlParameter{Id: s.Name, Type: "string?", Doc: "Seed setting"})
+		values[EnvName(s.Name)] = "$(" + inputRef(s.Name) + ")"
+		envDefs = append(envDefs, cwlEnvDef{EnvName: EnvName(s.Name), EnvValue: values[EnvName(s.Name)]})
+		if s.Secret {
+			warn("Setting %s is secret. CWL v1.2 has no secret inputs so its value may be logged", s.Name)
+		}
+	}
+
+	for _, m := range iface.Mounts {
+		warn("Mount %s at %s cannot be represented in CWL and must be provided by the workflow engine", m.Name, m.Path)
+	}
+
+	for _, out := range iface.Outputs.Files {
+		param := CwlParameter{Id: out.Name, Type: "File", OutputBinding: &CwlOutputBinding{Glob: out.Pattern}}
+		if out.Multiple {
+			param.Type = "File[]"
+		}
+		if !out.Required {
+			param.Type = param.Type.(string) + "?"
+		}
+		if out.MediaType != "" {
+			param.Doc = "Media type: " + out.MediaType
+			mediaTypesDropped = true
+		}
+		tool.Outputs = append(tool.Outputs, param)
+	}
+
+	for _, out := range iface.Outputs.JSON {
+		cwlType, ok := cwlJsonTypes[out.Type]
+		if !ok {
+			cwlType = "Any"
+			warn("JSON output %s has type %s which is returned by CWL as Any without validation", out.Name, out.Type)
+		}
+		if !out.Required && cwlType != "Any" {
+			cwlType += "?"
+		}
+		key := out.Key
+		if key == "" {
+			key = out.Name
+		}
+		tool.Outputs = append(tool.Outputs, CwlParameter{Id: out.Name, Type: cwlType, OutputBinding: &CwlOutputBinding{
+			Glob: seedResultsManifest, LoadContents: true, OutputEval: "$(JSON.parse(self[0].contents)['" + key + "'])"}})
+		needsJavascript = true
+	}
+
+	if mediaTypesDropped {
+		warn("Media types are listed in parameter docs; CWL formats require ontology identifiers and are not set")
+	}
+
+	command := iface.Command
+	if strings.Contains(command, "$(") {
+		warn("The command contains $( which CWL interprets as a parameter reference")
+	}
+	command = seedVariable.ReplaceAllStringFunc(command, func(match string) string {
+		name := strings.Trim(match, "${}")
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return match
+	})
+	if command != "" {
+		tool.Arguments = append(tool.Arguments, CwlArgument{ValueFrom: command, ShellQuote: false})
+		tool.Requirements = append(tool.Requirements, cwlRequirement{Class: "ShellCommandRequirement"})
+	}
+	if needsJavascript {
+		tool.Requirements = append(tool.Requirements, cwlRequirement{Class: "InlineJavascriptRequirement"})
+	}
+	tool.Requirements = append(tool.Requirements, cwlEnvVarRequirement{Class: "EnvVarRequirement", EnvDef: envDefs})
+
+	resources := cwlResourceRequirement{Class: "ResourceRequirement"}
+	for _, r := range job.Resources.Scalar {
+		switch r.Name {
+		case "cpus":
+			resources.CoresMin = r.Value
+		case "mem":
+			resources.RamMin = r.Value
+		case "disk":
+			resources.OutdirMin = r.Value
+		default:
+			if r.Value > 0 {
+				warn("Resource %s cannot be represented in CWL", r.Name)
+			}
+			continue
+		}
+		if r.InputMultiplier > 0 {
+			warn("Resource %s scales with input size; only its base value of %v is required", r.Name, r.Value)
+		}
+	}
+	if resources.CoresMin > 0 || resources.RamMin > 0 || resources.OutdirMin > 0 {
+		tool.Requirements = append(tool.Requirements, resources)
+	}
+
+	if job.Timeout > 0 {
+		tool.Requirements = append(tool.Requirements, cwlToolTimeLimit{Class: "ToolTimeLimit", Timelimit: job.Timeout})
+	}
+
+	for _, e := range job.Errors {
+		tool.PermanentFailCodes = append(tool.PermanentFailCodes, e.Code)
+	}
+	if len(job.Errors) > 0 {
+		warn("Error codes are mapped to permanentFailCodes; their titles and categories are not represented")
+	}
+
+	result.Document = tool
+	return result
+}
@@ -0,0 +1,40 @@
+//Package export converts seed images into the job definitions of other systems
+package export
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/ngageoint/seed-common/objects"
+)
+
+//directories inputs and outputs are mounted at in exported containers
+const (
+	ContainerInputDir  = "/seed/input"
+	ContainerOutputDir = "/seed/output"
+)
+
+var envInvalid = regexp.MustCompile(`[^A-Z0-9_]`)
+
+//EnvName returns the environment variable a seed input, setting, mount or resource is passed as: the name in upper
+//case with any character that is not allowed in a variable name replaced by an underscore
+func EnvName(name string) string {
+	return envInvalid.ReplaceAllString(strings.ToUpper(name), "_")
+}
+
+//Source is an image to export
+type Source struct {
+	Reference string //pullable image reference, e.g. registry/org/name:tag
+	Manifest  string //raw seed manifest
+	Seed      objects.Seed
+}
+
+//rawManifest returns the seed manifest as-is so that fields unknown to silo are exported unchanged
+func (s Source) rawManifest() json.RawMessage {
+	if json.Valid([]byte(s.Manifest)) {
+		return json.RawMessage(s.Manifest)
+	}
+	manifest, _ := json.Marshal(s.Seed)
+	return json.RawMessage(manifest)
+}
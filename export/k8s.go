@@ -0,0 +1,214 @@
+package export
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//K8sOptions are the cluster specific parts of an exported kubernetes job
+type K8sOptions struct {
+	Namespace       string
+	ImagePullSecret string
+	StorageClass    string
+}
+
+var dns1123Invalid = regexp.MustCompile(`[^a-z0-9-]+`)
+var labelInvalid = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+//dns1123 converts a string to a valid kubernetes resource name
+func dns1123(name string) string {
+	name = dns1123Invalid.ReplaceAllString(strings.ToLower(name), "-")
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return strings.Trim(name, "-")
+}
+
+func labelValue(value string) string {
+	value = labelInvalid.ReplaceAllString(value, "_")
+	if len(value) > 63 {
+		value = value[:63]
+	}
+	return strings.Trim(value, "._-")
+}
+
+func quantity(value float64, unit string) string {
+	return strconv.FormatFloat(value, 'f', -1, 64) + unit
+}
+
+//K8sJob converts a seed image into a kubernetes batch/v1 Job along with persistent volume claims for its input,
+//output and mounts. Inputs are expected under /seed/input/<input name> and outputs are written to /seed/output.
+//Settings and json inputs default to empty values; secret settings reference keys of a <job>-settings secret.
+func K8sJob(src Source, opts K8sOptions) string {
+	job := src.Seed.Job
+	iface := job.Interface
+	name := dns1123(job.Name + "-" + job.JobVersion)
+	warnings := []string{}
+
+	labels := yamlMap{
+		{"app.kubernetes.io/name", labelValue(job.Name)},
+		{"seed.job-version", labelValue(job.JobVersion)},
+		{"seed.package-version", labelValue(job.PackageVersion)},
+	}
+	metadata := func(resource string) yamlMap {
+		m := yamlMap{{"name", resource}}
+		if opts.Namespace != "" {
+			m = append(m, yamlEntry{"namespace", opts.Namespace})
+		}
+		return append(m, yamlEntry{"labels", labels})
+	}
+
+	env := []interface{}{yamlMap{{"name", "OUTPUT_DIR"}, {"value", ContainerOutputDir}}}
+	for _, in := range iface.Inputs.Files {
+		path := ContainerInputDir + "/" + in.Name
+		if !in.Multiple {
+			path += "/" + in.Name
+			warnings = append(warnings, "Input "+in.Name+" is expected at "+path)
+		}
+		env = append(env, yamlMap{{"name", EnvName(in.Name)}, {"value", path}})
+	}
+	for _, in := range iface.Inputs.Json {
+		env = append(env, yamlMap{{"name", EnvName(in.Name)}, {"value", ""}})
+	}
+	secretName := name + "-settings"
+	for _, s := range iface.Settings {
+		if s.Secret {
+			ref := yamlMap{{"name", secretName}, {"key", s.Name}}
+			env = append(env, yamlMap{{"name", EnvName(s.Name)}, {"valueFrom", yamlMap{{"secretKeyRef", ref}}}})
+		} else {
+			env = append(env, yamlMap{{"name", EnvName(s.Name)}, {"value", ""}})
+		}
+	}
+
+	requests := yamlMap{}
+	limits := yamlMap{}
+	outputSize := "1Gi"
+	sharedMem := 0.0
+	for _, r := range job.Resources.Scalar {
+		switch r.Name {
+		case "cpus":
+			requests = append(requests, yamlEntry{"cpu", quantity(r.Value, "")})
+			limits = append(limits, yamlEntry{"cpu", quantity(r.Value, "")})
+			env = append(env, yamlMap{{"name", "ALLOCATED_CPUS"}, {"value", quantity(r.Value, "")}})
+		case "mem":
+			requests = append(requests, yamlEntry{"memory", quantity(r.Value, "Mi")})
+			limits = append(limits, yamlEntry{"memory", quantity(r.Value, "Mi")})
+			env = append(env, yamlMap{{"name", "ALLOCATED_MEM"}, {"value", quantity(r.Value, "")}})
+		case "gpus":
+			limits = append(limits, yamlEntry{"nvidia.com/gpu", quantity(r.Value, "")})
+			env = append(env, yamlMap{{"name", "ALLOCATED_GPUS"}, {"value", quantity(r.Value, "")}})
+		case "disk":
+			if r.Value > 0 {
+				outputSize = quantity(r.Value, "Mi")
+			}
+			env = append(env, yamlMap{{"name", "ALLOCATED_DISK"}, {"value", quantity(r.Value, "")}})
+		case "sharedMem":
+			sharedMem = r.Value
+			env = append(env, yamlMap{{"name", "ALLOCATED_SHAREDMEM"}, {"value", quantity(r.Value, "")}})
+		default:
+			if r.Value > 0 {
+				warnings = append(warnings, "Resource "+r.Name+" has no kubernetes equivalent and is not requested")
+			}
+		}
+		if r.InputMultiplier > 0 {
+			warnings = append(warnings, "Resource "+r.Name+" scales with input size; only its base value is requested")
+		}
+	}
+
+	volumeMounts := []interface{}{
+		yamlMap{{"name", "input"}, {"mountPath", ContainerInputDir}, {"readOnly", true}},
+		yamlMap{{"name", "output"}, {"mountPath", ContainerOutputDir}},
+	}
+	volumes := []interface{}{
+		yamlMap{{"name", "input"}, {"persistentVolumeClaim", yamlMap{{"claimName", name + "-input"}}}},
+		yamlMap{{"name", "output"}, {"persistentVolumeClaim", yamlMap{{"claimName", name + "-output"}}}},
+	}
+	claims := []yamlMap{
+		persistentVolumeClaim(metadata(name+"-input"), "ReadOnlyMany", "1Gi", opts.StorageClass),
+		persistentVolumeClaim(metadata(name+"-output"), "ReadWriteOnce", outputSize, opts.StorageClass),
+	}
+	for _, m := range iface.Mounts {
+		volume := dns1123("mount-" + m.Name)
+		readOnly := m.Mode != "rw"
+		access := "ReadOnlyMany"
+		if !readOnly {
+			access = "ReadWriteMany"
+		}
+		volumeMounts = append(volumeMounts, yamlMap{{"name", volume}, {"mountPath", m.Path}, {"readOnly", readOnly}})
+		volumes = append(volumes, yamlMap{{"name", volume},
+			{"persistentVolumeClaim", yamlMap{{"claimName", name + "-" + volume}}}})
+		claims = append(claims, persistentVolumeClaim(metadata(name+"-"+volume), access, "1Gi", opts.StorageClass))
+	}
+	if sharedMem > 0 {
+		volumeMounts = append(volumeMounts, yamlMap{{"name", "shm"}, {"mountPath", "/dev/shm"}})
+		volumes = append(volumes, yamlMap{{"name", "shm"},
+			{"emptyDir", yamlMap{{"medium", "Memory"}, {"sizeLimit", quantity(sharedMem, "Mi")}}}})
+	}
+
+	container := yamlMap{{"name", dns1123(job.Name)}, {"image", src.Reference}}
+	if iface.Command != "" {
+		// seed passes its command as arguments to the image entrypoint, expanding variables first; kubernetes expands
+		// $(NAME) references to the container's environment variables in args
+		args := []interface{}{}
+		for _, arg := range strings.Fields(iface.Command) {
+			args = append(args, seedVariable.ReplaceAllString(arg, "$$($1$2)"))
+		}
+		container = append(container, yamlEntry{"args", args})
+	}
+	container = append(container, yamlEntry{"env", env})
+	resources := yamlMap{}
+	if len(requests) > 0 {
+		resources = append(resources, yamlEntry{"requests", requests})
+	}
+	if len(limits) > 0 {
+		resources = append(resources, yamlEntry{"limits", limits})
+	}
+	container = append(container, yamlEntry{"resources", resources}, yamlEntry{"volumeMounts", volumeMounts})
+
+	podSpec := yamlMap{{"restartPolicy", "Never"}}
+	if opts.ImagePullSecret != "" {
+		podSpec = append(podSpec, yamlEntry{"imagePullSecrets", []interface{}{yamlMap{{"name", opts.ImagePullSecret}}}})
+	}
+	podSpec = append(podSpec, yamlEntry{"containers", []interface{}{container}}, yamlEntry{"volumes", volumes})
+
+	spec := yamlMap{{"backoffLimit", 0}}
+	if job.Timeout > 0 {
+		spec = append(spec, yamlEntry{"activeDeadlineSeconds", job.Timeout})
+	}
+	spec = append(spec, yamlEntry{"template", yamlMap{{"metadata", yamlMap{{"labels", labels}}}, {"spec", podSpec}}})
+
+	k8sJob := yamlMap{
+		{"apiVersion", "batch/v1"},
+		{"kind", "Job"},
+		{"metadata", metadata(name)},
+		{"spec", spec},
+	}
+
+	docs := []string{}
+	header := "# Generated by seed-silo from " + src.Reference + "\n"
+	for _, w := range warnings {
+		header += "# " + w + "\n"
+	}
+	for _, claim := range claims {
+		docs = append(docs, toYaml(claim))
+	}
+	docs = append(docs, toYaml(k8sJob))
+
+	return header + strings.Join(docs, "---\n")
+}
+
+func persistentVolumeClaim(metadata yamlMap, access, size, storageClass string) yamlMap {
+	spec := yamlMap{{"accessModes", []interface{}{access}}}
+	if storageClass != "" {
+		spec = append(spec, yamlEntry{"storageClassName", storageClass})
+	}
+	spec = append(spec, yamlEntry{"resources", yamlMap{{"requests", yamlMap{{"storage", size}}}}})
+
+	return yamlMap{
+		{"apiVersion", "v1"},
+		{"kind", "PersistentVolumeClaim"},
+		{"metadata", metadata},
+		{"spec", spec},
+	}
+}
@@ -0,0 +1,125 @@
+package export
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//RunCommand is a seed run command line and the equivalent docker run command line for an image. Placeholders lists
+//the values in angle brackets that must be replaced before running either command.
+type RunCommand struct {
+	SeedRun      string
+	DockerRun    string
+	Placeholders []string
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+func shellQuote(value string) string {
+	if shellSafe.MatchString(value) {
+		return value
+	}
+	return "'" + strings.Replace(value, "'", `'"'"'`, -1) + "'"
+}
+
+//Commands builds the command lines to run an image. Values maps input, json input, setting and mount names, as well as
+//OUTPUT_DIR, to the values to use; anything required without a value is left as a <NAME> placeholder. Optional
+//inputs are only included when they have a value.
+func Commands(src Source, values map[string]string) RunCommand {
+	job := src.Seed.Job
+	iface := job.Interface
+	result := RunCommand{Placeholders: []string{}}
+	value := func(name string, required bool) (string, bool) {
+		if v, ok := values[name]; ok && v != "" {
+			return shellQuote(v), true
+		}
+		if !required {
+			return "", false
+		}
+		result.Placeholders = append(result.Placeholders, name)
+		return "<" + name + ">", true
+	}
+
+	seed := []string{"seed", "run", "-rm", "-in", shellQuote(src.Reference)}
+	docker := []string{"docker", "run", "--rm"}
+	// values of environment variables inside the container, used to expand the seed command
+	env := map[string]string{}
+	setEnv := func(name, v string) {
+		env[name] = v
+		docker = append(docker, "-e", name+"="+v)
+	}
+
+	for _, in := range iface.Inputs.Files {
+		v, ok := value(in.Name, in.Required)
+		if !ok {
+			continue
+		}
+		seed = append(seed, "-i", in.Name+"="+v)
+		target := path.Join(ContainerInputDir, in.Name)
+		docker = append(docker, "-v", v+":"+target+":ro")
+		setEnv(EnvName(in.Name), target)
+	}
+
+	for _, in := range iface.Inputs.Json {
+		v, ok := value(in.Name, in.Required)
+		if !ok {
+			continue
+		}
+		seed = append(seed, "-j", in.Name+"="+v)
+		setEnv(EnvName(in.Name), v)
+	}
+
+	outputDir, _ := value("OUTPUT_DIR", true)
+	seed = append(seed, "-o", outputDir)
+	docker = append(docker, "-v", outputDir+":"+ContainerOutputDir)
+	setEnv("OUTPUT_DIR", ContainerOutputDir)
+
+	for _, s := range iface.Settings {
+		v, _ := value(s.Name, true)
+		seed = append(seed, "-e", s.Name+"="+v)
+		setEnv(EnvName(s.Name), v)
+	}
+
+	for _, m := range iface.Mounts {
+		v, _ := value(m.Name, true)
+		seed = append(seed, "-m", m.Name+"="+v)
+		mode := m.Mode
+		if mode == "" {
+			mode = "ro"
+		}
+		docker = append(docker, "-v", v+":"+m.Path+":"+mode)
+	}
+
+	for _, r := range job.Resources.Scalar {
+		amount := strconv.FormatFloat(r.Value, 'f', -1, 64)
+		switch r.Name {
+		case "cpus":
+			docker = append(docker, "--cpus", amount)
+		case "mem":
+			docker = append(docker, "-m", amount+"m")
+		case "sharedMem":
+			if r.Value > 0 {
+				docker = append(docker, "--shm-size", amount+"m")
+			}
+		}
+		setEnv("ALLOCATED_"+EnvName(r.Name), amount)
+	}
+
+	docker = append(docker, shellQuote(src.Reference))
+	// seed expands the variables in the command before passing its arguments to the container
+	command := seedVariable.ReplaceAllStringFunc(iface.Command, func(match string) string {
+		if v, ok := env[strings.Trim(match, "${}")]; ok {
+			return v
+		}
+		return match
+	})
+	if command != "" {
+		docker = append(docker, command)
+	}
+
+	result.SeedRun = strings.Join(seed, " ")
+	result.DockerRun = strings.Join(docker, " ")
+	return result
+}
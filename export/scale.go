@@ -0,0 +1,90 @@
+package export
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//DefaultScaleVersion is the Scale API version used when none is requested
+const DefaultScaleVersion = "v6"
+
+//scaleMappings converts a source into a job type payload for each supported Scale API version
+var scaleMappings = map[string]func(Source) interface{}{
+	"v6": scaleJobTypeV6,
+}
+
+//ScaleVersions returns the supported Scale API versions
+func ScaleVersions() []string {
+	versions := []string{}
+	for v := range scaleMappings {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+//ScaleJobType returns a job type definition that can be posted to the job-types endpoint of the given Scale API
+//version
+func ScaleJobType(src Source, apiVersion string) (interface{}, error) {
+	if apiVersion == "" {
+		apiVersion = DefaultScaleVersion
+	}
+	mapping, ok := scaleMappings[strings.ToLower(apiVersion)]
+	if !ok {
+		return nil, fmt.Errorf("Unsupported Scale API version %s. Supported versions: %s", apiVersion,
+			strings.Join(ScaleVersions(), ", "))
+	}
+	return mapping(src), nil
+}
+
+type ScaleMountV6 struct {
+	Type     string `json:"type"`
+	HostPath string `json:"host_path"`
+}
+
+type ScaleOutputWorkspacesV6 struct {
+	Default string            `json:"default"`
+	Outputs map[string]string `json:"outputs"`
+}
+
+type ScaleConfigurationV6 struct {
+	OutputWorkspaces ScaleOutputWorkspacesV6 `json:"output_workspaces"`
+	Priority         int                     `json:"priority"`
+	Mounts           map[string]ScaleMountV6 `json:"mounts"`
+	Settings         map[string]string       `json:"settings"`
+}
+
+type ScaleJobTypeV6 struct {
+	DockerImage   string               `json:"docker_image"`
+	IconCode      string               `json:"icon_code"`
+	IsPublished   bool                 `json:"is_published"`
+	MaxScheduled  int                  `json:"max_scheduled,omitempty"`
+	Manifest      interface{}          `json:"manifest"`
+	Configuration ScaleConfigurationV6 `json:"configuration"`
+}
+
+//scaleJobTypeV6 maps a source to a v6 job type. Settings default to empty values and mounts default to host mounts
+//of the container path, both of which should be reviewed before the job type is created.
+func scaleJobTypeV6(src Source) interface{} {
+	config := ScaleConfigurationV6{
+		OutputWorkspaces: ScaleOutputWorkspacesV6{Outputs: map[string]string{}},
+		Priority:         100,
+		Mounts:           map[string]ScaleMountV6{},
+		Settings:         map[string]string{},
+	}
+	for _, m := range src.Seed.Job.Interface.Mounts {
+		config.Mounts[m.Name] = ScaleMountV6{Type: "host", HostPath: m.Path}
+	}
+	for _, s := range src.Seed.Job.Interface.Settings {
+		config.Settings[s.Name] = ""
+	}
+
+	return ScaleJobTypeV6{
+		DockerImage:   src.Reference,
+		IconCode:      "f1b2",
+		IsPublished:   true,
+		Manifest:      src.rawManifest(),
+		Configuration: config,
+	}
+}
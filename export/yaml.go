@@ -0,0 +1,122 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//yamlMap is a mapping that keeps its keys in order when written as YAML
+type yamlMap []yamlEntry
+
+type yamlEntry struct {
+	Key   string
+	Value interface{}
+}
+
+//plainScalar matches strings that can be written without quotes
+var plainScalar = regexp.MustCompile(`^[A-Za-z_/.][A-Za-z0-9_./-]*$`)
+
+var yamlReserved = map[string]bool{"true": true, "false": true, "yes": true, "no": true, "on": true, "off": true,
+	"y": true, "n": true, "null": true, "~": true}
+
+//toYaml writes maps, lists and scalars as block style YAML
+func toYaml(v interface{}) string {
+	b := &strings.Builder{}
+	writeYaml(b, v, 0)
+	return b.String()
+}
+
+func writeYaml(b *strings.Builder, v interface{}, indent int) {
+	pad := strings.Repeat(" ", indent)
+	switch value := v.(type) {
+	case yamlMap:
+		for _, e := range value {
+			b.WriteString(pad + yamlScalar(e.Key) + ":")
+			writeYamlValue(b, e.Value, indent)
+		}
+	case []interface{}:
+		for _, item := range value {
+			writeYamlItem(b, item, indent)
+		}
+	default:
+		b.WriteString(pad + yamlScalar(v) + "\n")
+	}
+}
+
+//writeYamlItem writes a list item. Collections are written indented under the item and the indent of their first
+//line is replaced by the list marker.
+func writeYamlItem(b *strings.Builder, item interface{}, indent int) {
+	pad := strings.Repeat(" ", indent)
+	if !isYamlCollection(item) || isEmptyYamlCollection(item) {
+		b.WriteString(pad + "- " + inlineYaml(item) + "\n")
+		return
+	}
+	nested := &strings.Builder{}
+	writeYaml(nested, item, indent+2)
+	b.WriteString(pad + "- " + strings.TrimPrefix(nested.String(), pad+"  "))
+}
+
+func writeYamlValue(b *strings.Builder, v interface{}, indent int) {
+	if !isYamlCollection(v) || isEmptyYamlCollection(v) {
+		b.WriteString(" " + inlineYaml(v) + "\n")
+		return
+	}
+	b.WriteString("\n")
+	writeYaml(b, v, indent+2)
+}
+
+func isYamlCollection(v interface{}) bool {
+	switch v.(type) {
+	case yamlMap, []interface{}:
+		return true
+	}
+	return false
+}
+
+func isEmptyYamlCollection(v interface{}) bool {
+	switch value := v.(type) {
+	case yamlMap:
+		return len(value) == 0
+	case []interface{}:
+		return len(value) == 0
+	}
+	return false
+}
+
+func inlineYaml(v interface{}) string {
+	switch value := v.(type) {
+	case yamlMap:
+		return "{}"
+	case []interface{}:
+		return "[]"
+	default:
+		return yamlScalar(value)
+	}
+}
+
+func yamlScalar(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		if plainScalar.MatchString(value) && !yamlReserved[strings.ToLower(value)] {
+			return value
+		}
+		quoted, _ := json.Marshal(value)
+		return string(quoted)
+	case int:
+		return strconv.Itoa(value)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	case nil:
+		return "null"
+	default:
+		quoted, _ := json.Marshal(fmt.Sprint(value))
+		return string(quoted)
+	}
+}
@@ -0,0 +1,438 @@
+package handlers
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ngageoint/seed-silo/database"
+	"github.com/ngageoint/seed-silo/models"
+	"github.com/ngageoint/seed-silo/registry"
+)
+
+//CreateBundle writes a tar bundle of the images of the requested job versions for transfer to a disconnected silo.
+//The bundle holds an OCI image layout of the images, their seed manifests and a catalog listing them.
+func CreateBundle(w http.ResponseWriter, r *http.Request) {
+	db := database.GetDB()
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := r.Body.Close(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var request models.BundleRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		respondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if len(request.JobVersions) == 0 {
+		respondWithError(w, http.StatusBadRequest, "No job versions to bundle")
+		return
+	}
+
+	images, code, err := bundleImages(db, request.JobVersions)
+	if err != nil {
+		respondWithError(w, code, err.Error())
+		return
+	}
+
+	file, err := ioutil.TempFile("", "silo-bundle-")
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if err = writeBundle(db, file, images); err != nil {
+		respondWithError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	name := "silo-bundle-" + time.Now().UTC().Format("20060102T150405Z") + ".tar"
+	w.Header().Set("Content-Type", "application/x-tar")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+name+"\"")
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, file)
+}
+
+//bundleImages reads the images of the given job versions, leaving out mirrors of an image already in the bundle.
+//It returns the status code to respond with if a job version is missing or two images would get the same name.
+func bundleImages(db *sql.DB, jobVersions []int) ([]models.Image, int, error) {
+	images := []models.Image{}
+	digests := map[string]bool{}
+	refs := map[string]string{}
+	for _, id := range jobVersions {
+		if _, err := models.ReadJobVersion(db, id); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, http.StatusNotFound, fmt.Errorf("No job version found with ID %d", id)
+			}
+			return nil, http.StatusInternalServerError, err
+		}
+		for _, imageId := range models.GetJobVersionImageIds(db, id) {
+			img, err := models.ReadImage(db, imageId)
+			if err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			if img.Digest != "" && digests[img.Digest] {
+				continue
+			}
+			ref := models.NewBundleImage(img).Ref
+			if other, ok := refs[ref]; ok {
+				return nil, http.StatusBadRequest, fmt.Errorf("Images %s and %s would both be named %s in the bundle",
+					other, img.FullName, ref)
+			}
+			digests[img.Digest] = true
+			refs[ref] = img.FullName
+			images = append(images, img)
+		}
+	}
+	return images, http.StatusOK, nil
+}
+
+//writeBundle writes the image layout, seed manifests and catalog of a bundle. Each image's manifest digest must
+//match the digest recorded by the last scan.
+func writeBundle(db *sql.DB, w io.Writer, images []models.Image) error {
+	tw := tar.NewWriter(w)
+	layout := registry.NewLayoutWriter(tw, models.BundleLayoutDir)
+	catalog := models.BundleCatalog{BundleVersion: models.BundleVersion, Created: time.Now().UTC().Format(time.RFC3339),
+		Images: []models.BundleImage{}}
+
+	for _, img := range images {
+		info, err := models.GetRegistry(db, img.RegistryId)
+		if err != nil {
+			return err
+		}
+		reg, err := createImageRegistry(info, img.Registry, img.Org)
+		if err != nil {
+			return errors.New(checkError(err, info.Url, info.Username, info.Password))
+		}
+
+		entry := models.NewBundleImage(img)
+		repo, tag := models.SplitImageName(img.FullName)
+		digest, err := layout.AddImage(reg, img.Org, repo, tag, entry.Ref)
+		if err != nil {
+			return fmt.Errorf("Unable to bundle %s: %s", img.FullName, err.Error())
+		}
+		if img.Digest != "" && digest != img.Digest {
+			return fmt.Errorf("Manifest digest of %s is %s but the last scan recorded %s; rescan the registry",
+				img.FullName, digest, img.Digest)
+		}
+		entry.Digest = digest
+
+		if err = writeBundleFile(tw, entry.SeedManifest, []byte(img.Manifest)); err != nil {
+			return err
+		}
+		catalog.Images = append(catalog.Images, entry)
+	}
+
+	if err := layout.Close(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(catalog, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = writeBundleFile(tw, models.BundleCatalogFile, data); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+func writeBundleFile(tw *tar.Writer, name string, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data))}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+//ImportBundle pushes the images of a bundle to the registry given by the registry parameter and adds them to the
+//catalog. The bundle is verified before anything is pushed; the push runs in the background and the response is the
+//operation reporting its progress.
+func ImportBundle(w http.ResponseWriter, r *http.Request) {
+	db := database.GetDB()
+	id, err := strconv.Atoi(r.URL.Query().Get("registry"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+
+	target, err := models.GetRegistry(db, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "No registry found with that ID")
+			return
+		} else {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	if models.IsManualRegistry(target) {
+		respondWithError(w, http.StatusBadRequest,
+			"Images can not be pushed to the pseudo-registry of manually registered images")
+		return
+	}
+	dstReg, err := registry.CreateRegistry(target.Url, target.Org, target.Username, target.Password)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, checkError(err, target.Url, target.Username, target.Password))
+		return
+	}
+	if _, ok := dstReg.(registry.V2Backed); !ok {
+		respondWithError(w, http.StatusBadRequest, "Registry "+target.Name+" does not support pushing images")
+		return
+	}
+	if sl.IsScanning() {
+		respondWithError(w, http.StatusConflict, "Registries are being scanned")
+		return
+	}
+
+	dir, err := ioutil.TempDir("", "silo-bundle-")
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	started := false
+	defer func() {
+		if !started {
+			os.RemoveAll(dir)
+		}
+	}()
+
+	err = extractBundle(r.Body, dir)
+	r.Body.Close()
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid bundle: "+err.Error())
+		return
+	}
+	catalog, layoutImages, manifests, err := readBundle(dir)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid bundle: "+err.Error())
+		return
+	}
+
+	op := models.NewOperation(models.OperationImport)
+	op.RegistryId = target.ID
+	op.Target = target.Url
+	for _, img := range layoutImages {
+		op.Total += img.Steps()
+	}
+	if database.GetDbType() == "postgres" {
+		op.ID, err = models.AddOperationPg(db, op)
+	} else {
+		op.ID, err = models.AddOperationLite(db, op)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	started = true
+	go func(op models.Operation) {
+		defer os.RemoveAll(dir)
+		layoutDir := filepath.Join(dir, models.BundleLayoutDir)
+		images := []models.Image{}
+		refs := []string{}
+		for i, entry := range catalog.Images {
+			offset := op.Done
+			repo, tag := models.SplitImageName(entry.Ref)
+			_, err := registry.PushLayoutImage(layoutDir, layoutImages[i], dstReg, target.Org, repo, tag,
+				func(step registry.CopyStep) {
+					op.Done = offset + step.Done
+					if step.Skipped {
+						op.Skipped++
+						op.Message = "Target already has " + step.Digest
+					} else {
+						op.Message = "Copied " + step.Digest
+					}
+					models.UpdateOperation(db, op)
+				})
+			if err != nil {
+				op.Status = models.OperationFailed
+				op.Error = fmt.Sprintf("Unable to push %s: %s", entry.Ref, err.Error())
+				models.UpdateOperation(db, op)
+				return
+			}
+			images = append(images, entry.Image(target, manifests[i]))
+			refs = append(refs, models.ImageReference(target.Url, target.Org, entry.Ref, ""))
+		}
+
+		waiting := "Waiting for the registries to finish scanning"
+		err := registerImages(db, images, func() {
+			if op.Message != waiting {
+				op.Message = waiting
+				models.UpdateOperation(db, op)
+			}
+		})
+		if err != nil {
+			op.Status = models.OperationFailed
+			op.Error = "Unable to add the images to the catalog: " + err.Error()
+			models.UpdateOperation(db, op)
+			return
+		}
+		op.Status = models.OperationSucceeded
+		op.Result = strings.Join(refs, ", ")
+		op.Message = fmt.Sprintf("Imported %d images into %s", len(images), target.Name)
+		models.UpdateOperation(db, op)
+	}(op)
+
+	w.Header().Set("Location", fmt.Sprintf("/operations/%d", op.ID))
+	respondWithJSON(w, http.StatusAccepted, op)
+}
+
+//extractBundle extracts a bundle, optionally gzip compressed, into a directory
+func extractBundle(body io.Reader, dir string) error {
+	reader := bufio.NewReader(body)
+	if magic, err := reader.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		body = gz
+	} else {
+		body = reader
+	}
+
+	tr := tar.NewReader(body)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("unexpected file %s", header.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err = os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err = os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+//readBundle reads the catalog of an extracted bundle and checks its images against their digests. It returns the
+//layout image of each catalog image and the seed manifest labelling its verified config, rejecting images whose seed
+//manifest file says otherwise.
+func readBundle(dir string) (models.BundleCatalog, []registry.LayoutImage, []string, error) {
+	catalog := models.BundleCatalog{}
+	data, err := ioutil.ReadFile(filepath.Join(dir, models.BundleCatalogFile))
+	if err != nil {
+		return catalog, nil, nil, errors.New("missing " + models.BundleCatalogFile)
+	}
+	if err = json.Unmarshal(data, &catalog); err != nil {
+		return catalog, nil, nil, err
+	}
+	if catalog.BundleVersion > models.BundleVersion {
+		return catalog, nil, nil, fmt.Errorf("unsupported bundle version %d", catalog.BundleVersion)
+	}
+	if len(catalog.Images) == 0 {
+		return catalog, nil, nil, errors.New("no images")
+	}
+
+	layoutDir := filepath.Join(dir, models.BundleLayoutDir)
+	if err = registry.VerifyLayout(layoutDir); err != nil {
+		return catalog, nil, nil, err
+	}
+
+	layoutImages := []registry.LayoutImage{}
+	manifests := []string{}
+	for _, entry := range catalog.Images {
+		img, err := registry.ReadLayoutImage(layoutDir, entry.Digest)
+		if err != nil {
+			return catalog, nil, nil, err
+		}
+		if entry.ConfigDigest != "" && img.Blobs[0].Digest.String() != entry.ConfigDigest {
+			return catalog, nil, nil, fmt.Errorf("config of %s does not match its digest", entry.Ref)
+		}
+		manifest, err := registry.LayoutSeedManifest(layoutDir, img)
+		if err != nil {
+			return catalog, nil, nil, fmt.Errorf("no seed manifest in the config of %s: %s", entry.Ref, err.Error())
+		}
+		name := path.Clean(entry.SeedManifest)
+		if path.IsAbs(name) || strings.HasPrefix(name, "..") {
+			return catalog, nil, nil, fmt.Errorf("unexpected seed manifest %s", entry.SeedManifest)
+		}
+		file, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return catalog, nil, nil, fmt.Errorf("missing seed manifest for %s", entry.Ref)
+		}
+		if !sameJson(file, []byte(manifest)) {
+			return catalog, nil, nil, fmt.Errorf("seed manifest of %s does not match its image config", entry.Ref)
+		}
+		layoutImages = append(layoutImages, img)
+		manifests = append(manifests, manifest)
+	}
+	return catalog, layoutImages, manifests, nil
+}
+
+//sameJson returns true if two documents hold the same JSON value, whatever their formatting
+func sameJson(a, b []byte) bool {
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+//scanWait is how often registerImages checks whether the registries have finished scanning
+const scanWait = time.Second
+
+//registerImages adds images to the catalog, replacing any with the same registry and name, and rebuilds the jobs
+//and the tables derived from the images as a scan does. It waits for any scan of the registries to finish first,
+//calling waiting each time it checks.
+func registerImages(db *sql.DB, images []models.Image, waiting func()) error {
+	for !sl.TryStartScan() {
+		waiting()
+		time.Sleep(scanWait)
+	}
+	defer sl.EndScan()
+
+	return models.MergeImages(db, images, database.GetDbType())
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/ngageoint/seed-silo/database"
+	"github.com/ngageoint/seed-silo/models"
+)
+
+//ExportCatalog streams the catalog as JSON Lines
+func ExportCatalog(w http.ResponseWriter, r *http.Request) {
+	db := database.GetDB()
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"silo-catalog.jsonl\"")
+	w.WriteHeader(http.StatusOK)
+
+	//the response has started so an error can only be logged
+	if err := models.ExportCatalog(db, w); err != nil {
+		log.Printf("Error exporting catalog: %s \n", err.Error())
+	}
+}
+
+//ImportCatalog adds a catalog export to the catalog. The mode parameter is merge, the default, or replace.
+func ImportCatalog(w http.ResponseWriter, r *http.Request) {
+	if sl.IsScanning() {
+		respondWithError(w, http.StatusConflict, "Registries are being scanned")
+		return
+	}
+	sl.StartScan()
+	defer sl.EndScan()
+
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = models.CatalogMerge
+	}
+
+	db := database.GetDB()
+	result, err := models.ImportCatalog(db, database.GetDbType(), r.Body, mode)
+	r.Body.Close()
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, result)
+}
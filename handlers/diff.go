@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/ngageoint/seed-common/objects"
+	"github.com/ngageoint/seed-silo/database"
+	"github.com/ngageoint/seed-silo/models"
+)
+
+//DiffImages compares the seed manifests of the images given by the from and to parameters
+func DiffImages(w http.ResponseWriter, r *http.Request) {
+	db := database.GetDB()
+	sides := []models.DiffSide{}
+	seeds := []objects.Seed{}
+	for _, param := range []string{"from", "to"} {
+		id, err := strconv.Atoi(r.URL.Query().Get(param))
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid "+param+" ID")
+			return
+		}
+		img, err := models.ReadImage(db, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				respondWithError(w, http.StatusNotFound, "No image found with ID "+strconv.Itoa(id))
+			} else {
+				respondWithError(w, http.StatusInternalServerError, err.Error())
+			}
+			return
+		}
+		sides = append(sides, models.DiffSide{ImageId: img.ID, Job: img.ShortName, JobVersion: img.JobVersion,
+			PackageVersion: img.PackageVersion})
+		seeds = append(seeds, img.Seed)
+	}
+
+	respondWithDiff(w, r, models.NewManifestDiff(sides[0], sides[1], seeds[0], seeds[1]))
+}
+
+//DiffJobVersions compares the seed manifests of the latest packages of the job versions given by the from and to
+//parameters
+func DiffJobVersions(w http.ResponseWriter, r *http.Request) {
+	db := database.GetDB()
+	sides := []models.DiffSide{}
+	seeds := []objects.Seed{}
+	for _, param := range []string{"from", "to"} {
+		id, err := strconv.Atoi(r.URL.Query().Get(param))
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid "+param+" ID")
+			return
+		}
+		jv, err := models.ReadJobVersion(db, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				respondWithError(w, http.StatusNotFound, "No job version found with ID "+strconv.Itoa(id))
+			} else {
+				respondWithError(w, http.StatusInternalServerError, err.Error())
+			}
+			return
+		}
+		seed, err := models.JobVersionManifest(db, jv)
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "No image found for job version "+strconv.Itoa(id))
+			return
+		}
+		sides = append(sides, models.DiffSide{JobVersionId: jv.ID, Job: jv.JobName, JobVersion: jv.JobVersion,
+			PackageVersion: jv.LatestPackageVersion})
+		seeds = append(seeds, seed)
+	}
+
+	respondWithDiff(w, r, models.NewManifestDiff(sides[0], sides[1], seeds[0], seeds[1]))
+}
+
+func respondWithDiff(w http.ResponseWriter, r *http.Request, diff models.ManifestDiff) {
+	if r.URL.Query().Get("format") == "text" {
+		respondWithText(w, http.StatusOK, "text/plain; charset=utf-8", diff.Text())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, diff)
+}
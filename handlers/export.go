@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/ngageoint/seed-silo/database"
+	"github.com/ngageoint/seed-silo/export"
+	"github.com/ngageoint/seed-silo/models"
+)
+
+func ExportImageScale(w http.ResponseWriter, r *http.Request) {
+	src, ok := imageExportSource(w, r)
+	if !ok {
+		return
+	}
+	exportScale(w, r, src)
+}
+
+func ExportImageCwl(w http.ResponseWriter, r *http.Request) {
+	src, ok := imageExportSource(w, r)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, export.Cwl(src))
+}
+
+func ExportImageK8s(w http.ResponseWriter, r *http.Request) {
+	src, ok := imageExportSource(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	opts := export.K8sOptions{Namespace: query.Get("namespace"), ImagePullSecret: query.Get("imagePullSecret"),
+		StorageClass: query.Get("storageClass")}
+	respondWithText(w, http.StatusOK, "application/x-yaml", export.K8sJob(src, opts))
+}
+
+//ImageRunCommand returns seed run and docker run command lines for an image. Query parameters of the form
+//input.NAME=value fill in the value of the input, json input, setting or mount NAME.
+func ImageRunCommand(w http.ResponseWriter, r *http.Request) {
+	src, ok := imageExportSource(w, r)
+	if !ok {
+		return
+	}
+	values := map[string]string{}
+	for key, value := range r.URL.Query() {
+		if strings.HasPrefix(key, "input.") && len(value) > 0 {
+			values[strings.TrimPrefix(key, "input.")] = value[0]
+		}
+	}
+	respondWithJSON(w, http.StatusOK, export.Commands(src, values))
+}
+
+func ExportJobVersionScale(w http.ResponseWriter, r *http.Request) {
+	src, ok := jobVersionExportSource(w, r)
+	if !ok {
+		return
+	}
+	exportScale(w, r, src)
+}
+
+func exportScale(w http.ResponseWriter, r *http.Request, src export.Source) {
+	jobType, err := export.ScaleJobType(src, r.URL.Query().Get("apiVersion"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, jobType)
+}
+
+//imageExportSource reads the image to export from the id in the url, writing an error response if it is not found.
+//If the pin parameter is true the image reference includes the current manifest digest.
+func imageExportSource(w http.ResponseWriter, r *http.Request) (export.Source, bool) {
+	db := database.GetDB()
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ID")
+		return export.Source{}, false
+	}
+
+	img, err := models.ReadImage(db, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "No image found with that ID")
+		} else {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return export.Source{}, false
+	}
+
+	src := export.Source{Reference: models.ImageReference(img.Registry, img.Org, img.FullName, ""),
+		Manifest: img.Manifest, Seed: img.Seed}
+	if r.URL.Query().Get("pin") == "true" {
+		digest, err := imageDigest(db, img.RegistryId, img.Registry, img.Org, img.FullName)
+		if err != nil {
+			respondWithError(w, http.StatusBadGateway, "Unable to get manifest digest: "+err.Error())
+			return export.Source{}, false
+		}
+		src.Reference = models.ImageReference(img.Registry, img.Org, img.FullName, digest)
+	}
+
+	return src, true
+}
+
+//jobVersionExportSource reads the latest package of the job version in the url, preferring registries in the
+//configured registry order
+func jobVersionExportSource(w http.ResponseWriter, r *http.Request) (export.Source, bool) {
+	db := database.GetDB()
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ID")
+		return export.Source{}, false
+	}
+
+	jv, err := models.ReadJobVersion(db, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "No job version found with that ID")
+		} else {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return export.Source{}, false
+	}
+
+	res, err := models.ResolveJob(db, models.JobVersionQuery(jv))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "No image found for that job version")
+		return export.Source{}, false
+	}
+
+	img, err := models.ReadImage(db, res.ImageId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return export.Source{}, false
+	}
+
+	src := export.Source{Reference: res.Reference, Manifest: img.Manifest, Seed: img.Seed}
+	if r.URL.Query().Get("pin") == "true" {
+		res = pinDigest(db, res)
+		if res.Digest == "" {
+			respondWithError(w, http.StatusBadGateway, "Unable to get manifest digest for "+res.Reference)
+			return export.Source{}, false
+		}
+		src.Reference = res.Reference
+	}
+
+	return src, true
+}
@@ -3,8 +3,12 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"path"
 	"sort"
 	"strconv"
 	"strings"
@@ -19,12 +23,80 @@ import (
 func ListImages(w http.ResponseWriter, r *http.Request) {
 	db := database.GetDB()
 	imageList := []models.SimpleImage{}
-	images := models.ReadSimpleImages(db)
-	imageList = append(imageList, images...)
+
+	var images []models.SimpleImage
+	metadata := metadataFilter(r)
+	if metadata.IsEmpty() {
+		images = models.ReadSimpleImages(db)
+	} else {
+		for _, img := range models.ReadImages(db) {
+			if metadata.Matches(img) {
+				images = append(images, models.SimplifyImage(img))
+			}
+		}
+	}
+
+	filter := interfaceFilter(r)
+	if filter.IsEmpty() {
+		imageList = append(imageList, images...)
+	} else {
+		ids := models.FilterImageIds(db, filter)
+		for _, img := range images {
+			if ids[img.ID] {
+				imageList = append(imageList, img)
+			}
+		}
+	}
+
+	if r.URL.Query().Get("canonical") == "true" {
+		imageList = models.CanonicalImages(imageList)
+	}
+	if r.URL.Query().Get("collapseMirrors") == "true" {
+		imageList = models.CollapseMirrors(imageList, models.MirrorRanks(db))
+	}
+
+	if order := r.URL.Query().Get("sort"); order != "" {
+		if err := models.SortImages(imageList, order); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
 
 	respondWithJSON(w, http.StatusOK, imageList)
 }
 
+//metadataFilter reads the registry metadata filters from the query parameters. Labels are given as key=value, or
+//just key to require the label be present.
+func metadataFilter(r *http.Request) models.MetadataFilter {
+	params := r.URL.Query()
+	filter := models.MetadataFilter{
+		License:  params.Get("license"),
+		Platform: params.Get("platform"),
+		Digest:   params.Get("digest"),
+		Labels:   map[string]string{},
+	}
+	for _, label := range params["label"] {
+		parts := strings.SplitN(label, "=", 2)
+		if len(parts) == 2 {
+			filter.Labels[parts[0]] = parts[1]
+		} else {
+			filter.Labels[parts[0]] = ""
+		}
+	}
+	return filter
+}
+
+//interfaceFilter reads the seed interface filters from the query parameters
+func interfaceFilter(r *http.Request) models.InterfaceFilter {
+	params := r.URL.Query()
+	return models.InterfaceFilter{
+		AcceptsMediaType:  params.Get("acceptsMediaType"),
+		ProducesMediaType: params.Get("producesMediaType"),
+		Setting:           params.Get("setting"),
+		Mount:             params.Get("mount"),
+	}
+}
+
 type RankedResult struct {
 	Score int
 	Image models.Image
@@ -105,6 +177,10 @@ func SearchImages(w http.ResponseWriter, r *http.Request) {
 		results = append(results, simple)
 	}
 
+	if r.URL.Query().Get("collapseMirrors") == "true" {
+		results = models.CollapseMirrors(results, models.MirrorRanks(db))
+	}
+
 	respondWithJSON(w, http.StatusOK, results)
 }
 
@@ -128,9 +204,59 @@ func Image(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	img.CanonicalId, img.Tags = models.ImageAliases(db, img)
 	respondWithJSON(w, http.StatusOK, img)
 }
 
+//ImageTagHistory lists the tag changes found by scans of an image's repository
+func ImageTagHistory(w http.ResponseWriter, r *http.Request) {
+	db := database.GetDB()
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	img, err := models.ReadImage(db, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "No image found with that ID")
+			return
+		} else {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	repo, _ := models.SplitImageName(img.FullName)
+	respondWithJSON(w, http.StatusOK, models.GetTagHistory(db, img.RegistryId, img.Org, repo))
+}
+
+//ImageMirrors lists the registries carrying the same manifest as an image, preferred registry first
+func ImageMirrors(w http.ResponseWriter, r *http.Request) {
+	db := database.GetDB()
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	img, err := models.ReadImage(db, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "No image found with that ID")
+			return
+		} else {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, models.ImageMirrors(db, img, models.MirrorRanks(db)))
+}
+
 func ImageManifest(w http.ResponseWriter, r *http.Request) {
 	db := database.GetDB()
 	vars := mux.Vars(r)
@@ -157,43 +283,180 @@ func ImageManifest(w http.ResponseWriter, r *http.Request) {
 
 func JITImageManifest(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	regUrl := vars["registry"]
-	imgstr := vars["image"]
-	org := ""
-	if strings.Contains(regUrl, "docker.io") || regUrl == "hub.docker.com" {
-		regUrl = "hub.docker.com"
-		temp := strings.SplitN(imgstr, "/", 2)
-		org = temp[0]
-		imgstr = temp[1]
+	img, code, err := fetchImage(vars["registry"], vars["image"], "", "")
+	if err != nil {
+		respondWithError(w, code, err.Error())
+		return
 	}
-	reg, err := registry.CreateRegistry(regUrl, org, "", "")
+	var seed objects.Seed
+	err = json.Unmarshal([]byte(img.manifest), &seed)
 	if err != nil {
-		humanError := checkError(err, regUrl, "", "")
-		respondWithError(w, http.StatusBadRequest, humanError)
+		respondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	respondWithJSON(w, http.StatusOK, seed)
+}
+
+//jitImage is an image read just in time from its registry instead of by a scan
+type jitImage struct {
+	reg      registry.RepositoryRegistry
+	registry string
+	org      string
+	name     string
+	tag      string
+	manifest string
+}
+
+//parseImage splits an image name within a registry into its org, repository and tag. Docker hub images are named
+//with their org.
+func parseImage(regUrl, imgstr string) (jitImage, error) {
+	img := jitImage{registry: regUrl}
+	if strings.Contains(regUrl, "docker.io") || regUrl == "hub.docker.com" {
+		img.registry = "docker.io"
+		temp := strings.SplitN(imgstr, "/", 2)
+		if len(temp) != 2 {
+			return img, errors.New("Missing organization in image name")
+		}
+		img.org = temp[0]
+		imgstr = temp[1]
+	}
 	temp := strings.Split(imgstr, ":")
-	var imageName, imageTag string
 	if len(temp) == 1 {
-		imageName = temp[0]
-		imageTag = "latest"
+		img.name = temp[0]
+		img.tag = "latest"
 	} else if len(temp) == 2 {
-		imageName = temp[0]
-		imageTag = temp[1]
+		img.name = temp[0]
+		img.tag = temp[1]
 	} else {
-		respondWithError(w, http.StatusBadRequest, "More than one colon in image name")
-		return
+		return img, errors.New("More than one colon in image name")
+	}
+	return img, nil
+}
+
+//fetchImage gets the seed manifest of an image from its registry, returning the response code for any error
+func fetchImage(regUrl, imgstr, username, password string) (jitImage, int, error) {
+	img, err := parseImage(regUrl, imgstr)
+	if err != nil {
+		return img, http.StatusBadRequest, err
+	}
+	if img.registry == "docker.io" {
+		regUrl = "hub.docker.com"
+	}
+	img.reg, err = registry.CreateRegistry(regUrl, img.org, username, password)
+	if err != nil {
+		humanError := checkError(err, regUrl, username, password)
+		return img, http.StatusBadRequest, errors.New(humanError)
+	}
+	img.manifest, err = img.reg.GetImageManifest(img.name, img.tag)
+	if err != nil {
+		return img, http.StatusInternalServerError, err
 	}
-	manifest, err := reg.GetImageManifest(imageName, imageTag)
+	return img, http.StatusOK, nil
+}
+
+//createImageRegistry creates the registry an image of the given registry is read from. Manually registered images
+//are read anonymously from the registry they were registered from, since silo keeps no credentials for them.
+func createImageRegistry(info models.RegistryInfo, host, org string) (registry.RepositoryRegistry, error) {
+	if !models.IsManualRegistry(info) {
+		return registry.CreateRegistry(info.Url, info.Org, info.Username, info.Password)
+	}
+	if host == "docker.io" {
+		host = "hub.docker.com"
+	}
+	return registry.CreateRegistry(host, org, "", "")
+}
+
+//RegisterImage adds an image silo cannot find by scanning to the manual pseudo-registry. The seed manifest is
+//uploaded or fetched from the image's registry. Registered images are kept by scans and linked to jobs as scanned
+//images are.
+func RegisterImage(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	var seed objects.Seed
-	err = json.Unmarshal([]byte(manifest), &seed)
+	if err := r.Body.Close(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var request models.RegisterImageRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		respondWithError(w, http.StatusUnprocessableEntity, "Error unmarshalling json. "+err.Error())
+		return
+	}
+	if request.Image == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing image")
+		return
+	}
+
+	regUrl, imgstr := models.SplitImageReference(request.Image)
+	var img jitImage
+	if len(request.Manifest) > 0 {
+		img, err = parseImage(regUrl, imgstr)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		img.manifest = string(request.Manifest)
+	} else {
+		var code int
+		img, code, err = fetchImage(regUrl, imgstr, request.Username, request.Password)
+		if err != nil {
+			respondWithError(w, code, err.Error())
+			return
+		}
+	}
+	if img.org == "" && strings.Contains(img.name, "/") {
+		img.org = path.Dir(img.name)
+	}
+
+	image := models.Image{FullName: img.name + ":" + img.tag, Registry: img.registry, Org: img.org,
+		Manifest: img.manifest}
+	models.SetSeedInfo(&image)
+	if image.ShortName == "" {
+		respondWithError(w, http.StatusBadRequest, "Invalid seed manifest")
+		return
+	}
+	if img.reg != nil {
+		addImageMetadata(img.reg, &image)
+	}
+
+	if sl.IsScanning() {
+		respondWithError(w, http.StatusConflict, "Registries are being scanned")
+		return
+	}
+	sl.StartScan()
+	defer sl.EndScan()
+
+	db := database.GetDB()
+	dbType := database.GetDbType()
+	manual, err := models.GetManualRegistry(db, dbType)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	respondWithJSON(w, http.StatusOK, seed)
+	image.RegistryId = manual.ID
+
+	previous := models.ManualImages(db, models.ReadImages(db))
+	for _, existing := range previous {
+		if existing.FullName == image.FullName && existing.Registry != image.Registry {
+			respondWithError(w, http.StatusConflict, "An image named "+image.FullName+" is already registered from "+
+				existing.Registry)
+			return
+		}
+	}
+	models.RecordTagChanges(db, previous, []models.Image{image})
+
+	if err = models.MergeImages(db, []models.Image{image}, dbType); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	for _, stored := range models.ManualImages(db, models.ReadImages(db)) {
+		if stored.FullName == image.FullName {
+			respondWithJSON(w, http.StatusCreated, models.SimplifyImage(stored))
+			return
+		}
+	}
+	respondWithError(w, http.StatusInternalServerError, "Error storing image")
 }
@@ -50,6 +50,11 @@ var routes = []Route{
 		"GET",
 		"/registries/{id}/scan",
 	},
+	Route{
+		"RegistryStatus",
+		"GET",
+		"/registries/{id}/status",
+	},
 	Route{
 		"ListImages",
 		"GET",
@@ -80,11 +85,66 @@ var routes = []Route{
 		"GET",
 		"/images/manifest/{registry}/{image:.+}",
 	},
+	Route{
+		"RegisterImage",
+		"POST",
+		"/images/register",
+	},
+	Route{
+		"ExportImageScale",
+		"GET",
+		"/images/{id:[0-9]+}/export/scale",
+	},
+	Route{
+		"ExportImageCwl",
+		"GET",
+		"/images/{id:[0-9]+}/export/cwl",
+	},
+	Route{
+		"ExportImageK8s",
+		"GET",
+		"/images/{id:[0-9]+}/export/k8s",
+	},
+	Route{
+		"ImageRunCommand",
+		"GET",
+		"/images/{id:[0-9]+}/run-command",
+	},
+	Route{
+		"ImageTagHistory",
+		"GET",
+		"/images/{id:[0-9]+}/tag-history",
+	},
+	Route{
+		"ImageMirrors",
+		"GET",
+		"/images/{id:[0-9]+}/mirrors",
+	},
+	Route{
+		"PromoteImage",
+		"POST",
+		"/images/{id:[0-9]+}/promote",
+	},
+	Route{
+		"ListOperations",
+		"GET",
+		"/operations",
+	},
+	Route{
+		"Operation",
+		"GET",
+		"/operations/{id:[0-9]+}",
+	},
 	Route{
 		"ListJobs",
 		"GET",
 		"/jobs",
 	},
+	Route{
+		"JobConflicts",
+		"GET",
+		"/jobs/conflicts",
+	},
 	Route{
 		"Job",
 		"GET",
@@ -95,16 +155,121 @@ var routes = []Route{
 		"GET",
 		"/jobs/{id}/job-versions",
 	},
+	Route{
+		"JobDownstream",
+		"GET",
+		"/jobs/{id}/downstream",
+	},
+	Route{
+		"JobUpstream",
+		"GET",
+		"/jobs/{id}/upstream",
+	},
+	Route{
+		"JobPolicy",
+		"GET",
+		"/jobs/{id}/policy",
+	},
+	Route{
+		"ValidatePipeline",
+		"POST",
+		"/pipelines/validate",
+	},
 	Route{
 		"ListJobVersions",
 		"GET",
 		"/job-versions",
 	},
+	Route{
+		"DiffJobVersions",
+		"GET",
+		"/job-versions/diff",
+	},
 	Route{
 		"JobVersion",
 		"GET",
 		"/job-versions/{id}",
 	},
+	Route{
+		"ExportJobVersionScale",
+		"GET",
+		"/job-versions/{id}/export/scale",
+	},
+	Route{
+		"JobVersionInputSchema",
+		"GET",
+		"/job-versions/{id}/input-schema",
+	},
+	Route{
+		"ValidateJobVersionInputs",
+		"POST",
+		"/job-versions/{id}/validate-inputs",
+	},
+	Route{
+		"Lint",
+		"POST",
+		"/lint",
+	},
+	Route{
+		"DiffImages",
+		"GET",
+		"/diff",
+	},
+	Route{
+		"Resolve",
+		"GET",
+		"/resolve",
+	},
+	Route{
+		"Lock",
+		"POST",
+		"/lock",
+	},
+	Route{
+		"VerifyLock",
+		"POST",
+		"/lock/verify",
+	},
+	Route{
+		"CreateBundle",
+		"POST",
+		"/bundles",
+	},
+	Route{
+		"ImportBundle",
+		"POST",
+		"/bundles/import",
+	},
+	Route{
+		"ExportCatalog",
+		"GET",
+		"/catalog/export",
+	},
+	Route{
+		"ImportCatalog",
+		"POST",
+		"/catalog/import",
+	},
+	Route{
+		"NodeProfile",
+		"GET",
+		"/node-profiles/{id}",
+	},
+	Route{
+		"AddNodeProfile",
+		"POST",
+		"/node-profiles/add",
+	},
+	Route{
+		"DeleteNodeProfile",
+		"DELETE",
+		"/node-profiles/delete/{id}",
+	},
+	Route{
+		"ListNodeProfiles",
+		"GET",
+		"/node-profiles",
+	},
 	Route{
 		"Login",
 		"POST",
@@ -188,3 +353,11 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 
 	util.PrintUtil("Response: %s\n", response)
 }
+
+func respondWithText(w http.ResponseWriter, code int, contentType string, body string) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(code)
+	w.Write([]byte(body))
+
+	util.PrintUtil("Response: %s\n", body)
+}
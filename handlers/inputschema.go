@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/ngageoint/seed-silo/database"
+	"github.com/ngageoint/seed-silo/models"
+)
+
+func JobVersionInputSchema(w http.ResponseWriter, r *http.Request) {
+	schema, ok := jobVersionInputSchema(w, r)
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, schema)
+}
+
+func ValidateJobVersionInputs(w http.ResponseWriter, r *http.Request) {
+	schema, ok := jobVersionInputSchema(w, r)
+	if !ok {
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := r.Body.Close(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var inputs interface{}
+	if err := json.Unmarshal(body, &inputs); err != nil {
+		respondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.ValidateInputs(schema, inputs))
+}
+
+//jobVersionInputSchema builds the input schema of the job version in the url, writing an error response if it is
+//not found
+func jobVersionInputSchema(w http.ResponseWriter, r *http.Request) (models.JsonSchema, bool) {
+	db := database.GetDB()
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ID")
+		return nil, false
+	}
+
+	jv, err := models.ReadJobVersion(db, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "No job version found with that ID")
+		} else {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return nil, false
+	}
+
+	seed, err := models.JobVersionManifest(db, jv)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "No image found for that job version")
+		return nil, false
+	}
+
+	return models.InputSchema(seed), true
+}
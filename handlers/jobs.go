@@ -18,11 +18,70 @@ func ListJobs(w http.ResponseWriter, r *http.Request) {
 	db := database.GetDB()
 	jobList := []models.Job{}
 	jobs := models.ReadJobs(db)
-	jobList = append(jobList, jobs...)
+
+	filter := interfaceFilter(r)
+	if filter.IsEmpty() {
+		jobList = append(jobList, jobs...)
+	} else {
+		ids := models.FilterJobIds(db, filter)
+		for _, job := range jobs {
+			if ids[job.ID] {
+				jobList = append(jobList, job)
+			}
+		}
+	}
+
+	params := r.URL.Query()
+	if fits := params.Get("fits"); fits != "" {
+		profile, err := models.GetNodeProfileByName(db, fits)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				respondWithError(w, http.StatusNotFound, "No node profile found with that name")
+			} else {
+				respondWithError(w, http.StatusInternalServerError, err.Error())
+			}
+			return
+		}
+
+		inputSize := 0.0
+		if size := params.Get("inputSize"); size != "" {
+			inputSize, err = strconv.ParseFloat(size, 64)
+			if err != nil || inputSize < 0 {
+				respondWithError(w, http.StatusBadRequest, "Invalid input size")
+				return
+			}
+		}
+
+		jobList = fittingJobs(jobList, profile, inputSize)
+	}
 
 	respondWithJSON(w, http.StatusOK, jobList)
 }
 
+//fittingJobs restricts the job versions of each job to those that fit on the node profile, dropping jobs without
+//any fitting job versions
+func fittingJobs(jobs []models.Job, profile models.NodeProfile, inputSize float64) []models.Job {
+	result := []models.Job{}
+	for _, job := range jobs {
+		jvs := []models.JobVersion{}
+		imageIds := []int{}
+		for _, jv := range job.JobVersions {
+			if models.ResourcesFit(jv.Resources, profile, inputSize) {
+				jvs = append(jvs, jv)
+				for _, img := range jv.Images {
+					imageIds = append(imageIds, img.ID)
+				}
+			}
+		}
+		if len(jvs) > 0 {
+			job.JobVersions = jvs
+			job.ImageIDs = imageIds
+			result = append(result, job)
+		}
+	}
+	return result
+}
+
 func Job(w http.ResponseWriter, r *http.Request) {
 	db := database.GetDB()
 	vars := mux.Vars(r)
@@ -105,6 +164,33 @@ func JobVersion(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, jv)
 }
 
+func JobConflicts(w http.ResponseWriter, r *http.Request) {
+	db := database.GetDB()
+
+	respondWithJSON(w, http.StatusOK, models.JobConflicts(db))
+}
+
+func JobPolicy(w http.ResponseWriter, r *http.Request) {
+	db := database.GetDB()
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if _, err := models.ReadJob(db, id); err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "No job found with that ID")
+		} else {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.GetJobPolicyFindings(db, id))
+}
+
 func SearchJobs(w http.ResponseWriter, r *http.Request) {
 	db := database.GetDB()
 	vars := mux.Vars(r)
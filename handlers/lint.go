@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/ngageoint/seed-silo/database"
+	"github.com/ngageoint/seed-silo/models"
+)
+
+//Lint checks a seed manifest posted as raw JSON or as an escaped label string
+func Lint(w http.ResponseWriter, r *http.Request) {
+	db := database.GetDB()
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := r.Body.Close(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if strings.TrimSpace(string(body)) == "" {
+		respondWithError(w, http.StatusBadRequest, "No manifest given")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.LintManifest(db, models.ParseManifestBody(string(body))))
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ngageoint/seed-silo/database"
+	"github.com/ngageoint/seed-silo/models"
+	"github.com/ngageoint/seed-silo/registry"
+)
+
+func Lock(w http.ResponseWriter, r *http.Request) {
+	db := database.GetDB()
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := r.Body.Close(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var request models.LockRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		respondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if len(request.Jobs) == 0 {
+		respondWithError(w, http.StatusBadRequest, "No jobs to lock")
+		return
+	}
+
+	lockfile := models.Lockfile{LockfileVersion: models.LockfileVersion, Created: time.Now().UTC().Format(time.RFC3339)}
+	errs := []string{}
+	code := http.StatusBadRequest
+	for _, job := range request.Jobs {
+		if job.Name == "" {
+			errs = append(errs, "Missing job name")
+			continue
+		}
+		query := models.ResolveQuery{Job: job.Name, Namespace: job.Namespace, Version: job.Version,
+			PackageVersion: job.PackageVersion, Registries: job.Registries}
+		resolution, err := resolveImage(db, query)
+		if err != nil {
+			if c := resolveErrorCode(err); c > code {
+				code = c
+			}
+			errs = append(errs, fmt.Sprintf("Unable to resolve %s %s: %s", job.Name, job.Version, err.Error()))
+			continue
+		}
+		if resolution.Digest == "" {
+			errs = append(errs, fmt.Sprintf("Unable to get manifest digest for %s", resolution.Reference))
+			continue
+		}
+		reg, err := models.GetRegistry(db, resolution.RegistryId)
+		if err != nil {
+			code = http.StatusInternalServerError
+			errs = append(errs, err.Error())
+			continue
+		}
+		lockfile.Entries = append(lockfile.Entries, models.NewLockEntry(job, resolution, reg))
+	}
+
+	if len(errs) > 0 {
+		respondWithError(w, code, strings.Join(errs, "; "))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, lockfile)
+}
+
+func VerifyLock(w http.ResponseWriter, r *http.Request) {
+	db := database.GetDB()
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := r.Body.Close(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var lockfile models.Lockfile
+	if err := json.Unmarshal(body, &lockfile); err != nil {
+		respondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if lockfile.LockfileVersion > models.LockfileVersion {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Unsupported lockfile version %d", lockfile.LockfileVersion))
+		return
+	}
+
+	registries, err := models.GetRegistries(db)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	result := models.LockVerification{Verified: true, Entries: []models.LockEntryStatus{}}
+	for _, entry := range lockfile.Entries {
+		status := verifyLockEntry(registries, entry)
+		result.Verified = result.Verified && (status.Status == models.LockOk || status.Status == models.LockDrifted)
+		result.Entries = append(result.Entries, status)
+	}
+
+	respondWithJSON(w, http.StatusOK, result)
+}
+
+//verifyLockEntry checks that the pinned digest of an entry can still be pulled from its registry and whether its tag
+//still points at it
+func verifyLockEntry(registries []models.RegistryInfo, entry models.LockEntry) models.LockEntryStatus {
+	status := models.LockEntryStatus{Job: entry.Job, Reference: entry.Reference, Digest: entry.Digest}
+
+	info, ok := models.FindLockRegistry(registries, entry)
+	if !ok {
+		status.Status = models.LockError
+		status.Error = "No registry found for " + entry.RegistryUrl
+		return status
+	}
+
+	reg, err := createImageRegistry(info, entry.Registry, entry.Org)
+	if err != nil {
+		status.Status = models.LockError
+		status.Error = err.Error()
+		return status
+	}
+
+	repo, tag := models.SplitImageName(entry.Name)
+	if _, err = registry.ImageDigest(reg, entry.Org, repo, entry.Digest); err != nil {
+		if err == registry.ErrManifestUnknown {
+			status.Status = models.LockMissing
+		} else {
+			status.Status = models.LockError
+			status.Error = err.Error()
+		}
+		return status
+	}
+
+	digest, err := registry.ImageDigest(reg, entry.Org, repo, tag)
+	switch {
+	case err != nil && err != registry.ErrManifestUnknown:
+		status.Status = models.LockError
+		status.Error = err.Error()
+	case digest != entry.Digest:
+		status.Status = models.LockDrifted
+		status.CurrentDigest = digest
+	default:
+		status.Status = models.LockOk
+		status.CurrentDigest = digest
+	}
+
+	return status
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/ngageoint/seed-silo/database"
+	"github.com/ngageoint/seed-silo/models"
+)
+
+func NodeProfile(w http.ResponseWriter, r *http.Request) {
+	db := database.GetDB()
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	profile, err := models.GetNodeProfile(db, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "No node profile found with that ID")
+			return
+		} else {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, profile)
+}
+
+func ListNodeProfiles(w http.ResponseWriter, r *http.Request) {
+	db := database.GetDB()
+	profiles, err := models.GetNodeProfiles(db)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	list := []models.NodeProfile{}
+	list = append(list, profiles...)
+	respondWithJSON(w, http.StatusOK, list)
+}
+
+func AddNodeProfile(w http.ResponseWriter, r *http.Request) {
+	db := database.GetDB()
+	dbType := database.GetDbType()
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := r.Body.Close(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var profile models.NodeProfile
+	if err := json.Unmarshal(body, &profile); err != nil {
+		respondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if profile.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing node profile name")
+		return
+	}
+
+	var id int
+	var err2 error
+	if dbType == "postgres" {
+		id, err2 = models.AddNodeProfilePg(db, profile)
+	} else {
+		id, err2 = models.AddNodeProfileLite(db, profile)
+	}
+	if err2 != nil {
+		if strings.Contains(strings.ToLower(err2.Error()), "unique") {
+			respondWithError(w, http.StatusBadRequest, "Node profile already exists with name "+profile.Name)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, err2.Error())
+		}
+		return
+	}
+	profile.ID = id
+	respondWithJSON(w, http.StatusCreated, profile)
+}
+
+func DeleteNodeProfile(w http.ResponseWriter, r *http.Request) {
+	db := database.GetDB()
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if err := models.DeleteNodeProfile(db, id); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/ngageoint/seed-silo/database"
+	"github.com/ngageoint/seed-silo/models"
+)
+
+func JobDownstream(w http.ResponseWriter, r *http.Request) {
+	compatibleJobs(w, r, models.DownstreamJobs)
+}
+
+func JobUpstream(w http.ResponseWriter, r *http.Request) {
+	compatibleJobs(w, r, models.UpstreamJobs)
+}
+
+func compatibleJobs(w http.ResponseWriter, r *http.Request, find func(*sql.DB, int) []models.CompatibleJob) {
+	db := database.GetDB()
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	_, err = models.ReadJob(db, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "No job found with that ID")
+			return
+		} else {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, find(db, id))
+}
+
+func ValidatePipeline(w http.ResponseWriter, r *http.Request) {
+	db := database.GetDB()
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := r.Body.Close(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var request models.PipelineRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		respondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if len(request.Steps) == 0 {
+		respondWithError(w, http.StatusBadRequest, "No pipeline steps given")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.ValidatePipeline(db, request))
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/ngageoint/seed-silo/database"
+	"github.com/ngageoint/seed-silo/models"
+	"github.com/ngageoint/seed-silo/registry"
+)
+
+//PromoteImage starts copying an image's blobs and manifest into another registry and returns the operation
+//reporting its progress
+func PromoteImage(w http.ResponseWriter, r *http.Request) {
+	db := database.GetDB()
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	img, err := models.ReadImage(db, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "No image found with that ID")
+			return
+		} else {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1048576))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := r.Body.Close(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var request models.PromoteRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		respondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	target, err := models.GetRegistry(db, request.RegistryId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "No registry found with that ID")
+			return
+		} else {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	source, err := models.GetRegistry(db, img.RegistryId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if models.IsManualRegistry(target) {
+		respondWithError(w, http.StatusBadRequest,
+			"Images can not be pushed to the pseudo-registry of manually registered images")
+		return
+	}
+	srcReg, err := createImageRegistry(source, img.Registry, img.Org)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, checkError(err, source.Url, source.Username, source.Password))
+		return
+	}
+	dstReg, err := registry.CreateRegistry(target.Url, target.Org, target.Username, target.Password)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, checkError(err, target.Url, target.Username, target.Password))
+		return
+	}
+	if _, ok := dstReg.(registry.V2Backed); !ok {
+		respondWithError(w, http.StatusBadRequest, "Registry "+target.Name+" does not support pushing images")
+		return
+	}
+
+	repo, tag := models.SplitImageName(img.FullName)
+	if request.Tag == "" {
+		request.Tag = tag
+	}
+	if request.Repository == "" {
+		request.Repository = path.Base(repo)
+	}
+
+	op := models.NewOperation(models.OperationPromote)
+	op.ImageId = img.ID
+	op.RegistryId = target.ID
+	op.Target = models.ImageReference(target.Url, target.Org, request.Repository+":"+request.Tag, "")
+	if database.GetDbType() == "postgres" {
+		op.ID, err = models.AddOperationPg(db, op)
+	} else {
+		op.ID, err = models.AddOperationLite(db, op)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	go func(op models.Operation) {
+		digest, err := registry.CopyImage(srcReg, img.Org, repo, tag, dstReg, target.Org, request.Repository,
+			request.Tag, func(step registry.CopyStep) {
+				op.Done, op.Total = step.Done, step.Total
+				if step.Skipped {
+					op.Skipped++
+					op.Message = "Target already has " + step.Digest
+				} else {
+					op.Message = "Copied " + step.Digest
+				}
+				models.UpdateOperation(db, op)
+			})
+		if err != nil {
+			op.Status = models.OperationFailed
+			op.Error = err.Error()
+		} else {
+			op.Status = models.OperationSucceeded
+			op.Result = digest
+			op.Message = "Promoted " + img.FullName + " to " + op.Target
+		}
+		models.UpdateOperation(db, op)
+	}(op)
+
+	w.Header().Set("Location", fmt.Sprintf("/operations/%d", op.ID))
+	respondWithJSON(w, http.StatusAccepted, op)
+}
+
+func ListOperations(w http.ResponseWriter, r *http.Request) {
+	db := database.GetDB()
+	respondWithJSON(w, http.StatusOK, models.GetOperations(db))
+}
+
+func Operation(w http.ResponseWriter, r *http.Request) {
+	db := database.GetDB()
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	op, err := models.GetOperation(db, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "No operation found with that ID")
+			return
+		} else {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, op)
+}
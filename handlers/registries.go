@@ -39,6 +39,17 @@ func (sl *ScanLock) StartScan() {
 	sl.ScanInProcess = true
 }
 
+// TryStartScan starts a scan unless the registries are already being scanned, reporting whether it did
+func (sl *ScanLock) TryStartScan() bool {
+	sl.mux.Lock()
+	defer sl.mux.Unlock()
+	if sl.ScanInProcess {
+		return false
+	}
+	sl.ScanInProcess = true
+	return true
+}
+
 // EndScan
 func (sl *ScanLock) EndScan() {
 	sl.mux.Lock()
@@ -71,6 +82,28 @@ func Registry(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, reg)
 }
 
+func RegistryStatus(w http.ResponseWriter, r *http.Request) {
+	db := database.GetDB()
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	status, err := models.GetRegistryStatus(db, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondWithError(w, http.StatusNotFound, "No registry found with that ID")
+		} else {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, status)
+}
+
 func AddRegistry(w http.ResponseWriter, r *http.Request) {
 	db := database.GetDB()
 	dbType := database.GetDbType()
@@ -86,16 +119,21 @@ func AddRegistry(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusUnprocessableEntity, err.Error())
 	}
 	url := reginfo.Url
-	org := reginfo.Org
 	username := reginfo.Username
 	password := reginfo.Password
 
-	registry, err := registry.CreateRegistry(url, org, username, password)
-	if registry == nil || err != nil {
+	if err := registry.ValidateOrgs(url, reginfo.ScanOrgs()); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	reg, err := registry.CreateOrgsRegistry(url, reginfo.ScanOrgs(), username, password)
+	if reg == nil || err != nil {
 		humanError := checkError(err, url, username, password)
 		respondWithError(w, http.StatusBadRequest, humanError)
 		log.Print(humanError)
 		log.Print(err)
+	} else if err = registry.SetRepositoryList(reg, reginfo.Repositories); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
 	} else {
 		var id int
 		var err2 error
@@ -163,6 +201,11 @@ func ScanRegistry(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if models.IsManualRegistry(registry) {
+		respondWithError(w, http.StatusBadRequest, "Manually registered images are not scanned")
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/text; charset=UTF-8")
 	w.WriteHeader(http.StatusAccepted)
 
@@ -174,6 +217,8 @@ func ScanRegistry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	models.RecordTagChanges(db, models.ReadImages(db), dbImages)
+
 	//clear out image table before scanning
 	err = models.DeleteRegistryImages(db, id)
 	if err != nil {
@@ -205,6 +250,8 @@ func ScanRegistry(w http.ResponseWriter, r *http.Request) {
 		models.BuildJobsList(db, &allImages, dbType)
 		models.StoreOrUpdateImages(db, allImages, dbType)
 	}
+
+	models.BuildImageIndexes(db)
 }
 
 func ScanRegistries(w http.ResponseWriter, r *http.Request) {
@@ -233,6 +280,12 @@ func ScanRegistries(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	previous := models.ReadImages(db)
+	models.RecordTagChanges(db, previous, dbImages)
+
+	//keep the manually registered images, which are not scanned
+	dbImages = append(dbImages, models.ManualImages(db, previous)...)
+
 	//clear out image table before scanning
 	dbType := database.GetDbType()
 	err = models.ResetImageTable(db, dbType)
@@ -259,42 +312,41 @@ func ScanRegistries(w http.ResponseWriter, r *http.Request) {
 		models.BuildJobsList(db, &dbImages, dbType)
 		models.StoreImages(db, dbImages, dbType)
 	}
+
+	models.BuildImageIndexes(db)
 }
 
 func Scan(w http.ResponseWriter, req *http.Request, registries []models.RegistryInfo) ([]models.Image, error) {
 	dbImages := []models.Image{}
 	var err error
 	for _, r := range registries {
-		log.Printf("Scanning registry %s... \n url: %s \n org: %s \n", r.Name, r.Url, r.Org)
-		registry, err := registry.CreateRegistry(r.Url, r.Org, r.Username, r.Password)
+		if models.IsManualRegistry(r) {
+			continue
+		}
+		log.Printf("Scanning registry %s... \n url: %s \n orgs: %v \n", r.Name, r.Url, r.ScanOrgs())
+		reg, err := registry.CreateOrgsRegistry(r.Url, r.ScanOrgs(), r.Username, r.Password)
 		if err != nil {
 			humanError := checkError(err, r.Url, r.Username, r.Password)
 			respondWithError(w, http.StatusInternalServerError, humanError)
 			return nil, err
 		}
 
-		if registry == nil {
+		if reg == nil {
 			respondWithError(w, http.StatusInternalServerError, "Error creating registry.")
 			return nil, errors.New("ERROR: Unknown error creating registry.")
 		}
+		if err = registry.SetRepositoryList(reg, r.Repositories); err != nil {
+			respondWithError(w, http.StatusInternalServerError, err.Error())
+			return nil, err
+		}
 
 		var images []objects.Image
-		images, err = registry.ImagesWithManifests()
+		images, err = reg.ImagesWithManifests()
 
 		for _, img := range images {
 			image := models.Image{FullName: img.Name, Registry: img.Registry, Org: img.Org, Manifest: img.Manifest, RegistryId: r.ID}
-			err1 := json.Unmarshal([]byte(img.Manifest), &image.Seed)
-			if err1 != nil {
-				log.Printf("Error unmarshalling seed manifest for %s: %s \n", img.Name, err1.Error())
-			}
-			image.ShortName = image.Seed.Job.Name
-			image.Title = image.Seed.Job.Title
-			image.Maintainer = image.Seed.Job.Maintainer.Name
-			image.Email = image.Seed.Job.Maintainer.Email
-			image.MaintOrg = image.Seed.Job.Maintainer.Organization
-			image.JobVersion = image.Seed.Job.JobVersion
-			image.PackageVersion = image.Seed.Job.PackageVersion
-			image.Description = image.Seed.Job.Description
+			models.SetSeedInfo(&image)
+			addImageMetadata(reg, &image)
 			dbImages = append(dbImages, image)
 		}
 	}
@@ -302,6 +354,38 @@ func Scan(w http.ResponseWriter, req *http.Request, registries []models.Registry
 	return dbImages, err
 }
 
+//addImageMetadata records the digests, size, created time, platform and labels of an image if its registry supports it,
+//keeping any values the image already has when they can't be read
+func addImageMetadata(reg registry.RepositoryRegistry, image *models.Image) {
+	if source, ok := reg.(registry.MetadataSource); ok {
+		if metadata, found := source.ImageMetadata(image.Registry, image.Org, image.FullName); found {
+			image.Digest = metadata.Digest
+			image.ConfigDigest = metadata.ConfigDigest
+			image.Size = metadata.Size
+			image.Created = metadata.Created
+			image.Platform = metadata.Platform
+			image.Labels = metadata.Labels
+		}
+		return
+	}
+	if _, ok := reg.(registry.V2Backed); !ok {
+		return
+	}
+
+	repo, tag := models.SplitImageName(image.FullName)
+	metadata, err := registry.GetImageMetadata(reg, image.Org, repo, tag)
+	if err != nil {
+		log.Printf("Error reading image metadata for %s: %s \n", image.FullName, err.Error())
+		return
+	}
+	image.Digest = metadata.Digest
+	image.ConfigDigest = metadata.ConfigDigest
+	image.Size = metadata.Size
+	image.Created = metadata.Created
+	image.Platform = metadata.Platform
+	image.Labels = metadata.Labels
+}
+
 func ListRegistries(w http.ResponseWriter, r *http.Request) {
 	db := database.GetDB()
 	registries, err := models.DisplayRegistries(db)
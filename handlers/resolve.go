@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/ngageoint/seed-common/util"
+	"github.com/ngageoint/seed-silo/database"
+	"github.com/ngageoint/seed-silo/models"
+	"github.com/ngageoint/seed-silo/registry"
+)
+
+func Resolve(w http.ResponseWriter, r *http.Request) {
+	db := database.GetDB()
+	params := r.URL.Query()
+
+	query := models.ResolveQuery{
+		Job:            params.Get("job"),
+		Namespace:      params.Get("namespace"),
+		Version:        params.Get("version"),
+		PackageVersion: params.Get("packageVersion"),
+		Registries:     models.SplitList(params.Get("registry")),
+	}
+	if query.Job == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing job name")
+		return
+	}
+
+	resolution, err := resolveImage(db, query)
+	if err != nil {
+		respondWithError(w, resolveErrorCode(err), err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resolution)
+}
+
+//resolveErrorCode returns the response code of an error resolving a job: bad request for queries that can't be
+//resolved as given, not found if no image matches and an internal error otherwise
+func resolveErrorCode(err error) int {
+	switch err.(type) {
+	case models.InvalidConstraintError, models.AmbiguousJobError:
+		return http.StatusBadRequest
+	}
+	if err == models.ErrNoMatch {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+//resolveImage resolves the query against the catalog and pins the result to its current manifest digest. Without
+//registries in the query the default registry order only breaks ties between equal versions.
+func resolveImage(db *sql.DB, query models.ResolveQuery) (models.Resolution, error) {
+	query.Preferred = models.RegistryOrder()
+	resolution, err := models.ResolveJob(db, query)
+	if err != nil {
+		return resolution, err
+	}
+	return pinDigest(db, resolution), nil
+}
+
+func pinDigest(db *sql.DB, resolution models.Resolution) models.Resolution {
+	digest, err := imageDigest(db, resolution.RegistryId, resolution.Registry, resolution.Org, resolution.Name)
+	if err != nil {
+		util.PrintUtil("ERROR: Unable to get digest for %s: %s\n", resolution.Reference, err.Error())
+		return resolution
+	}
+
+	resolution.Digest = digest
+	resolution.Reference = models.ImageReference(resolution.Registry, resolution.Org, resolution.Name, digest)
+	return resolution
+}
+
+//imageDigest looks up the current manifest digest of an image in its registry, given by its id and the host the image
+//is pulled from
+func imageDigest(db *sql.DB, registryId int, host, org, fullName string) (string, error) {
+	info, err := models.GetRegistry(db, registryId)
+	if err != nil {
+		return "", err
+	}
+
+	reg, err := createImageRegistry(info, host, org)
+	if err != nil {
+		return "", err
+	}
+
+	repo, tag := models.SplitImageName(fullName)
+	return registry.ImageDigest(reg, org, repo, tag)
+}
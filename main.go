@@ -37,6 +37,13 @@ func main() {
         defer db.Close()
 	}
 
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
 	router, err := route.NewRouter()
 	util.InitPrinter(util.PrintLog, nil, nil)
 
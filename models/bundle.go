@@ -0,0 +1,80 @@
+package models
+
+import (
+	"path"
+	"strings"
+)
+
+//BundleVersion is the version of the bundle format written by /bundles
+const BundleVersion = 1
+
+//Files and directories of a bundle
+const (
+	BundleCatalogFile = "catalog.json"
+	BundleLayoutDir   = "oci"
+	BundleSeedDir     = "seed"
+)
+
+type BundleRequest struct {
+	JobVersions []int
+}
+
+//BundleCatalog is the catalog export included in a bundle, listing the images of its image layout
+type BundleCatalog struct {
+	BundleVersion int
+	Created       string
+	Images        []BundleImage
+}
+
+//BundleImage is the catalog entry of an image in a bundle. Ref is the name of the image in the bundle's image layout
+//and SeedManifest the bundle file holding its seed manifest.
+type BundleImage struct {
+	Name           string
+	Registry       string
+	Org            string
+	JobName        string
+	JobVersion     string
+	PackageVersion string
+	Digest         string
+	ConfigDigest   string
+	Size           int64
+	Created        string
+	Platform       string
+	Labels         map[string]string
+	Ref            string
+	SeedManifest   string
+}
+
+//NewBundleImage creates the bundle entry of a catalog image. The image is named in the bundle by its repository name
+//without any namespace and its tag.
+func NewBundleImage(img Image) BundleImage {
+	repo, tag := SplitImageName(img.FullName)
+	ref := path.Base(repo) + ":" + tag
+
+	return BundleImage{
+		Name:           img.FullName,
+		Registry:       img.Registry,
+		Org:            img.Org,
+		JobName:        img.ShortName,
+		JobVersion:     img.JobVersion,
+		PackageVersion: img.PackageVersion,
+		Digest:         img.Digest,
+		ConfigDigest:   img.ConfigDigest,
+		Size:           img.Size,
+		Created:        img.Created,
+		Platform:       img.Platform,
+		Labels:         img.Labels,
+		Ref:            ref,
+		SeedManifest:   path.Join(BundleSeedDir, strings.Replace(ref, ":", "_", 1)+".json"),
+	}
+}
+
+//Image returns the catalog image for a bundle image pushed to the given registry under its ref name
+func (b BundleImage) Image(reg RegistryInfo, manifest string) Image {
+	img := Image{FullName: b.Ref, Registry: reg.Url, Org: reg.Org, Manifest: manifest, RegistryId: reg.ID,
+		Digest: b.Digest, ConfigDigest: b.ConfigDigest, Size: b.Size, Created: b.Created, Platform: b.Platform,
+		Labels: b.Labels}
+	SetSeedInfo(&img)
+
+	return img
+}
@@ -0,0 +1,330 @@
+package models
+
+import (
+	"bufio"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+//CatalogSchemaVersion is the version of the catalog export format
+const CatalogSchemaVersion = 1
+
+//Kinds of catalog records
+const (
+	CatalogHeader     = "header"
+	CatalogRegistry   = "registry"
+	CatalogImage      = "image"
+	CatalogJob        = "job"
+	CatalogJobVersion = "jobVersion"
+	CatalogUser       = "user"
+)
+
+//Catalog import modes. Merge adds to the catalog, replacing images with the same registry and name; replace removes
+//registries and images missing from the import.
+const (
+	CatalogMerge   = "merge"
+	CatalogReplace = "replace"
+)
+
+//CatalogRecord is a line of a catalog export. The first record is the header giving the schema version; each other
+//record holds the item of its kind.
+type CatalogRecord struct {
+	Kind          string
+	SchemaVersion int              `json:",omitempty"`
+	Created       string           `json:",omitempty"`
+	Registry      *DisplayRegistry `json:",omitempty"`
+	Image         *ExportedImage   `json:",omitempty"`
+	Job           *Job             `json:",omitempty"`
+	JobVersion    *JobVersion      `json:",omitempty"`
+	User          *DisplayUser     `json:",omitempty"`
+}
+
+//ExportedImage is an image in a catalog export. The job fields are read from the seed manifest on import.
+type ExportedImage struct {
+	ID           int
+	RegistryId   int
+	Name         string
+	Registry     string
+	Org          string
+	Digest       string
+	ConfigDigest string
+	Size         int64
+	Created      string
+	Platform     string
+	Labels       map[string]string
+	Manifest     string
+}
+
+//CatalogImportResult counts what an import added to the catalog
+type CatalogImportResult struct {
+	Mode              string
+	RegistriesAdded   int
+	RegistriesRemoved int
+	Images            int
+	UsersAdded        int
+	RecordsIgnored    int //job and job version records, which are rebuilt from the images
+}
+
+//BuildImageIndexes rebuilds the tables derived from the image table once images have been stored
+func BuildImageIndexes(db Queryer) {
+	BuildInterfaceIndex(db)
+	BuildResourceIndex(db)
+	BuildPolicyFindings(db)
+}
+
+//ExportCatalog writes the registries without credentials, images, jobs, job versions and users without passwords as
+//JSON Lines, starting with a header giving the schema version
+func ExportCatalog(db *sql.DB, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	err := enc.Encode(CatalogRecord{Kind: CatalogHeader, SchemaVersion: CatalogSchemaVersion,
+		Created: time.Now().UTC().Format(time.RFC3339)})
+	if err != nil {
+		return err
+	}
+
+	registries, err := DisplayRegistries(db)
+	if err != nil {
+		return err
+	}
+	for i := range registries {
+		if err = enc.Encode(CatalogRecord{Kind: CatalogRegistry, Registry: &registries[i]}); err != nil {
+			return err
+		}
+	}
+
+	for _, img := range ReadImages(db) {
+		exported := ExportedImage{ID: img.ID, RegistryId: img.RegistryId, Name: img.FullName, Registry: img.Registry,
+			Org: img.Org, Digest: img.Digest, ConfigDigest: img.ConfigDigest, Size: img.Size, Created: img.Created,
+			Platform: img.Platform, Labels: img.Labels, Manifest: img.Manifest}
+		if err = enc.Encode(CatalogRecord{Kind: CatalogImage, Image: &exported}); err != nil {
+			return err
+		}
+	}
+
+	jobs := ReadJobs(db)
+	for i := range jobs {
+		jobs[i].JobVersions = nil
+		if err = enc.Encode(CatalogRecord{Kind: CatalogJob, Job: &jobs[i]}); err != nil {
+			return err
+		}
+	}
+
+	jobVersions := ReadJobVersions(db)
+	for i := range jobVersions {
+		jobVersions[i].Images = nil
+		if err = enc.Encode(CatalogRecord{Kind: CatalogJobVersion, JobVersion: &jobVersions[i]}); err != nil {
+			return err
+		}
+	}
+
+	users, err := DisplayUsers(db)
+	if err != nil {
+		return err
+	}
+	for i := range users {
+		if err = enc.Encode(CatalogRecord{Kind: CatalogUser, User: &users[i]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Queryer is implemented by both *sql.DB and *sql.Tx, so catalog changes can be made inside a transaction
+type Queryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Prepare(query string) (*sql.Stmt, error)
+}
+
+//ImportCatalog reads a catalog export and adds it to the catalog. Registries are matched by name; those already in
+//the catalog keep their url, org and credentials and new ones are added without credentials. Jobs and job versions
+//are rebuilt from the images as a scan does. Users are only ever added, with a random password, so an import cannot
+//lock out an admin. The export is read and checked completely before the catalog is changed, and the changes are made
+//in a single transaction so a failed import leaves the catalog as it was.
+func ImportCatalog(db *sql.DB, dbType string, r io.Reader, mode string) (CatalogImportResult, error) {
+	result := CatalogImportResult{Mode: mode}
+	if mode != CatalogMerge && mode != CatalogReplace {
+		return result, fmt.Errorf("Unknown import mode %s", mode)
+	}
+
+	registries := []DisplayRegistry{}
+	images := []ExportedImage{}
+	users := []DisplayUser{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		record := CatalogRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return result, fmt.Errorf("Line %d: %s", line, err.Error())
+		}
+		if line == 1 {
+			if record.Kind != CatalogHeader {
+				return result, errors.New("Missing catalog header")
+			}
+			if record.SchemaVersion > CatalogSchemaVersion {
+				return result, fmt.Errorf("Unsupported catalog schema version %d", record.SchemaVersion)
+			}
+			continue
+		}
+
+		switch {
+		case record.Kind == CatalogRegistry && record.Registry != nil:
+			registries = append(registries, *record.Registry)
+		case record.Kind == CatalogImage && record.Image != nil:
+			images = append(images, *record.Image)
+		case record.Kind == CatalogUser && record.User != nil:
+			users = append(users, *record.User)
+		case record.Kind == CatalogJob || record.Kind == CatalogJobVersion:
+			result.RecordsIgnored++
+		default:
+			return result, fmt.Errorf("Line %d: unknown %s record", line, record.Kind)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return result, err
+	}
+	if line == 0 {
+		return result, errors.New("Missing catalog header")
+	}
+
+	exportedRegistries := map[int]bool{}
+	for _, reg := range registries {
+		exportedRegistries[reg.ID] = true
+	}
+	for _, exported := range images {
+		if !exportedRegistries[exported.RegistryId] {
+			return result, fmt.Errorf("No registry record for image %s", exported.Name)
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+	if err = importCatalog(tx, dbType, registries, images, users, mode, &result); err != nil {
+		return CatalogImportResult{Mode: mode}, err
+	}
+	return result, tx.Commit()
+}
+
+//importCatalog makes the changes of an import whose records have all been checked
+func importCatalog(db Queryer, dbType string, registries []DisplayRegistry, images []ExportedImage,
+	users []DisplayUser, mode string, result *CatalogImportResult) error {
+	registryIds, err := importRegistries(db, dbType, registries, mode, result)
+	if err != nil {
+		return err
+	}
+
+	catalogImages := []Image{}
+	for _, exported := range images {
+		img := Image{RegistryId: registryIds[exported.RegistryId], FullName: exported.Name,
+			Registry: exported.Registry, Org: exported.Org, Manifest: exported.Manifest, Digest: exported.Digest,
+			ConfigDigest: exported.ConfigDigest, Size: exported.Size, Created: exported.Created,
+			Platform: exported.Platform, Labels: exported.Labels}
+		SetSeedInfo(&img)
+		catalogImages = append(catalogImages, img)
+	}
+	if mode == CatalogReplace {
+		if err = ResetImageTable(db, dbType); err != nil {
+			return err
+		}
+	}
+	if err = MergeImages(db, catalogImages, dbType); err != nil {
+		return err
+	}
+	result.Images = len(catalogImages)
+
+	return importUsers(db, dbType, users, result)
+}
+
+//importRegistries adds the registries missing from the catalog, removing any not imported in replace mode, and maps
+//the exported registry ids to the ids in the catalog
+func importRegistries(db Queryer, dbType string, registries []DisplayRegistry, mode string,
+	result *CatalogImportResult) (map[int]int, error) {
+	existing, err := GetRegistries(db)
+	if err != nil {
+		return nil, err
+	}
+	byName := map[string]int{}
+	for _, reg := range existing {
+		byName[reg.Name] = reg.ID
+	}
+
+	ids := map[int]int{}
+	imported := map[string]bool{}
+	for _, reg := range registries {
+		imported[reg.Name] = true
+		if id, ok := byName[reg.Name]; ok {
+			ids[reg.ID] = id
+			continue
+		}
+		info := RegistryInfo{Name: reg.Name, Url: reg.Url, Org: reg.Org, Orgs: reg.Orgs, Repositories: reg.Repositories}
+		var id int
+		if dbType == "postgres" {
+			id, err = AddRegistryPg(db, info)
+		} else {
+			id, err = AddRegistryLite(db, info)
+		}
+		if err != nil {
+			return nil, err
+		}
+		byName[reg.Name] = id
+		ids[reg.ID] = id
+		result.RegistriesAdded++
+	}
+
+	if mode == CatalogReplace {
+		for _, reg := range existing {
+			if imported[reg.Name] {
+				continue
+			}
+			if err = DeleteRegistryImages(db, reg.ID); err != nil {
+				return nil, err
+			}
+			if err = DeleteRegistry(db, reg.ID); err != nil {
+				return nil, err
+			}
+			result.RegistriesRemoved++
+		}
+	}
+	return ids, nil
+}
+
+//importUsers adds the users missing from the catalog with a random password, which must be replaced before they can
+//log in
+func importUsers(db Queryer, dbType string, users []DisplayUser, result *CatalogImportResult) error {
+	for _, user := range users {
+		if _, err := GetUserByName(db, user.Username); err == nil {
+			continue
+		}
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		newUser := SiloUser{Username: user.Username, Password: hex.EncodeToString(secret), Role: user.Role}
+		var err error
+		if dbType == "postgres" {
+			_, err = AddUserPg(db, newUser)
+		} else {
+			_, err = AddUserLite(db, newUser)
+		}
+		if err != nil {
+			return err
+		}
+		result.UsersAdded++
+	}
+	return nil
+}
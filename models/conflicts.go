@@ -0,0 +1,109 @@
+package models
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+)
+
+//Ways in which same-named jobs can disagree
+const (
+	ConflictMaintainer = "maintainer"
+	ConflictInterface  = "interface"
+)
+
+//JobSource is the latest package of a job name published in one registry and org
+type JobSource struct {
+	JobId          int
+	ImageId        int
+	Namespace      string
+	RegistryId     int
+	Registry       string
+	Org            string
+	Maintainer     string
+	Email          string
+	JobVersion     string
+	PackageVersion string
+}
+
+//JobConflict lists the registries and orgs publishing a job name whose manifests disagree on maintainer or interface.
+//The interface is only compared between packages with the same job version.
+type JobConflict struct {
+	Name      string
+	Conflicts []string
+	Sources   []JobSource
+}
+
+//JobConflicts finds job names published in several registries or orgs that look like unrelated jobs
+func JobConflicts(db *sql.DB) []JobConflict {
+	type source struct {
+		JobSource
+		versions map[string]Image //latest package of each job version
+	}
+
+	policy := JobMergePolicy()
+	names := map[string]map[string]*source{}
+	for _, img := range ReadImages(db) {
+		key := img.Registry + "/" + img.Org
+		if names[img.ShortName] == nil {
+			names[img.ShortName] = map[string]*source{}
+		}
+		src, ok := names[img.ShortName][key]
+		if !ok {
+			src = &source{versions: map[string]Image{}}
+			names[img.ShortName][key] = src
+		}
+		if !ok || CompareVersions(img.JobVersion, src.JobVersion) > 0 ||
+			(img.JobVersion == src.JobVersion && CompareVersions(img.PackageVersion, src.PackageVersion) > 0) {
+			src.JobSource = JobSource{JobId: img.JobId, ImageId: img.ID, Namespace: JobNamespace(img, policy),
+				RegistryId: img.RegistryId, Registry: img.Registry, Org: img.Org, Maintainer: img.Maintainer,
+				Email: img.Email, JobVersion: img.JobVersion, PackageVersion: img.PackageVersion}
+		}
+		latest, ok := src.versions[img.JobVersion]
+		if !ok || CompareVersions(img.PackageVersion, latest.PackageVersion) > 0 {
+			src.versions[img.JobVersion] = img
+		}
+	}
+
+	result := []JobConflict{}
+	for name, sources := range names {
+		if len(sources) < 2 {
+			continue
+		}
+		keys := []string{}
+		for key := range sources {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		conflict := JobConflict{Name: name, Conflicts: []string{}, Sources: []JobSource{}}
+		maintainer, iface := false, false
+		first := sources[keys[0]]
+		for i, key := range keys {
+			src := sources[key]
+			conflict.Sources = append(conflict.Sources, src.JobSource)
+			if src.Maintainer != first.Maintainer || !strings.EqualFold(src.Email, first.Email) {
+				maintainer = true
+			}
+			for _, other := range keys[:i] {
+				for version, img := range src.versions {
+					if otherImg, ok := sources[other].versions[version]; ok && len(DiffManifests(otherImg.Seed, img.Seed)) > 0 {
+						iface = true
+					}
+				}
+			}
+		}
+		if maintainer {
+			conflict.Conflicts = append(conflict.Conflicts, ConflictMaintainer)
+		}
+		if iface {
+			conflict.Conflicts = append(conflict.Conflicts, ConflictInterface)
+		}
+		if len(conflict.Conflicts) > 0 {
+			result = append(result, conflict)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
@@ -0,0 +1,321 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ngageoint/seed-common/objects"
+)
+
+//Kinds of manifest change
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+//Manifest sections a change can be in
+const (
+	SectionJob        = "job"
+	SectionInput      = "input"
+	SectionJsonInput  = "json-input"
+	SectionOutput     = "output"
+	SectionJsonOutput = "json-output"
+	SectionSetting    = "setting"
+	SectionMount      = "mount"
+	SectionResource   = "resource"
+	SectionError      = "error"
+)
+
+//ManifestChange is a difference between two seed manifests. Field is set for changed items. A breaking change may
+//require callers of the job, or jobs consuming its outputs, to change.
+type ManifestChange struct {
+	Kind     string
+	Section  string
+	Name     string
+	Field    string `json:",omitempty"`
+	From     string `json:",omitempty"`
+	To       string `json:",omitempty"`
+	Breaking bool
+}
+
+//DiffSide identifies one of the manifests being compared
+type DiffSide struct {
+	ImageId        int `json:",omitempty"`
+	JobVersionId   int `json:",omitempty"`
+	Job            string
+	JobVersion     string
+	PackageVersion string
+}
+
+type ManifestDiff struct {
+	From     DiffSide
+	To       DiffSide
+	Breaking bool
+	Changes  []ManifestChange
+}
+
+//DiffManifests lists the changes from one seed manifest to another
+func DiffManifests(from, to objects.Seed) []ManifestChange {
+	changes := []ManifestChange{}
+	add := func(kind, section, name, field, fromValue, toValue string, breaking bool) {
+		changes = append(changes, ManifestChange{Kind: kind, Section: section, Name: name, Field: field,
+			From: fromValue, To: toValue, Breaking: breaking})
+	}
+	changed := func(section, name, field, fromValue, toValue string, breaking bool) {
+		if fromValue != toValue {
+			add(ChangeChanged, section, name, field, fromValue, toValue, breaking)
+		}
+	}
+	fb := strconv.FormatBool
+
+	changed(SectionJob, from.Job.Name, "name", from.Job.Name, to.Job.Name, true)
+	changed(SectionJob, to.Job.Name, "command", from.Job.Interface.Command, to.Job.Interface.Command, false)
+	changed(SectionJob, to.Job.Name, "timeout", strconv.Itoa(from.Job.Timeout), strconv.Itoa(to.Job.Timeout),
+		to.Job.Timeout < from.Job.Timeout)
+
+	// inputs: anything a caller did not have to provide before breaks them
+	fromFiles := map[string]objects.InFile{}
+	for _, in := range from.Job.Interface.Inputs.Files {
+		fromFiles[in.Name] = in
+	}
+	for _, in := range to.Job.Interface.Inputs.Files {
+		old, ok := fromFiles[in.Name]
+		if !ok {
+			add(ChangeAdded, SectionInput, in.Name, "", "", "", in.Required)
+			continue
+		}
+		delete(fromFiles, in.Name)
+		changed(SectionInput, in.Name, "required", fb(old.Required), fb(in.Required), in.Required)
+		changed(SectionInput, in.Name, "multiple", fb(old.Multiple), fb(in.Multiple), old.Multiple)
+		changed(SectionInput, in.Name, "partial", fb(old.Partial), fb(in.Partial), false)
+		changed(SectionInput, in.Name, "mediaTypes", strings.Join(old.MediaTypes, ", "),
+			strings.Join(in.MediaTypes, ", "), !mediaTypesCovered(old.MediaTypes, in.MediaTypes))
+	}
+	for _, in := range from.Job.Interface.Inputs.Files {
+		if _, ok := fromFiles[in.Name]; ok {
+			add(ChangeRemoved, SectionInput, in.Name, "", "", "", true)
+		}
+	}
+
+	fromJson := map[string]objects.InJson{}
+	for _, in := range from.Job.Interface.Inputs.Json {
+		fromJson[in.Name] = in
+	}
+	for _, in := range to.Job.Interface.Inputs.Json {
+		old, ok := fromJson[in.Name]
+		if !ok {
+			add(ChangeAdded, SectionJsonInput, in.Name, "", "", "", in.Required)
+			continue
+		}
+		delete(fromJson, in.Name)
+		changed(SectionJsonInput, in.Name, "type", old.Type, in.Type, true)
+		changed(SectionJsonInput, in.Name, "required", fb(old.Required), fb(in.Required), in.Required)
+	}
+	for _, in := range from.Job.Interface.Inputs.Json {
+		if _, ok := fromJson[in.Name]; ok {
+			add(ChangeRemoved, SectionJsonInput, in.Name, "", "", "", true)
+		}
+	}
+
+	// outputs: anything consumers could rely on before that is no longer guaranteed breaks them
+	fromOutputs := map[string]objects.OutFile{}
+	for _, out := range from.Job.Interface.Outputs.Files {
+		fromOutputs[out.Name] = out
+	}
+	for _, out := range to.Job.Interface.Outputs.Files {
+		old, ok := fromOutputs[out.Name]
+		if !ok {
+			add(ChangeAdded, SectionOutput, out.Name, "", "", "", false)
+			continue
+		}
+		delete(fromOutputs, out.Name)
+		changed(SectionOutput, out.Name, "mediaType", old.MediaType, out.MediaType, true)
+		changed(SectionOutput, out.Name, "multiple", fb(old.Multiple), fb(out.Multiple), true)
+		changed(SectionOutput, out.Name, "required", fb(old.Required), fb(out.Required), old.Required)
+		changed(SectionOutput, out.Name, "pattern", old.Pattern, out.Pattern, false)
+	}
+	for _, out := range from.Job.Interface.Outputs.Files {
+		if _, ok := fromOutputs[out.Name]; ok {
+			add(ChangeRemoved, SectionOutput, out.Name, "", "", "", true)
+		}
+	}
+
+	fromJsonOutputs := map[string]objects.OutJson{}
+	for _, out := range from.Job.Interface.Outputs.JSON {
+		fromJsonOutputs[out.Name] = out
+	}
+	for _, out := range to.Job.Interface.Outputs.JSON {
+		old, ok := fromJsonOutputs[out.Name]
+		if !ok {
+			add(ChangeAdded, SectionJsonOutput, out.Name, "", "", "", false)
+			continue
+		}
+		delete(fromJsonOutputs, out.Name)
+		changed(SectionJsonOutput, out.Name, "type", old.Type, out.Type, true)
+		changed(SectionJsonOutput, out.Name, "key", old.Key, out.Key, false)
+		changed(SectionJsonOutput, out.Name, "required", fb(old.Required), fb(out.Required), old.Required)
+	}
+	for _, out := range from.Job.Interface.Outputs.JSON {
+		if _, ok := fromJsonOutputs[out.Name]; ok {
+			add(ChangeRemoved, SectionJsonOutput, out.Name, "", "", "", true)
+		}
+	}
+
+	fromSettings := map[string]objects.Setting{}
+	for _, s := range from.Job.Interface.Settings {
+		fromSettings[s.Name] = s
+	}
+	for _, s := range to.Job.Interface.Settings {
+		old, ok := fromSettings[s.Name]
+		if !ok {
+			add(ChangeAdded, SectionSetting, s.Name, "", "", "", true)
+			continue
+		}
+		delete(fromSettings, s.Name)
+		changed(SectionSetting, s.Name, "secret", fb(old.Secret), fb(s.Secret), false)
+	}
+	for _, s := range from.Job.Interface.Settings {
+		if _, ok := fromSettings[s.Name]; ok {
+			add(ChangeRemoved, SectionSetting, s.Name, "", "", "", false)
+		}
+	}
+
+	fromMounts := map[string]objects.Mount{}
+	for _, m := range from.Job.Interface.Mounts {
+		fromMounts[m.Name] = m
+	}
+	for _, m := range to.Job.Interface.Mounts {
+		old, ok := fromMounts[m.Name]
+		if !ok {
+			add(ChangeAdded, SectionMount, m.Name, "", "", "", true)
+			continue
+		}
+		delete(fromMounts, m.Name)
+		changed(SectionMount, m.Name, "path", old.Path, m.Path, false)
+		changed(SectionMount, m.Name, "mode", old.Mode, m.Mode, m.Mode == "rw")
+	}
+	for _, m := range from.Job.Interface.Mounts {
+		if _, ok := fromMounts[m.Name]; ok {
+			add(ChangeRemoved, SectionMount, m.Name, "", "", "", false)
+		}
+	}
+
+	// resources: requiring more may stop the job fitting on the nodes it ran on before
+	fromResources := map[string]ScalarResource{}
+	for _, r := range from.Job.Resources.Scalar {
+		fromResources[r.Name] = ScalarResource{Name: r.Name, Value: r.Value, InputMultiplier: r.InputMultiplier}
+	}
+	ff := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+	for _, r := range to.Job.Resources.Scalar {
+		old, ok := fromResources[r.Name]
+		if !ok {
+			add(ChangeAdded, SectionResource, r.Name, "", "", ff(r.Value), r.Value > 0 || r.InputMultiplier > 0)
+			continue
+		}
+		delete(fromResources, r.Name)
+		changed(SectionResource, r.Name, "value", ff(old.Value), ff(r.Value), r.Value > old.Value)
+		changed(SectionResource, r.Name, "inputMultiplier", ff(old.InputMultiplier), ff(r.InputMultiplier),
+			r.InputMultiplier > old.InputMultiplier)
+	}
+	for _, r := range from.Job.Resources.Scalar {
+		if _, ok := fromResources[r.Name]; ok {
+			add(ChangeRemoved, SectionResource, r.Name, "", ff(r.Value), "", false)
+		}
+	}
+
+	// errors: callers may handle exit codes, so removing or redefining one breaks them
+	fromErrors := map[string]objects.ErrorMap{}
+	for _, e := range from.Job.Errors {
+		fromErrors[strconv.Itoa(e.Code)] = e
+	}
+	for _, e := range to.Job.Errors {
+		code := strconv.Itoa(e.Code)
+		old, ok := fromErrors[code]
+		if !ok {
+			add(ChangeAdded, SectionError, code, "", "", e.Name, false)
+			continue
+		}
+		delete(fromErrors, code)
+		changed(SectionError, code, "name", old.Name, e.Name, true)
+		changed(SectionError, code, "category", old.Category, e.Category, true)
+		changed(SectionError, code, "title", old.Title, e.Title, false)
+	}
+	for _, e := range from.Job.Errors {
+		if _, ok := fromErrors[strconv.Itoa(e.Code)]; ok {
+			add(ChangeRemoved, SectionError, strconv.Itoa(e.Code), "", e.Name, "", true)
+		}
+	}
+
+	return changes
+}
+
+//mediaTypesCovered returns true if every media type accepted before is still accepted
+func mediaTypesCovered(from, to []string) bool {
+	if len(to) == 0 {
+		return true
+	}
+	if len(from) == 0 {
+		return false
+	}
+	for _, f := range from {
+		covered := false
+		for _, t := range to {
+			if MediaTypeMatches(f, t) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+//NewManifestDiff compares the manifests of two images or job versions
+func NewManifestDiff(from, to DiffSide, fromSeed, toSeed objects.Seed) ManifestDiff {
+	diff := ManifestDiff{From: from, To: to, Changes: DiffManifests(fromSeed, toSeed)}
+	for _, c := range diff.Changes {
+		if c.Breaking {
+			diff.Breaking = true
+		}
+	}
+	return diff
+}
+
+func (s DiffSide) String() string {
+	return fmt.Sprintf("%s %s (package %s)", s.Job, s.JobVersion, s.PackageVersion)
+}
+
+//Text renders the diff in the style of a unified diff with one line per removed or added item and a pair of lines
+//for each changed field
+func (d ManifestDiff) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", d.From, d.To)
+	for _, c := range d.Changes {
+		suffix := ""
+		if c.Breaking {
+			suffix = "  # breaking"
+		}
+		name := c.Section + " " + c.Name
+		switch c.Kind {
+		case ChangeAdded:
+			fmt.Fprintf(&b, "+%s%s\n", withValue(name, c.To), suffix)
+		case ChangeRemoved:
+			fmt.Fprintf(&b, "-%s%s\n", withValue(name, c.From), suffix)
+		default:
+			fmt.Fprintf(&b, "-%s %s: %s%s\n", name, c.Field, c.From, suffix)
+			fmt.Fprintf(&b, "+%s %s: %s%s\n", name, c.Field, c.To, suffix)
+		}
+	}
+	return b.String()
+}
+
+func withValue(name, value string) string {
+	if value == "" {
+		return name
+	}
+	return name + ": " + value
+}
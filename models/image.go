@@ -11,22 +11,30 @@ import (
 )
 
 type Image struct {
-	ID             int    `db:"id"`
-	RegistryId     int    `db:"registry_id"`
-	JobId          int    `db:"job_id"`
-	JobVersionId   int    `db:"job_version_id"`
-	FullName       string `db:"full_name"`  //full name from registry (may include org et. al.)
-	ShortName      string `db:"short_name"` //job name from seed manifest
-	Title          string `db:"title"`
-	Maintainer     string `db:"maintainer"`
-	Email          string `db:"email"`
-	MaintOrg       string `db:"maint_org"`
-	JobVersion     string `db:"job_version"`
-	PackageVersion string `db:"package_version"`
-	Description    string `db:"description"`
-	Registry       string `db:"registry"`
-	Org            string `db:"org"`
-	Manifest       string `db:"manifest"`
+	ID             int               `db:"id"`
+	RegistryId     int               `db:"registry_id"`
+	JobId          int               `db:"job_id"`
+	JobVersionId   int               `db:"job_version_id"`
+	FullName       string            `db:"full_name"`  //full name from registry (may include org et. al.)
+	ShortName      string            `db:"short_name"` //job name from seed manifest
+	Title          string            `db:"title"`
+	Maintainer     string            `db:"maintainer"`
+	Email          string            `db:"email"`
+	MaintOrg       string            `db:"maint_org"`
+	JobVersion     string            `db:"job_version"`
+	PackageVersion string            `db:"package_version"`
+	Description    string            `db:"description"`
+	Registry       string            `db:"registry"`
+	Org            string            `db:"org"`
+	Manifest       string            `db:"manifest"`
+	Digest         string            `db:"digest"` //manifest digest
+	ConfigDigest   string            `db:"config_digest"`
+	Size           int64             `db:"size"`    //total compressed size of the layers
+	Created        string            `db:"created"` //from the image config
+	Platform       string            `db:"platform"`
+	Labels         map[string]string `db:"labels"`
+	CanonicalId    int               //image representing the tags of the repository pointing to the same manifest
+	Tags           []string          //tags of the repository pointing to the same manifest
 	Seed           objects.Seed
 }
 
@@ -44,6 +52,11 @@ type SimpleImage struct {
 	Description    string
 	JobVersion     string
 	PackageVersion string
+	Digest         string
+	Size           int64
+	Created        string
+	Platform       string
+	Mirrors        []Mirror `json:",omitempty"` //set when search results are collapsed
 }
 
 func SimplifyImage(img Image) SimpleImage {
@@ -61,10 +74,30 @@ func SimplifyImage(img Image) SimpleImage {
 	simple.Description = img.Description
 	simple.JobVersion = img.JobVersion
 	simple.PackageVersion = img.PackageVersion
+	simple.Digest = img.Digest
+	simple.Size = img.Size
+	simple.Created = img.Created
+	simple.Platform = img.Platform
 
 	return simple
 }
 
+//SetSeedInfo unmarshals the seed manifest of an image and sets the job fields read from it
+func SetSeedInfo(img *Image) {
+	err := json.Unmarshal([]byte(img.Manifest), &img.Seed)
+	if err != nil {
+		log.Printf("Error unmarshalling seed manifest for %s: %s \n", img.FullName, err.Error())
+	}
+	img.ShortName = img.Seed.Job.Name
+	img.Title = img.Seed.Job.Title
+	img.Maintainer = img.Seed.Job.Maintainer.Name
+	img.Email = img.Seed.Job.Maintainer.Email
+	img.MaintOrg = img.Seed.Job.Maintainer.Organization
+	img.JobVersion = img.Seed.Job.JobVersion
+	img.PackageVersion = img.Seed.Job.PackageVersion
+	img.Description = img.Seed.Job.Description
+}
+
 func CreateImageTable(db *sql.DB, dbType string) {
 	// create table if it does not exist
 	sql_table := `
@@ -85,6 +118,12 @@ func CreateImageTable(db *sql.DB, dbType string) {
 		registry TEXT,
 		org TEXT,
 		manifest TEXT,
+		digest TEXT NOT NULL DEFAULT '',
+		config_digest TEXT NOT NULL DEFAULT '',
+		size BIGINT NOT NULL DEFAULT 0,
+		created TEXT NOT NULL DEFAULT '',
+		platform TEXT NOT NULL DEFAULT '',
+		labels TEXT NOT NULL DEFAULT '{}',
 		CONSTRAINT fk_inv_registry_id
 		    FOREIGN KEY (registry_id)
 		    REFERENCES RegistryInfo (id)
@@ -108,9 +147,47 @@ func CreateImageTable(db *sql.DB, dbType string) {
 	if err != nil {
 		panic(err)
 	}
+
+	migrateImageTable(db)
 }
 
-func ResetImageTable(db *sql.DB, dbType string) error {
+//imageMetadataColumns are the columns added to existing image tables for registry metadata
+var imageMetadataColumns = []string{
+	"digest TEXT NOT NULL DEFAULT ''",
+	"config_digest TEXT NOT NULL DEFAULT ''",
+	"size BIGINT NOT NULL DEFAULT 0",
+	"created TEXT NOT NULL DEFAULT ''",
+	"platform TEXT NOT NULL DEFAULT ''",
+	"labels TEXT NOT NULL DEFAULT '{}'",
+}
+
+//migrateImageTable adds the registry metadata columns to an image table created before they existed
+func migrateImageTable(db *sql.DB) {
+	if _, err := db.Exec("SELECT digest FROM Image LIMIT 1"); err == nil {
+		return
+	}
+
+	log.Print("Adding registry metadata to the image table")
+	for _, column := range imageMetadataColumns {
+		if _, err := db.Exec("ALTER TABLE Image ADD COLUMN " + column); err != nil {
+			panic(err)
+		}
+	}
+}
+
+//labelsJson serializes image labels for storage
+func labelsJson(labels map[string]string) string {
+	if labels == nil {
+		return "{}"
+	}
+	data, err := json.Marshal(labels)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+func ResetImageTable(db Queryer, dbType string) error {
     if dbType == "sqlite" {
         return ResetImageTableLite(db)
     } else if dbType == "postgres" {
@@ -120,7 +197,7 @@ func ResetImageTable(db *sql.DB, dbType string) error {
     }
 }
 
-func ResetImageTableLite(db *sql.DB) error {
+func ResetImageTableLite(db Queryer) error {
 	// delete all images and reset the counter
 	delete := `DELETE FROM Image;`
 
@@ -139,7 +216,7 @@ func ResetImageTableLite(db *sql.DB) error {
 	return err2
 }
 
-func ResetImageTablePG(db *sql.DB) error {
+func ResetImageTablePG(db Queryer) error {
 	// delete all images and reset the counter
 	delete := `TRUNCATE Image RESTART IDENTITY CASCADE;`
 
@@ -178,8 +255,14 @@ func StoreImagesLite(db *sql.DB, images []Image) {
 		description,
 		registry,
 		org,
-		manifest
-	) values(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		manifest,
+		digest,
+		config_digest,
+		size,
+		created,
+		platform,
+		labels
+	) values(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	stmt, err := db.Prepare(sql_addimg)
@@ -192,7 +275,8 @@ func StoreImagesLite(db *sql.DB, images []Image) {
 		_, err2 := stmt.Exec(img.RegistryId, img.JobId, img.JobVersionId, img.FullName,
 			img.ShortName, img.Title, img.Maintainer, img.Email, img.MaintOrg,
 			img.JobVersion, img.PackageVersion, img.Description, img.Registry,
-			img.Org, img.Manifest)
+			img.Org, img.Manifest, img.Digest, img.ConfigDigest, img.Size, img.Created,
+			img.Platform, labelsJson(img.Labels))
 		if err2 != nil {
 			panic(err2)
 		}
@@ -216,15 +300,22 @@ func StoreImagesPg(db *sql.DB, images []Image) {
 		description,
 		registry,
 		org,
-		manifest
-	) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15);
+		manifest,
+		digest,
+		config_digest,
+		size,
+		created,
+		platform,
+		labels
+	) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21);
 	`
 
 	for _, img := range images {
 		_, err := db.Exec(query, img.RegistryId, img.JobId, img.JobVersionId, img.FullName,
 			img.ShortName, img.Title, img.Maintainer, img.Email, img.MaintOrg,
 			img.JobVersion, img.PackageVersion, img.Description, img.Registry,
-			img.Org, img.Manifest)
+			img.Org, img.Manifest, img.Digest, img.ConfigDigest, img.Size, img.Created,
+			img.Platform, labelsJson(img.Labels))
 
 		if err != nil {
 			panic(err)
@@ -232,7 +323,7 @@ func StoreImagesPg(db *sql.DB, images []Image) {
 	}
 }
 
-func StoreOrUpdateImages(db *sql.DB, images []Image, dbType string) {
+func StoreOrUpdateImages(db Queryer, images []Image, dbType string) {
 	if dbType == "sqlite" {
 		StoreOrUpdateImagesLite(db, images)
 	} else if dbType == "postgres" {
@@ -242,7 +333,40 @@ func StoreOrUpdateImages(db *sql.DB, images []Image, dbType string) {
 	}
 }
 
-func StoreOrUpdateImagesLite(db *sql.DB, images []Image) {
+//MergeImages adds images to the catalog, replacing any with the same registry and name. Jobs are rebuilt from all
+//images and the tables derived from the images rebuilt, as a scan does.
+func MergeImages(db Queryer, images []Image, dbType string) error {
+	allImages := ReadImages(db)
+	for _, img := range images {
+		replaced := false
+		for i, existing := range allImages {
+			if existing.RegistryId == img.RegistryId && existing.FullName == img.FullName {
+				img.ID = existing.ID
+				allImages[i] = img
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			allImages = append(allImages, img)
+		}
+	}
+
+	if err := ResetJobTable(db, dbType); err != nil {
+		return err
+	}
+	if err := ResetJobVersionTable(db, dbType); err != nil {
+		return err
+	}
+	if len(allImages) > 0 {
+		BuildJobsList(db, &allImages, dbType)
+		StoreOrUpdateImages(db, allImages, dbType)
+	}
+	BuildImageIndexes(db)
+	return nil
+}
+
+func StoreOrUpdateImagesLite(db Queryer, images []Image) {
 	sql_add_img := `
 	INSERT INTO Image(
 	    registry_id,
@@ -259,8 +383,14 @@ func StoreOrUpdateImagesLite(db *sql.DB, images []Image) {
 		description,
 		registry,
 		org,
-		manifest
-	) values(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		manifest,
+		digest,
+		config_digest,
+		size,
+		created,
+		platform,
+		labels
+	) values(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	addStatement, err := db.Prepare(sql_add_img)
@@ -285,7 +415,13 @@ func StoreOrUpdateImagesLite(db *sql.DB, images []Image) {
 		description=?,
 		registry=?,
 		org=?,
-		manifest=?
+		manifest=?,
+		digest=?,
+		config_digest=?,
+		size=?,
+		created=?,
+		platform=?,
+		labels=?
 	WHERE id=?
 	`
 
@@ -299,14 +435,16 @@ func StoreOrUpdateImagesLite(db *sql.DB, images []Image) {
 		if img.ID != 0 {
 			_, err2 := updateStatement.Exec(img.RegistryId, img.JobId, img.JobVersionId, img.FullName,
 				img.ShortName, img.Title, img.Maintainer, img.Email, img.MaintOrg, img.JobVersion,
-				img.PackageVersion, img.Description, img.Registry, img.Org, img.Manifest, img.ID)
+				img.PackageVersion, img.Description, img.Registry, img.Org, img.Manifest, img.Digest,
+				img.ConfigDigest, img.Size, img.Created, img.Platform, labelsJson(img.Labels), img.ID)
 			if err2 != nil {
 				panic(err2)
 			}
 		} else {
 			_, err2 := addStatement.Exec(img.RegistryId, img.JobId, img.JobVersionId, img.FullName,
 				img.ShortName, img.Title, img.Maintainer, img.Email, img.MaintOrg, img.JobVersion,
-				img.PackageVersion, img.Description, img.Registry, img.Org, img.Manifest)
+				img.PackageVersion, img.Description, img.Registry, img.Org, img.Manifest, img.Digest,
+				img.ConfigDigest, img.Size, img.Created, img.Platform, labelsJson(img.Labels))
 			if err2 != nil {
 				panic(err2)
 			}
@@ -314,7 +452,7 @@ func StoreOrUpdateImagesLite(db *sql.DB, images []Image) {
 	}
 }
 
-func StoreOrUpdateImagesPg(db *sql.DB, images []Image) {
+func StoreOrUpdateImagesPg(db Queryer, images []Image) {
 	sql_add_img := `
 	INSERT INTO Image(
 	    registry_id,
@@ -331,8 +469,14 @@ func StoreOrUpdateImagesPg(db *sql.DB, images []Image) {
 		description,
 		registry,
 		org,
-		manifest
-	) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15);
+		manifest,
+		digest,
+		config_digest,
+		size,
+		created,
+		platform,
+		labels
+	) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21);
 	`
 
 	addStatement, err := db.Prepare(sql_add_img)
@@ -357,8 +501,14 @@ func StoreOrUpdateImagesPg(db *sql.DB, images []Image) {
 		description=$12,
 		registry=$13,
 		org=$14,
-		manifest=$15
-	WHERE id=$16
+		manifest=$15,
+		digest=$16,
+		config_digest=$17,
+		size=$18,
+		created=$19,
+		platform=$20,
+		labels=$21
+	WHERE id=$22
 	`
 
 	updateStatement, err := db.Prepare(sql_update_img)
@@ -372,7 +522,8 @@ func StoreOrUpdateImagesPg(db *sql.DB, images []Image) {
 			_, err := db.Exec(sql_update_img, img.RegistryId, img.JobId, img.JobVersionId, img.FullName,
 				img.ShortName, img.Title, img.Maintainer, img.Email, img.MaintOrg,
 				img.JobVersion, img.PackageVersion, img.Description, img.Registry,
-				img.Org, img.Manifest, img.ID)
+				img.Org, img.Manifest, img.Digest, img.ConfigDigest, img.Size, img.Created,
+				img.Platform, labelsJson(img.Labels), img.ID)
 
 			if err != nil {
 				panic(err)
@@ -381,7 +532,8 @@ func StoreOrUpdateImagesPg(db *sql.DB, images []Image) {
 			_, err := db.Exec(sql_add_img, img.RegistryId, img.JobId, img.JobVersionId, img.FullName,
 				img.ShortName, img.Title, img.Maintainer, img.Email, img.MaintOrg,
 				img.JobVersion, img.PackageVersion, img.Description, img.Registry,
-				img.Org, img.Manifest)
+				img.Org, img.Manifest, img.Digest, img.ConfigDigest, img.Size, img.Created,
+				img.Platform, labelsJson(img.Labels))
 
 			if err != nil {
 				panic(err)
@@ -390,7 +542,7 @@ func StoreOrUpdateImagesPg(db *sql.DB, images []Image) {
 	}
 }
 
-func ReadImages(db *sql.DB) []Image {
+func ReadImages(db Queryer) []Image {
 	sql_readall := `
 	SELECT * FROM Image
 	ORDER BY id ASC
@@ -405,12 +557,15 @@ func ReadImages(db *sql.DB) []Image {
 	var result []Image
 	for rows.Next() {
 		item := Image{}
+		var labels string
 		err2 := rows.Scan(&item.ID, &item.RegistryId, &item.JobId, &item.JobVersionId, &item.FullName,
 			&item.ShortName, &item.Title, &item.Maintainer, &item.Email, &item.MaintOrg, &item.JobVersion,
-			&item.PackageVersion, &item.Description, &item.Registry, &item.Org, &item.Manifest)
+			&item.PackageVersion, &item.Description, &item.Registry, &item.Org, &item.Manifest, &item.Digest,
+			&item.ConfigDigest, &item.Size, &item.Created, &item.Platform, &labels)
 		if err2 != nil {
 			panic(err2)
 		}
+		json.Unmarshal([]byte(labels), &item.Labels)
 
 		err2 = json.Unmarshal([]byte(item.Manifest), &item.Seed)
 		if err2 != nil {
@@ -442,11 +597,12 @@ func ReadSimpleImages(db *sql.DB) []SimpleImage {
 	for rows.Next() {
 		item := SimpleImage{}
 		img := Image{}
-		var manifest string
+		var manifest, configDigest, labels string
 		err2 := rows.Scan(&item.ID, &item.RegistryId, &img.JobId, &img.JobVersionId, &item.Name,
 			&item.JobName, &item.Title, &item.Maintainer, &item.Email, &item.MaintOrg,
 			&item.JobVersion, &item.PackageVersion, &item.Description,
-			&item.Registry, &item.Org, &manifest)
+			&item.Registry, &item.Org, &manifest, &item.Digest, &configDigest, &item.Size, &item.Created,
+			&item.Platform, &labels)
 		if err2 != nil {
 			panic(err2)
 		}
@@ -463,16 +619,19 @@ func ReadImage(db *sql.DB, id int) (Image, error) {
 	row := db.QueryRow("SELECT * FROM Image WHERE id=$1", id)
 
 	var result Image
+	var labels string
 	err := row.Scan(&result.ID, &result.RegistryId, &result.JobId, &result.JobVersionId,
 		&result.FullName, &result.ShortName, &result.Title, &result.Maintainer, &result.Email,
 		&result.MaintOrg, &result.JobVersion, &result.PackageVersion, &result.Description,
-		&result.Registry, &result.Org, &result.Manifest)
+		&result.Registry, &result.Org, &result.Manifest, &result.Digest, &result.ConfigDigest,
+		&result.Size, &result.Created, &result.Platform, &labels)
 
 	if err != nil {
 		util.PrintUtil("ERROR scanning in read image: %v", err.Error())
 	}
 
 	if err == nil {
+		json.Unmarshal([]byte(labels), &result.Labels)
 		err = json.Unmarshal([]byte(result.Manifest), &result.Seed)
 		if err != nil {
 			log.Printf("Error unmarshalling seed manifest for %s: %s \n", result.FullName, err.Error())
@@ -483,7 +642,7 @@ func ReadImage(db *sql.DB, id int) (Image, error) {
 	return result, err
 }
 
-func DeleteRegistryImages(db *sql.DB, registryId int) error {
+func DeleteRegistryImages(db Queryer, registryId int) error {
 	_, err := db.Exec("DELETE FROM Image WHERE registry_id=$1", registryId)
 
 	return err
@@ -536,10 +695,11 @@ func GetJobImages(db *sql.DB, jobid int) []SimpleImage {
 	for rows.Next() {
 		item := SimpleImage{}
 		img := Image{}
-		var manifest string
+		var manifest, configDigest, labels string
 		err2 := rows.Scan(&item.ID, &item.RegistryId, &img.JobId, &img.JobVersionId, &item.Name,
 			&item.JobName, &item.Title, &item.Maintainer, &item.Email, &item.MaintOrg,
-			&item.JobVersion, &item.PackageVersion, &item.Description, &item.Registry, &item.Org, &manifest)
+			&item.JobVersion, &item.PackageVersion, &item.Description, &item.Registry, &item.Org, &manifest,
+			&item.Digest, &configDigest, &item.Size, &item.Created, &item.Platform, &labels)
 		if err2 != nil {
 			panic(err2)
 		}
@@ -590,10 +750,11 @@ func GetJobVersionImages(db *sql.DB, jobversionid int) []SimpleImage {
 	for rows.Next() {
 		item := SimpleImage{}
 		img := Image{}
-		var manifest string
+		var manifest, configDigest, labels string
 		err2 := rows.Scan(&item.ID, &item.RegistryId, &img.JobId, &img.JobVersionId, &item.Name,
 			&item.JobName, &item.Title, &item.Maintainer, &item.Email, &item.MaintOrg,
-			&item.JobVersion, &item.PackageVersion, &item.Description, &item.Registry, &item.Org, &manifest)
+			&item.JobVersion, &item.PackageVersion, &item.Description, &item.Registry, &item.Org, &manifest,
+			&item.Digest, &configDigest, &item.Size, &item.Created, &item.Platform, &labels)
 		if err2 != nil {
 			panic(err2)
 		}
@@ -0,0 +1,197 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/ngageoint/seed-common/objects"
+)
+
+const JsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+//JsonSchema is a JSON Schema document or subschema
+type JsonSchema map[string]interface{}
+
+//InputError is a failed schema check. Path is a JSON pointer to the offending value.
+type InputError struct {
+	Path    string
+	Message string
+}
+
+type InputValidation struct {
+	Valid  bool
+	Errors []InputError
+}
+
+//seedJsonTypes maps seed json input types to JSON Schema types
+var seedJsonTypes = map[string]string{
+	"string":  "string",
+	"integer": "integer",
+	"number":  "number",
+	"boolean": "boolean",
+	"object":  "object",
+	"array":   "array",
+}
+
+//JobVersionManifest returns the seed manifest of the latest package of a job version
+func JobVersionManifest(db *sql.DB, jv JobVersion) (objects.Seed, error) {
+	res, err := ResolveJob(db, JobVersionQuery(jv))
+	if err != nil {
+		return objects.Seed{}, err
+	}
+
+	return res.Manifest, nil
+}
+
+//JobVersionQuery resolves the latest package of a job version among its own images, preferring registries in the
+//configured registry order
+func JobVersionQuery(jv JobVersion) ResolveQuery {
+	return ResolveQuery{Job: jv.JobName, Version: "=" + jv.JobVersion, PackageVersion: "=" + jv.LatestPackageVersion,
+		Preferred: RegistryOrder(), JobVersionId: jv.ID}
+}
+
+//InputSchema builds a JSON Schema describing the values needed to run a seed job. Instances are objects with inputs
+//(file paths keyed by input name, arrays of paths for inputs accepting multiple files), json (json input values) and
+//settings (strings) properties.
+func InputSchema(seed objects.Seed) JsonSchema {
+	iface := seed.Job.Interface
+
+	files := objectSchema()
+	for _, in := range iface.Inputs.Files {
+		path := JsonSchema{"type": "string", "minLength": 1}
+		var prop JsonSchema
+		if in.Multiple {
+			prop = JsonSchema{"type": "array", "items": path, "minItems": 1}
+		} else {
+			prop = path
+		}
+		if len(in.MediaTypes) > 0 {
+			prop["description"] = "Accepts " + strings.Join(in.MediaTypes, ", ")
+			prop["x-mediaTypes"] = in.MediaTypes
+		}
+		if in.Partial {
+			prop["x-partial"] = true
+		}
+		addProperty(files, in.Name, prop, in.Required)
+	}
+
+	json := objectSchema()
+	for _, in := range iface.Inputs.Json {
+		prop := JsonSchema{}
+		if t, ok := seedJsonTypes[in.Type]; ok {
+			prop["type"] = t
+		}
+		addProperty(json, in.Name, prop, in.Required)
+	}
+
+	settings := objectSchema()
+	for _, s := range iface.Settings {
+		prop := JsonSchema{"type": "string"}
+		if s.Secret {
+			prop["writeOnly"] = true
+		}
+		addProperty(settings, s.Name, prop, true)
+	}
+
+	schema := objectSchema()
+	schema["$schema"] = JsonSchemaDraft
+	schema["title"] = seed.Job.Title
+	if seed.Job.Description != "" {
+		schema["description"] = seed.Job.Description
+	}
+	addProperty(schema, "inputs", files, len(files["required"].([]string)) > 0)
+	addProperty(schema, "json", json, len(json["required"].([]string)) > 0)
+	addProperty(schema, "settings", settings, len(settings["required"].([]string)) > 0)
+
+	return schema
+}
+
+func objectSchema() JsonSchema {
+	return JsonSchema{"type": "object", "properties": map[string]JsonSchema{}, "required": []string{},
+		"additionalProperties": false}
+}
+
+func addProperty(schema JsonSchema, name string, prop JsonSchema, required bool) {
+	schema["properties"].(map[string]JsonSchema)[name] = prop
+	if required {
+		schema["required"] = append(schema["required"].([]string), name)
+	}
+}
+
+//ValidateInputs validates a decoded JSON value against a schema built by InputSchema. Only the keywords used by
+//InputSchema are checked.
+func ValidateInputs(schema JsonSchema, value interface{}) InputValidation {
+	errors := validateSchema(schema, value, "")
+	return InputValidation{Valid: len(errors) == 0, Errors: errors}
+}
+
+func validateSchema(schema JsonSchema, value interface{}, path string) []InputError {
+	errors := []InputError{}
+	fail := func(format string, args ...interface{}) []InputError {
+		return append(errors, InputError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if t, ok := schema["type"].(string); ok && !jsonTypeMatches(t, value) {
+		return fail("Expected %s", t)
+	}
+
+	switch v := value.(type) {
+	case string:
+		if min, ok := schema["minLength"].(int); ok && len(v) < min {
+			return fail("Must not be empty")
+		}
+	case []interface{}:
+		if min, ok := schema["minItems"].(int); ok && len(v) < min {
+			errors = fail("Expected at least %d items", min)
+		}
+		if items, ok := schema["items"].(JsonSchema); ok {
+			for i, item := range v {
+				errors = append(errors, validateSchema(items, item, fmt.Sprintf("%s/%d", path, i))...)
+			}
+		}
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]JsonSchema)
+		required, _ := schema["required"].([]string)
+		for _, name := range required {
+			if _, ok := v[name]; !ok {
+				errors = append(errors, InputError{Path: path + "/" + name, Message: "Required"})
+			}
+		}
+		names := []string{}
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := properties[name]
+			if !ok {
+				if schema["additionalProperties"] == false {
+					errors = append(errors, InputError{Path: path + "/" + name, Message: "Unknown property"})
+				}
+				continue
+			}
+			errors = append(errors, validateSchema(prop, v[name], path+"/"+name)...)
+		}
+	}
+
+	return errors
+}
+
+func jsonTypeMatches(t string, value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return t == "string"
+	case float64:
+		return t == "number" || (t == "integer" && v == math.Trunc(v))
+	case bool:
+		return t == "boolean"
+	case []interface{}:
+		return t == "array"
+	case map[string]interface{}:
+		return t == "object"
+	}
+	return t == "null"
+}
@@ -0,0 +1,405 @@
+package models
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+)
+
+//InterfaceInput is a file or json input from the interface of an image's seed manifest. File inputs are stored
+//once per accepted media type.
+type InterfaceInput struct {
+	ID        int    `db:"id"`
+	ImageId   int    `db:"image_id"`
+	Kind      string `db:"kind"` //file or json
+	Name      string `db:"name"`
+	MediaType string `db:"media_type"`
+	JsonType  string `db:"json_type"`
+	Multiple  bool   `db:"multiple"`
+	Partial   bool   `db:"partial"`
+	Required  bool   `db:"required"`
+}
+
+//InterfaceOutput is a file or json output from the interface of an image's seed manifest
+type InterfaceOutput struct {
+	ID        int    `db:"id"`
+	ImageId   int    `db:"image_id"`
+	Kind      string `db:"kind"` //file or json
+	Name      string `db:"name"`
+	MediaType string `db:"media_type"`
+	JsonType  string `db:"json_type"`
+	Pattern   string `db:"pattern"`
+	Multiple  bool   `db:"multiple"`
+	Required  bool   `db:"required"`
+}
+
+type InterfaceSetting struct {
+	ID      int    `db:"id"`
+	ImageId int    `db:"image_id"`
+	Name    string `db:"name"`
+	Secret  bool   `db:"secret"`
+}
+
+type InterfaceMount struct {
+	ID      int    `db:"id"`
+	ImageId int    `db:"image_id"`
+	Name    string `db:"name"`
+	Path    string `db:"path"`
+	Mode    string `db:"mode"`
+}
+
+//InterfaceFilter restricts images to those whose seed interface matches every non-empty field
+type InterfaceFilter struct {
+	AcceptsMediaType  string
+	ProducesMediaType string
+	Setting           string
+	Mount             string
+}
+
+func (f InterfaceFilter) IsEmpty() bool {
+	return f.AcceptsMediaType == "" && f.ProducesMediaType == "" && f.Setting == "" && f.Mount == ""
+}
+
+func CreateInterfaceTables(db *sql.DB, dbType string) {
+	tables := []string{`
+	CREATE TABLE IF NOT EXISTS InterfaceInput(
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		image_id INTEGER NOT NULL,
+		kind TEXT,
+		name TEXT,
+		media_type TEXT,
+		json_type TEXT,
+		multiple BOOLEAN,
+		partial BOOLEAN,
+		required BOOLEAN,
+		CONSTRAINT fk_input_image_id
+		    FOREIGN KEY (image_id)
+		    REFERENCES Image (id)
+		    ON DELETE CASCADE
+	);
+	`, `
+	CREATE TABLE IF NOT EXISTS InterfaceOutput(
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		image_id INTEGER NOT NULL,
+		kind TEXT,
+		name TEXT,
+		media_type TEXT,
+		json_type TEXT,
+		pattern TEXT,
+		multiple BOOLEAN,
+		required BOOLEAN,
+		CONSTRAINT fk_output_image_id
+		    FOREIGN KEY (image_id)
+		    REFERENCES Image (id)
+		    ON DELETE CASCADE
+	);
+	`, `
+	CREATE TABLE IF NOT EXISTS InterfaceSetting(
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		image_id INTEGER NOT NULL,
+		name TEXT,
+		secret BOOLEAN,
+		CONSTRAINT fk_setting_image_id
+		    FOREIGN KEY (image_id)
+		    REFERENCES Image (id)
+		    ON DELETE CASCADE
+	);
+	`, `
+	CREATE TABLE IF NOT EXISTS InterfaceMount(
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		image_id INTEGER NOT NULL,
+		name TEXT,
+		path TEXT,
+		mode TEXT,
+		CONSTRAINT fk_mount_image_id
+		    FOREIGN KEY (image_id)
+		    REFERENCES Image (id)
+		    ON DELETE CASCADE
+	);
+	`}
+
+	for _, sql_table := range tables {
+		if dbType == "postgres" {
+			sql_table = strings.Replace(sql_table, "id INTEGER PRIMARY KEY AUTOINCREMENT", "id SERIAL PRIMARY KEY", 1)
+		}
+
+		_, err := db.Exec(sql_table)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+//BuildInterfaceIndex rebuilds the interface tables from the seed manifests of all images. It should be called
+//after the image table changes, e.g. at the end of a scan.
+func BuildInterfaceIndex(db Queryer) {
+	for _, table := range []string{"InterfaceInput", "InterfaceOutput", "InterfaceSetting", "InterfaceMount"} {
+		_, err := db.Exec("DELETE FROM " + table)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	for _, img := range ReadImages(db) {
+		if err := StoreImageInterface(db, img); err != nil {
+			log.Printf("Error indexing interface for %s: %s \n", img.FullName, err.Error())
+		}
+	}
+}
+
+//StoreImageInterface stores the inputs, outputs, settings and mounts of a single image
+func StoreImageInterface(db Queryer, img Image) error {
+	sql_input := `INSERT INTO InterfaceInput(image_id, kind, name, media_type, json_type, multiple, partial, required)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8)`
+	sql_output := `INSERT INTO InterfaceOutput(image_id, kind, name, media_type, json_type, pattern, multiple, required)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8)`
+	sql_setting := `INSERT INTO InterfaceSetting(image_id, name, secret) VALUES($1, $2, $3)`
+	sql_mount := `INSERT INTO InterfaceMount(image_id, name, path, mode) VALUES($1, $2, $3, $4)`
+
+	iface := img.Seed.Job.Interface
+	for _, in := range iface.Inputs.Files {
+		mediaTypes := in.MediaTypes
+		if len(mediaTypes) == 0 {
+			mediaTypes = []string{""}
+		}
+		for _, mt := range mediaTypes {
+			_, err := db.Exec(sql_input, img.ID, "file", in.Name, mt, "", in.Multiple, in.Partial, in.Required)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for _, in := range iface.Inputs.Json {
+		_, err := db.Exec(sql_input, img.ID, "json", in.Name, "", in.Type, false, false, in.Required)
+		if err != nil {
+			return err
+		}
+	}
+	for _, out := range iface.Outputs.Files {
+		_, err := db.Exec(sql_output, img.ID, "file", out.Name, out.MediaType, "", out.Pattern, out.Multiple, out.Required)
+		if err != nil {
+			return err
+		}
+	}
+	for _, out := range iface.Outputs.JSON {
+		_, err := db.Exec(sql_output, img.ID, "json", out.Name, "", out.Type, "", false, out.Required)
+		if err != nil {
+			return err
+		}
+	}
+	for _, s := range iface.Settings {
+		_, err := db.Exec(sql_setting, img.ID, s.Name, s.Secret)
+		if err != nil {
+			return err
+		}
+	}
+	for _, m := range iface.Mounts {
+		_, err := db.Exec(sql_mount, img.ID, m.Name, m.Path, m.Mode)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//GetImageInputs returns the indexed inputs of an image
+func GetImageInputs(db *sql.DB, imageId int) []InterfaceInput {
+	rows, err := db.Query("SELECT * FROM InterfaceInput WHERE image_id=$1 ORDER BY id ASC", imageId)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+
+	var result []InterfaceInput
+	for rows.Next() {
+		item := InterfaceInput{}
+		err2 := rows.Scan(&item.ID, &item.ImageId, &item.Kind, &item.Name, &item.MediaType, &item.JsonType,
+			&item.Multiple, &item.Partial, &item.Required)
+		if err2 != nil {
+			panic(err2)
+		}
+		result = append(result, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Fatal(err)
+	}
+	return result
+}
+
+//GetImageOutputs returns the indexed outputs of an image
+func GetImageOutputs(db *sql.DB, imageId int) []InterfaceOutput {
+	rows, err := db.Query("SELECT * FROM InterfaceOutput WHERE image_id=$1 ORDER BY id ASC", imageId)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+
+	var result []InterfaceOutput
+	for rows.Next() {
+		item := InterfaceOutput{}
+		err2 := rows.Scan(&item.ID, &item.ImageId, &item.Kind, &item.Name, &item.MediaType, &item.JsonType,
+			&item.Pattern, &item.Multiple, &item.Required)
+		if err2 != nil {
+			panic(err2)
+		}
+		result = append(result, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Fatal(err)
+	}
+	return result
+}
+
+//FilterImageIds returns the ids of the images matching every field of the filter
+func FilterImageIds(db *sql.DB, filter InterfaceFilter) map[int]bool {
+	var result map[int]bool
+	intersect := func(ids map[int]bool) {
+		if result == nil {
+			result = ids
+			return
+		}
+		for id := range result {
+			if !ids[id] {
+				delete(result, id)
+			}
+		}
+	}
+
+	if filter.AcceptsMediaType != "" {
+		intersect(matchMediaTypes(db, "SELECT image_id, media_type FROM InterfaceInput WHERE kind='file'",
+			filter.AcceptsMediaType))
+	}
+	if filter.ProducesMediaType != "" {
+		intersect(matchMediaTypes(db, "SELECT image_id, media_type FROM InterfaceOutput WHERE kind='file'",
+			filter.ProducesMediaType))
+	}
+	if filter.Setting != "" {
+		intersect(queryImageIds(db, "SELECT image_id FROM InterfaceSetting WHERE name=$1", filter.Setting))
+	}
+	if filter.Mount != "" {
+		intersect(queryImageIds(db, "SELECT image_id FROM InterfaceMount WHERE name=$1 OR path=$1", filter.Mount))
+	}
+
+	if result == nil {
+		result = make(map[int]bool)
+	}
+	return result
+}
+
+//FilterJobIds returns the ids of the jobs with at least one image matching the filter
+func FilterJobIds(db *sql.DB, filter InterfaceFilter) map[int]bool {
+	imageIds := FilterImageIds(db, filter)
+
+	rows, err := db.Query("SELECT id, job_id FROM Image")
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+
+	result := make(map[int]bool)
+	for rows.Next() {
+		var id, jobId int
+		err2 := rows.Scan(&id, &jobId)
+		if err2 != nil {
+			panic(err2)
+		}
+		if imageIds[id] {
+			result[jobId] = true
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Fatal(err)
+	}
+	return result
+}
+
+func matchMediaTypes(db *sql.DB, query, mediaType string) map[int]bool {
+	rows, err := db.Query(query)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+
+	result := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		var mt string
+		err2 := rows.Scan(&id, &mt)
+		if err2 != nil {
+			panic(err2)
+		}
+		if MediaTypeMatches(mt, mediaType) {
+			result[id] = true
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Fatal(err)
+	}
+	return result
+}
+
+func queryImageIds(db *sql.DB, query string, args ...interface{}) map[int]bool {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+
+	result := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		err2 := rows.Scan(&id)
+		if err2 != nil {
+			panic(err2)
+		}
+		result[id] = true
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Fatal(err)
+	}
+	return result
+}
+
+//MediaTypeMatches reports whether two media types are compatible. Either side may be a wildcard such as image/*
+//or */*. Parameters are ignored and the comparison is case insensitive. An empty media type matches nothing.
+func MediaTypeMatches(a, b string) bool {
+	a = normalizeMediaType(a)
+	b = normalizeMediaType(b)
+	if a == "" || b == "" {
+		return false
+	}
+	if a == "*/*" || b == "*/*" || a == b {
+		return true
+	}
+
+	aType, aSub := splitMediaType(a)
+	bType, bSub := splitMediaType(b)
+	if aType != bType {
+		return false
+	}
+	return aSub == "*" || bSub == "*"
+}
+
+func normalizeMediaType(mt string) string {
+	if i := strings.Index(mt, ";"); i >= 0 {
+		mt = mt[:i]
+	}
+	mt = strings.ToLower(strings.TrimSpace(mt))
+	if mt == "*" {
+		mt = "*/*"
+	}
+	return mt
+}
+
+func splitMediaType(mt string) (string, string) {
+	if i := strings.Index(mt, "/"); i >= 0 {
+		return mt[:i], mt[i+1:]
+	}
+	return mt, ""
+}
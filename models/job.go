@@ -1,8 +1,10 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"log"
+	"os"
 	"strings"
 
 	"github.com/ngageoint/seed-common/util"
@@ -18,10 +20,46 @@ type Job struct {
 	Email                string `db:"email"`
 	MaintOrg             string `db:"maint_org"`
 	Description          string `db:"description"`
+	Namespace            string `db:"namespace"` //registry, registry/org or maintainer email depending on the merge policy
 	ImageIDs             []int
 	JobVersions          []JobVersion
 }
 
+//Job merge policies set with SILO_JOB_MERGE_POLICY. Images with the same job name are merged into one job if they
+//are in the same namespace: everywhere (global, the default), the same registry, the same registry and org, or
+//have the same maintainer email.
+const (
+	MergeGlobal     = "global"
+	MergeRegistry   = "registry"
+	MergeOrg        = "org"
+	MergeMaintainer = "maintainer"
+)
+
+func JobMergePolicy() string {
+	policy := strings.ToLower(os.Getenv("SILO_JOB_MERGE_POLICY"))
+	switch policy {
+	case MergeRegistry, MergeOrg, MergeMaintainer:
+		return policy
+	case "", MergeGlobal:
+		return MergeGlobal
+	}
+	log.Printf("Unknown SILO_JOB_MERGE_POLICY %s, using %s \n", policy, MergeGlobal)
+	return MergeGlobal
+}
+
+//JobNamespace returns the namespace of the job an image belongs to under a merge policy
+func JobNamespace(img Image, policy string) string {
+	switch policy {
+	case MergeRegistry:
+		return img.Registry
+	case MergeOrg:
+		return img.Registry + "/" + img.Org
+	case MergeMaintainer:
+		return strings.ToLower(img.Email)
+	}
+	return ""
+}
+
 func SetJobInfo(job *Job, img Image) {
 	job.Name = img.ShortName
 	job.LatestJobVersion = img.JobVersion
@@ -33,21 +71,28 @@ func SetJobInfo(job *Job, img Image) {
 	job.Description = img.Description
 }
 
-func CreateJobTable(db *sql.DB, dbType string) {
-	// create table if it does not exist
-	sql_table := `
+//jobTable creates the job table if it does not exist
+const jobTable = `
 	CREATE TABLE IF NOT EXISTS Job(
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT NOT NULL UNIQUE,
+		name TEXT NOT NULL,
 		latest_job_version TEXT,
 		latest_package_version TEXT,
 		title TEXT,
 		maintainer TEXT,
 		email TEXT,
 		maint_org TEXT,
-		description TEXT
+		description TEXT,
+		namespace TEXT NOT NULL DEFAULT '',
+		UNIQUE(namespace, name)
 	);
 	`
+
+func CreateJobTable(db *sql.DB, dbType string) {
+	migrateJobTables(db, dbType)
+
+	// create table if it does not exist
+	sql_table := jobTable
 	if dbType == "postgres" {
 	    sql_table = strings.Replace(sql_table, "id INTEGER PRIMARY KEY AUTOINCREMENT", "id SERIAL PRIMARY KEY", 1)
 	}
@@ -58,7 +103,82 @@ func CreateJobTable(db *sql.DB, dbType string) {
 	}
 }
 
-func ResetJobTable(db *sql.DB, dbType string) error {
+//migrateJobTables updates job tables created before jobs had namespaces, when job names had to be unique. Existing
+//jobs and job versions are kept in the global namespace until the next scan rebuilds them.
+func migrateJobTables(db *sql.DB, dbType string) {
+	var exists int
+	err := db.QueryRow("SELECT COUNT(*) FROM Job").Scan(&exists)
+	if err != nil {
+		return
+	}
+	if _, err = db.Exec("SELECT namespace FROM Job LIMIT 1"); err == nil {
+		return
+	}
+
+	log.Print("Adding namespaces to the job tables")
+	if dbType != "postgres" {
+		if err = migrateJobTablesLite(db); err != nil {
+			panic(err)
+		}
+		return
+	}
+	statements := []string{
+		"ALTER TABLE Job ADD COLUMN namespace TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE Job DROP CONSTRAINT IF EXISTS job_name_key",
+		"CREATE UNIQUE INDEX IF NOT EXISTS job_namespace_name ON Job(namespace, name)",
+		"ALTER TABLE JobVersion DROP CONSTRAINT IF EXISTS jobversion_job_name_job_version_key",
+		"CREATE UNIQUE INDEX IF NOT EXISTS jobversion_job_id_job_version ON JobVersion(job_id, job_version)",
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			panic(err)
+		}
+	}
+}
+
+//migrateJobTablesLite copies the sqlite job tables into tables with the new unique constraints, which sqlite can't
+//change in place, keeping their ids. Foreign keys are turned off on the connection doing the copy so dropping the old
+//tables doesn't clear the jobs of images or delete their policy findings.
+func migrateJobTablesLite(db *sql.DB) error {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err = conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	statements := []string{
+		strings.Replace(jobTable, "EXISTS Job(", "EXISTS JobNamespaced(", 1),
+		strings.Replace(jobVersionTable, "EXISTS JobVersion(", "EXISTS JobVersionNamespaced(", 1),
+		`INSERT INTO JobNamespaced (id, name, latest_job_version, latest_package_version, title, maintainer, email,
+			maint_org, description)
+		SELECT id, name, latest_job_version, latest_package_version, title, maintainer, email, maint_org, description
+		FROM Job`,
+		`INSERT INTO JobVersionNamespaced (id, job_name, job_id, job_version, latest_package_version)
+		SELECT id, job_name, job_id, job_version, latest_package_version FROM JobVersion`,
+		"DROP TABLE JobVersion",
+		"DROP TABLE Job",
+		"ALTER TABLE JobNamespaced RENAME TO Job",
+		"ALTER TABLE JobVersionNamespaced RENAME TO JobVersion",
+	}
+	for _, statement := range statements {
+		if _, err = tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func ResetJobTable(db Queryer, dbType string) error {
     if dbType == "sqlite" {
         return ResetJobTableLite(db)
     } else if dbType == "postgres" {
@@ -68,7 +188,7 @@ func ResetJobTable(db *sql.DB, dbType string) error {
     }
 }
 
-func ResetJobTableLite(db *sql.DB) error {
+func ResetJobTableLite(db Queryer) error {
 	// delete all jobs and reset the counter
 	delete := `DELETE FROM Job;`
 
@@ -87,7 +207,7 @@ func ResetJobTableLite(db *sql.DB) error {
 	return err2
 }
 
-func ResetJobTablePG(db *sql.DB) error {
+func ResetJobTablePG(db Queryer) error {
 	// delete all images and reset the counter
 	delete := `TRUNCATE Job RESTART IDENTITY CASCADE;`
 
@@ -99,11 +219,13 @@ func ResetJobTablePG(db *sql.DB) error {
 	return err
 }
 
-func BuildJobsList(db *sql.DB, images *[]Image, dbType string) []Job {
+func BuildJobsList(db Queryer, images *[]Image, dbType string) []Job {
 	jobs := []Job{}
 	jobMap := make(map[string]Job)
 	jobVersions := []JobVersion{}
 	jvMap := make(map[string]JobVersion)
+	policy := JobMergePolicy()
+	mirrors := mirrorNamespaces(*images, policy, MirrorRanks(db))
 	(*images)[0].JobId = 1
 	for i, _ := range *images {
 		img := &(*images)[i]
@@ -111,9 +233,14 @@ func BuildJobsList(db *sql.DB, images *[]Image, dbType string) []Job {
 		img.JobVersion = img.Seed.Job.JobVersion
 		img.PackageVersion = img.Seed.Job.PackageVersion
 
-		versionName := img.ShortName + img.JobVersion
+		namespace := JobNamespace(*img, policy)
+		if ns, ok := mirrors[img.Digest]; ok {
+			namespace = ns
+		}
+		jobName := namespace + "\x00" + img.ShortName
+		versionName := jobName + "\x00" + img.JobVersion
 
-		job, ok := jobMap[img.ShortName]
+		job, ok := jobMap[jobName]
 		if ok {
 			jv := img.JobVersion
 			pv := img.PackageVersion
@@ -127,6 +254,7 @@ func BuildJobsList(db *sql.DB, images *[]Image, dbType string) []Job {
 		if !ok {
 			job = Job{}
 			SetJobInfo(&job, *img)
+			job.Namespace = namespace
 
 			var id int
 			var err2 error
@@ -140,7 +268,7 @@ func BuildJobsList(db *sql.DB, images *[]Image, dbType string) []Job {
 			}
 
 			job.ID = id
-			jobMap[img.ShortName] = job
+			jobMap[jobName] = job
 			jobs = append(jobs, job)
 		}
 
@@ -182,7 +310,7 @@ func BuildJobsList(db *sql.DB, images *[]Image, dbType string) []Job {
 	return jobs
 }
 
-func AddJobLite(db *sql.DB, job Job) (int, error) {
+func AddJobLite(db Queryer, job Job) (int, error) {
 	sql_add := `
 	INSERT INTO Job(
 		name,
@@ -192,8 +320,9 @@ func AddJobLite(db *sql.DB, job Job) (int, error) {
 		maintainer,
 		email,
 		maint_org,
-		description
-	) values($1, $2, $3, $4, $5, $6, $7, $8)
+		description,
+		namespace
+	) values($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
 
 	stmt, err := db.Prepare(sql_add)
@@ -203,7 +332,7 @@ func AddJobLite(db *sql.DB, job Job) (int, error) {
 	defer stmt.Close()
 
 	result, err := stmt.Exec(job.Name, job.LatestJobVersion, job.LatestPackageVersion,
-		job.Title, job.Maintainer, job.Email, job.MaintOrg, job.Description)
+		job.Title, job.Maintainer, job.Email, job.MaintOrg, job.Description, job.Namespace)
 	if err != nil {
 		return -1, err
 	}
@@ -218,7 +347,7 @@ func AddJobLite(db *sql.DB, job Job) (int, error) {
 	return id, err
 }
 
-func AddJobPg(db *sql.DB, job Job) (int, error) {
+func AddJobPg(db Queryer, job Job) (int, error) {
 	query :=
 	`INSERT INTO Job(
 			name, 
@@ -228,18 +357,19 @@ func AddJobPg(db *sql.DB, job Job) (int, error) {
 			maintainer,
 			email,
 			maint_org,
-			description) 
-		VALUES($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id;`
+			description,
+			namespace) 
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id;`
 
 
 	var id int
 	err := db.QueryRow(query, job.Name, job.LatestJobVersion, job.LatestPackageVersion,
-		job.Title, job.Maintainer, job.Email, job.MaintOrg, job.Description).Scan(&id)
+		job.Title, job.Maintainer, job.Email, job.MaintOrg, job.Description, job.Namespace).Scan(&id)
 
 	return id, err
 }
 
-func UpdateJob(db *sql.DB, job Job) error {
+func UpdateJob(db Queryer, job Job) error {
 	sql_update := `UPDATE Job SET 
 		latest_job_version=$1, 
 		latest_package_version=$2,		
@@ -295,8 +425,10 @@ func StoreJobs(db *sql.DB, jobs []Job) {
 		title,
 		maintainer,
 		email,
-		description
-	) values(?, ?, ?, ?, ?, ?, ?)
+		maint_org,
+		description,
+		namespace
+	) values(?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	stmt, err := db.Prepare(sql_add)
@@ -307,7 +439,7 @@ func StoreJobs(db *sql.DB, jobs []Job) {
 
 	for _, job := range jobs {
 		_, err2 := stmt.Exec(job.Name, job.LatestJobVersion, job.LatestPackageVersion,
-			job.Title, job.Maintainer, job.Email, job.MaintOrg, job.Description)
+			job.Title, job.Maintainer, job.Email, job.MaintOrg, job.Description, job.Namespace)
 		if err2 != nil {
 			panic(err2)
 		}
@@ -326,17 +458,18 @@ func ReadJobs(db *sql.DB) []Job {
 	}
 	defer rows.Close()
 
+	resources := ReadJobVersionResources(db)
 	var result []Job
 	for rows.Next() {
 		item := Job{}
 		err2 := rows.Scan(&item.ID, &item.Name, &item.LatestJobVersion, &item.LatestPackageVersion,
-			&item.Title, &item.Maintainer, &item.Email, &item.MaintOrg, &item.Description)
+			&item.Title, &item.Maintainer, &item.Email, &item.MaintOrg, &item.Description, &item.Namespace)
 		if err2 != nil {
 			panic(err2)
 		}
 
 		item.ImageIDs = GetJobImageIds(db, item.ID)
-		item.JobVersions = GetJobVersions(db, item.ID)
+		item.JobVersions = getJobVersions(db, item.ID, resources)
 
 		result = append(result, item)
 	}
@@ -352,7 +485,7 @@ func ReadJob(db *sql.DB, id int) (Job, error) {
 
 	var result Job
 	err := row.Scan(&result.ID, &result.Name, &result.LatestJobVersion, &result.LatestPackageVersion,
-		&result.Title, &result.Maintainer, &result.Email, &result.MaintOrg, &result.Description)
+		&result.Title, &result.Maintainer, &result.Email, &result.MaintOrg, &result.Description, &result.Namespace)
 	if err != nil {
 		util.PrintUtil("ERROR scanning in read job: %v", err.Error())
 	}
@@ -360,6 +493,38 @@ func ReadJob(db *sql.DB, id int) (Job, error) {
 	return result, err
 }
 
+//ReadJobByName reads the job with the given name in a namespace
+func ReadJobByName(db *sql.DB, namespace, name string) (Job, error) {
+	row := db.QueryRow("SELECT * FROM Job WHERE namespace=$1 AND name=$2", namespace, name)
+
+	var result Job
+	err := row.Scan(&result.ID, &result.Name, &result.LatestJobVersion, &result.LatestPackageVersion,
+		&result.Title, &result.Maintainer, &result.Email, &result.MaintOrg, &result.Description, &result.Namespace)
+
+	return result, err
+}
+
+//ReadJobsByName reads the jobs with the given name in every namespace
+func ReadJobsByName(db *sql.DB, name string) ([]Job, error) {
+	rows, err := db.Query("SELECT * FROM Job WHERE name=$1 ORDER BY id ASC", name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []Job{}
+	for rows.Next() {
+		item := Job{}
+		err = rows.Scan(&item.ID, &item.Name, &item.LatestJobVersion, &item.LatestPackageVersion,
+			&item.Title, &item.Maintainer, &item.Email, &item.MaintOrg, &item.Description, &item.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+	return result, rows.Err()
+}
+
 type JobVersion struct {
 	ID                   int    `db:"id"`
 	JobName              string `db:"job_name"`
@@ -367,6 +532,7 @@ type JobVersion struct {
 	JobVersion           string `db:"job_version"`
 	LatestPackageVersion string `db:"latest_package_version"`
 	Images               []SimpleImage
+	Resources            []ScalarResource //resources declared by the latest package version
 }
 
 func SetJobVersionInfo(jv *JobVersion, img Image) {
@@ -376,16 +542,15 @@ func SetJobVersionInfo(jv *JobVersion, img Image) {
 	jv.LatestPackageVersion = img.PackageVersion
 }
 
-func CreateJobVersionTable(db *sql.DB, dbType string) {
-	// create table if it does not exist
-	sql_table := `
+//jobVersionTable creates the job version table if it does not exist
+const jobVersionTable = `
 	CREATE TABLE IF NOT EXISTS JobVersion(
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		job_name TEXT,
 		job_id INTEGER NOT NULL,
 		job_version TEXT,
 		latest_package_version TEXT,
-		UNIQUE(job_name, job_version),
+		UNIQUE(job_id, job_version),
 		CONSTRAINT fk_inv_job_id
 		    FOREIGN KEY (job_id)
 		    REFERENCES Job (id)
@@ -393,6 +558,10 @@ func CreateJobVersionTable(db *sql.DB, dbType string) {
 	);
 	`
 
+func CreateJobVersionTable(db *sql.DB, dbType string) {
+	// create table if it does not exist
+	sql_table := jobVersionTable
+
 	if dbType == "postgres" {
         sql_table = strings.Replace(sql_table, "id INTEGER PRIMARY KEY AUTOINCREMENT", "id SERIAL PRIMARY KEY", 1)
     }
@@ -403,7 +572,7 @@ func CreateJobVersionTable(db *sql.DB, dbType string) {
 	}
 }
 
-func ResetJobVersionTable(db *sql.DB, dbType string) error {
+func ResetJobVersionTable(db Queryer, dbType string) error {
     if dbType == "sqlite" {
         return ResetJobVersionTableLite(db)
     } else if dbType == "postgres" {
@@ -413,7 +582,7 @@ func ResetJobVersionTable(db *sql.DB, dbType string) error {
     }
 }
 
-func ResetJobVersionTableLite(db *sql.DB) error {
+func ResetJobVersionTableLite(db Queryer) error {
 	// delete all job versions and reset the counter
 	delete := `DELETE FROM JobVersion;`
 
@@ -432,7 +601,7 @@ func ResetJobVersionTableLite(db *sql.DB) error {
 	return err2
 }
 
-func ResetJobVersionTablePG(db *sql.DB) error {
+func ResetJobVersionTablePG(db Queryer) error {
 	// delete all images and reset the counter
 	delete := `TRUNCATE JobVersion RESTART IDENTITY CASCADE;`
 
@@ -444,7 +613,7 @@ func ResetJobVersionTablePG(db *sql.DB) error {
 	return err
 }
 
-func AddJobVersionLite(db *sql.DB, jv JobVersion) (int, error) {
+func AddJobVersionLite(db Queryer, jv JobVersion) (int, error) {
 	sql_add := `
 	INSERT INTO JobVersion(
 		job_name,
@@ -475,7 +644,7 @@ func AddJobVersionLite(db *sql.DB, jv JobVersion) (int, error) {
 	return id, err
 }
 
-func AddJobVersionPg(db *sql.DB, jv JobVersion) (int, error) {
+func AddJobVersionPg(db Queryer, jv JobVersion) (int, error) {
 	query :=
 		`INSERT INTO JobVersion(
 			job_name,
@@ -492,7 +661,7 @@ func AddJobVersionPg(db *sql.DB, jv JobVersion) (int, error) {
 	return id, err
 }
 
-func UpdateJobVersion(db *sql.DB, jv JobVersion) error {
+func UpdateJobVersion(db Queryer, jv JobVersion) error {
 	sql_update := `UPDATE JobVersion SET 
 		job_name=$1,
 		job_id=$2,
@@ -523,6 +692,7 @@ func ReadJobVersions(db *sql.DB) []JobVersion {
 	}
 	defer rows.Close()
 
+	resources := ReadJobVersionResources(db)
 	var result []JobVersion
 	for rows.Next() {
 		item := JobVersion{}
@@ -532,6 +702,7 @@ func ReadJobVersions(db *sql.DB) []JobVersion {
 		}
 
 		item.Images = GetJobVersionImages(db, item.ID)
+		item.Resources = jobVersionResources(resources, item.ID)
 
 		result = append(result, item)
 	}
@@ -552,11 +723,17 @@ func ReadJobVersion(db *sql.DB, id int) (JobVersion, error) {
 	}
 
 	result.Images = GetJobVersionImages(db, result.ID)
+	result.Resources = GetJobVersionResources(db, result.ID, result.LatestPackageVersion)
 
 	return result, err
 }
 
 func GetJobVersions(db *sql.DB, jobid int) []JobVersion {
+	return getJobVersions(db, jobid, GetJobResources(db, jobid))
+}
+
+//getJobVersions reads the versions of a job, taking their resources from the given resources by job version id
+func getJobVersions(db *sql.DB, jobid int, resources map[int][]ScalarResource) []JobVersion {
 	sql_readall := `SELECT * FROM JobVersion WHERE job_id=$1`
 
 	rows, err := db.Query(sql_readall, jobid)
@@ -574,6 +751,7 @@ func GetJobVersions(db *sql.DB, jobid int) []JobVersion {
 		}
 
 		item.Images = GetJobVersionImages(db, item.ID)
+		item.Resources = jobVersionResources(resources, item.ID)
 
 		result = append(result, item)
 	}
@@ -583,3 +761,11 @@ func GetJobVersions(db *sql.DB, jobid int) []JobVersion {
 	}
 	return result
 }
+
+//jobVersionResources returns the resources of a job version from resources by job version id, listing none as empty
+func jobVersionResources(resources map[int][]ScalarResource, jobVersionId int) []ScalarResource {
+	if r, ok := resources[jobVersionId]; ok {
+		return r
+	}
+	return []ScalarResource{}
+}
@@ -0,0 +1,261 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ngageoint/seed-common/objects"
+	"github.com/ngageoint/seed-common/util"
+)
+
+//LintIssue is a schema error or lint warning for a seed manifest. Path is a JSON pointer into the manifest.
+type LintIssue struct {
+	Rule    string
+	Path    string
+	Message string
+}
+
+//LintResult is valid when the manifest has no schema errors; warnings do not affect validity
+type LintResult struct {
+	Valid    bool
+	Errors   []LintIssue
+	Warnings []LintIssue
+}
+
+//default limits for resources when no node profiles are defined
+var lintResourceLimits = map[string]float64{
+	"cpus":      64,
+	"mem":       262144,
+	"disk":      1048576,
+	"gpus":      8,
+	"sharedMem": 262144,
+}
+
+var jobNamePattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+var parameterNamePattern = regexp.MustCompile(`^[a-zA-Z_-]+$`)
+var secretNamePattern = regexp.MustCompile(`(?i)(password|passwd|secret|token|api_?key|access_?key|private_?key|credential)`)
+var emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+$`)
+
+//ParseManifestBody reads a seed manifest given either as raw JSON or as an escaped docker label value
+func ParseManifestBody(body string) string {
+	body = strings.TrimSpace(body)
+	if json.Valid([]byte(body)) && strings.HasPrefix(body, "{") {
+		return body
+	}
+	if len(body) > 1 && strings.HasPrefix(body, `"`) && strings.HasSuffix(body, `"`) {
+		body = body[1 : len(body)-1]
+	}
+	return util.UnescapeManifestLabel(body)
+}
+
+//LintManifest checks a seed manifest against the seed schema rules silo relies on and against the catalog
+func LintManifest(db *sql.DB, manifest string) LintResult {
+	result := LintResult{Errors: []LintIssue{}, Warnings: []LintIssue{}}
+	fail := func(rule, path, format string, args ...interface{}) {
+		result.Errors = append(result.Errors, LintIssue{Rule: rule, Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	warn := func(rule, path, format string, args ...interface{}) {
+		result.Warnings = append(result.Warnings, LintIssue{Rule: rule, Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	seed, err := objects.SeedFromManifestString(manifest)
+	if err != nil {
+		fail("invalid-json", "", "Unable to parse manifest: %s", err.Error())
+		return result
+	}
+
+	job := seed.Job
+	iface := job.Interface
+	if seed.SeedVersion == "" {
+		fail("required", "/seedVersion", "seedVersion is required")
+	}
+	if job.Name == "" {
+		fail("required", "/job/name", "Job name is required")
+	} else if !jobNamePattern.MatchString(job.Name) {
+		fail("pattern", "/job/name", "Job name %s may only contain lower case letters, numbers, dashes and "+
+			"underscores", job.Name)
+	}
+	if _, err := ParseVersion(job.JobVersion); err != nil || strings.HasPrefix(job.JobVersion, "v") {
+		fail("semver", "/job/jobVersion", "jobVersion %q is not a semantic version", job.JobVersion)
+	}
+	if _, err := ParseVersion(job.PackageVersion); err != nil || strings.HasPrefix(job.PackageVersion, "v") {
+		fail("semver", "/job/packageVersion", "packageVersion %q is not a semantic version", job.PackageVersion)
+	}
+	if job.Title == "" {
+		fail("required", "/job/title", "Job title is required")
+	}
+	if job.Maintainer.Name == "" {
+		fail("required", "/job/maintainer/name", "Maintainer name is required")
+	}
+	if job.Timeout <= 0 {
+		fail("required", "/job/timeout", "Timeout must be a positive number of seconds")
+	}
+
+	// inputs, json inputs, settings and mounts are all passed as environment variables so their names must be unique
+	names := map[string]string{}
+	checkName := func(path, name string) {
+		if name == "" {
+			fail("required", path+"/name", "Name is required")
+			return
+		}
+		if !parameterNamePattern.MatchString(name) {
+			fail("pattern", path+"/name", "Name %s may only contain letters, dashes and underscores", name)
+		}
+		env := strings.ToUpper(strings.Replace(name, "-", "_", -1))
+		if other, ok := names[env]; ok {
+			fail("duplicate-name", path+"/name", "Name %s is already used at %s", name, other)
+			return
+		}
+		names[env] = path
+	}
+	for i, in := range iface.Inputs.Files {
+		checkName(fmt.Sprintf("/job/interface/inputs/files/%d", i), in.Name)
+	}
+	for i, in := range iface.Inputs.Json {
+		path := fmt.Sprintf("/job/interface/inputs/json/%d", i)
+		checkName(path, in.Name)
+		if _, ok := seedJsonTypes[in.Type]; !ok {
+			fail("enum", path+"/type", "JSON input type %q is not one of string, integer, number, boolean, object or array", in.Type)
+		}
+	}
+	for i, s := range iface.Settings {
+		path := fmt.Sprintf("/job/interface/settings/%d", i)
+		checkName(path, s.Name)
+		if !s.Secret && secretNamePattern.MatchString(s.Name) {
+			warn("secret-setting", path+"/secret", "Setting %s looks like a secret but is not marked secret", s.Name)
+		}
+	}
+	for i, m := range iface.Mounts {
+		path := fmt.Sprintf("/job/interface/mounts/%d", i)
+		checkName(path, m.Name)
+		if !strings.HasPrefix(m.Path, "/") {
+			fail("pattern", path+"/path", "Mount path %q must be absolute", m.Path)
+		}
+		if m.Mode != "" && m.Mode != "ro" && m.Mode != "rw" {
+			fail("enum", path+"/mode", "Mount mode %q must be ro or rw", m.Mode)
+		}
+	}
+
+	outputs := map[string]string{}
+	checkOutput := func(path, name string) {
+		if name == "" {
+			fail("required", path+"/name", "Name is required")
+			return
+		}
+		if other, ok := outputs[name]; ok {
+			fail("duplicate-name", path+"/name", "Name %s is already used at %s", name, other)
+			return
+		}
+		outputs[name] = path
+	}
+	for i, out := range iface.Outputs.Files {
+		path := fmt.Sprintf("/job/interface/outputs/files/%d", i)
+		checkOutput(path, out.Name)
+		if out.Pattern == "" {
+			fail("required", path+"/pattern", "Output file pattern is required")
+		}
+	}
+	for i, out := range iface.Outputs.JSON {
+		path := fmt.Sprintf("/job/interface/outputs/json/%d", i)
+		checkOutput(path, out.Name)
+		if _, ok := seedJsonTypes[out.Type]; !ok {
+			fail("enum", path+"/type", "JSON output type %q is not one of string, integer, number, boolean, object or array", out.Type)
+		}
+	}
+
+	codes := map[int]int{}
+	for i, e := range job.Errors {
+		path := fmt.Sprintf("/job/errors/%d", i)
+		if e.Name == "" {
+			fail("required", path+"/name", "Error name is required")
+		}
+		if e.Category != "" && e.Category != "job" && e.Category != "data" {
+			fail("enum", path+"/category", "Error category %q must be job or data", e.Category)
+		}
+		if other, ok := codes[e.Code]; ok {
+			warn("duplicate-error-code", path+"/code", "Exit code %d is also mapped by /job/errors/%d", e.Code, other)
+			continue
+		}
+		codes[e.Code] = i
+	}
+
+	if job.Description == "" {
+		warn("missing-description", "/job/description", "The job has no description")
+	}
+	if len(job.Tags) == 0 {
+		warn("missing-tags", "/job/tags", "The job has no tags to help users find it")
+	}
+	if job.Maintainer.Email == "" {
+		warn("missing-email", "/job/maintainer/email", "The maintainer has no email")
+	} else if !emailPattern.MatchString(job.Maintainer.Email) {
+		warn("invalid-email", "/job/maintainer/email", "%s does not look like an email address", job.Maintainer.Email)
+	}
+
+	lintResources(db, job, warn)
+	lintCatalog(db, job, warn)
+
+	result.Valid = len(result.Errors) == 0
+	return result
+}
+
+//lintResources warns about resources no node profile can provide, or that exceed the default limits if there are
+//no node profiles
+func lintResources(db *sql.DB, job objects.Job, warn func(rule, path, format string, args ...interface{})) {
+	resources := []ScalarResource{}
+	for _, r := range job.Resources.Scalar {
+		resources = append(resources, ScalarResource{Name: r.Name, Value: r.Value, InputMultiplier: r.InputMultiplier})
+	}
+
+	profiles, err := GetNodeProfiles(db)
+	if err == nil && len(profiles) > 0 {
+		for _, p := range profiles {
+			if ResourcesFit(resources, p, 0) {
+				return
+			}
+		}
+		warn("large-resources", "/job/resources", "The job's resources do not fit any node profile")
+		return
+	}
+
+	for i, r := range resources {
+		if limit, ok := lintResourceLimits[r.Name]; ok && r.Value > limit {
+			warn("large-resources", fmt.Sprintf("/job/resources/scalar/%d/value", i),
+				"%s of %v is more than %v", r.Name, r.Value, limit)
+		}
+	}
+}
+
+//lintCatalog warns if the job name is already used by someone else, or the version has already been published. The
+//same name may be used in several namespaces, so the jobs of the same maintainer are checked in each of them.
+func lintCatalog(db *sql.DB, job objects.Job, warn func(rule, path, format string, args ...interface{})) {
+	jobs, err := ReadJobsByName(db, job.Name)
+	if err != nil || len(jobs) == 0 {
+		return
+	}
+
+	own := []Job{}
+	for _, existing := range jobs {
+		if strings.EqualFold(existing.Email, job.Maintainer.Email) {
+			own = append(own, existing)
+		}
+	}
+	if len(own) == 0 {
+		warn("name-collision", "/job/name", "Job name %s is already used by a job maintained by %s <%s>", job.Name,
+			jobs[0].Maintainer, jobs[0].Email)
+		return
+	}
+
+	for _, existing := range own {
+		var count int
+		row := db.QueryRow("SELECT COUNT(*) FROM Image WHERE job_id=$1 AND job_version=$2 AND package_version=$3",
+			existing.ID, job.JobVersion, job.PackageVersion)
+		if row.Scan(&count) == nil && count > 0 {
+			warn("already-published", "/job/packageVersion", "%s %s package %s is already in the catalog", job.Name,
+				job.JobVersion, job.PackageVersion)
+			return
+		}
+	}
+}
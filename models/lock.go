@@ -0,0 +1,104 @@
+package models
+
+//LockfileVersion is the version of the lockfile format returned by /lock
+const LockfileVersion = 1
+
+const (
+	LockOk      = "ok"
+	LockDrifted = "drifted"
+	LockMissing = "missing"
+	LockError   = "error"
+)
+
+type LockRequest struct {
+	Jobs []LockJob
+}
+
+type LockJob struct {
+	Name           string
+	Namespace      string //namespace of the job, needed if the job name is used in several namespaces
+	Version        string
+	PackageVersion string
+	Registries     []string
+}
+
+type Lockfile struct {
+	LockfileVersion int
+	Created         string
+	Entries         []LockEntry
+}
+
+//LockEntry pins a requested job to a single image by manifest digest
+type LockEntry struct {
+	Job                      string
+	Namespace                string `json:",omitempty"`
+	VersionConstraint        string
+	PackageVersionConstraint string
+	JobVersion               string
+	PackageVersion           string
+	RegistryId               int
+	RegistryName             string
+	RegistryUrl              string
+	Registry                 string
+	Org                      string
+	Name                     string
+	Reference                string
+	Digest                   string
+}
+
+type LockVerification struct {
+	Verified bool
+	Entries  []LockEntryStatus
+}
+
+//LockEntryStatus reports whether a pinned digest still exists in its registry
+type LockEntryStatus struct {
+	Job           string
+	Reference     string
+	Status        string
+	Digest        string
+	CurrentDigest string
+	Error         string `json:",omitempty"`
+}
+
+//NewLockEntry creates a lock entry for the job from its resolution in the given registry
+func NewLockEntry(job LockJob, res Resolution, reg RegistryInfo) LockEntry {
+	return LockEntry{
+		Job:                      job.Name,
+		Namespace:                job.Namespace,
+		VersionConstraint:        job.Version,
+		PackageVersionConstraint: job.PackageVersion,
+		JobVersion:               res.JobVersion,
+		PackageVersion:           res.PackageVersion,
+		RegistryId:               res.RegistryId,
+		RegistryName:             reg.Name,
+		RegistryUrl:              reg.Url,
+		Registry:                 res.Registry,
+		Org:                      res.Org,
+		Name:                     res.Name,
+		Reference:                res.Reference,
+		Digest:                   res.Digest,
+	}
+}
+
+//FindLockRegistry finds the registry a lock entry was pinned against. The id is only trusted if the url still
+//matches since ids are not stable across silo instances.
+func FindLockRegistry(registries []RegistryInfo, entry LockEntry) (RegistryInfo, bool) {
+	host := RegistryHost(entry.RegistryUrl)
+	for _, reg := range registries {
+		if reg.ID == entry.RegistryId && RegistryHost(reg.Url) == host {
+			return reg, true
+		}
+	}
+	for _, reg := range registries {
+		if reg.Name == entry.RegistryName && RegistryHost(reg.Url) == host {
+			return reg, true
+		}
+	}
+	for _, reg := range registries {
+		if RegistryHost(reg.Url) == host && (entry.Org == "" || reg.Org == entry.Org) {
+			return reg, true
+		}
+	}
+	return RegistryInfo{}, false
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+)
+
+//The pseudo-registry holding manually registered images. Scans skip it and keep its images.
+const (
+	ManualRegistryName = "manual"
+	ManualRegistryUrl  = "manual://"
+)
+
+//RegisterImageRequest registers an image silo cannot find by scanning. Image is a reference such as
+//registry.example.com/org/my-job-0.1.0-seed:0.1.0; an image without a registry is on docker hub. The seed manifest is
+//fetched from the image's registry with the given credentials unless it is uploaded as Manifest.
+type RegisterImageRequest struct {
+	Image    string
+	Username string
+	Password string
+	Manifest json.RawMessage
+}
+
+//SplitImageReference splits an image reference into its registry and the image name within the registry
+func SplitImageReference(ref string) (string, string) {
+	parts := strings.SplitN(ref, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		return parts[0], parts[1]
+	}
+	return "docker.io", ref
+}
+
+//IsManualRegistry reports whether a registry is the pseudo-registry of manually registered images
+func IsManualRegistry(r RegistryInfo) bool {
+	return r.Url == ManualRegistryUrl
+}
+
+//FindManualRegistry returns the pseudo-registry of manually registered images, or sql.ErrNoRows if no image has been
+//registered
+func FindManualRegistry(db *sql.DB) (RegistryInfo, error) {
+	row := db.QueryRow("SELECT "+registryColumns+" FROM RegistryInfo WHERE url=$1", ManualRegistryUrl)
+
+	return scanRegistry(row.Scan)
+}
+
+//GetManualRegistry returns the pseudo-registry of manually registered images, adding it if needed
+func GetManualRegistry(db *sql.DB, dbType string) (RegistryInfo, error) {
+	reg, err := FindManualRegistry(db)
+	if err != sql.ErrNoRows {
+		return reg, err
+	}
+
+	reg = RegistryInfo{Name: ManualRegistryName, Url: ManualRegistryUrl}
+	if dbType == "postgres" {
+		reg.ID, err = AddRegistryPg(db, reg)
+	} else {
+		reg.ID, err = AddRegistryLite(db, reg)
+	}
+	return reg, err
+}
+
+//ManualImages returns the manually registered images among the given images
+func ManualImages(db *sql.DB, images []Image) []Image {
+	manual := []Image{}
+	reg, err := FindManualRegistry(db)
+	if err != nil {
+		return manual
+	}
+	for _, img := range images {
+		if img.RegistryId == reg.ID {
+			manual = append(manual, img)
+		}
+	}
+	return manual
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+//OCI annotation keys silo reads from image labels
+const (
+	LabelSource   = "org.opencontainers.image.source"
+	LabelRevision = "org.opencontainers.image.revision"
+	LabelLicenses = "org.opencontainers.image.licenses"
+)
+
+//Orders images can be listed in
+const (
+	SortNewest = "newest"
+	SortOldest = "oldest"
+	SortSize   = "size"
+	SortName   = "name"
+)
+
+//MetadataFilter selects images by their registry metadata. Labels maps label keys to required values; an empty
+//value only requires the label to be present.
+type MetadataFilter struct {
+	License  string
+	Platform string
+	Digest   string
+	Labels   map[string]string
+}
+
+var licenseSeparator = regexp.MustCompile(`[\s()]+|,`)
+
+func (f MetadataFilter) IsEmpty() bool {
+	return f.License == "" && f.Platform == "" && f.Digest == "" && len(f.Labels) == 0
+}
+
+//Matches returns true if an image has all the metadata in the filter. Licenses are matched against each license in
+//an SPDX expression, so a filter of MIT matches "Apache-2.0 OR MIT".
+func (f MetadataFilter) Matches(img Image) bool {
+	if f.License != "" && !hasLicense(img.Labels[LabelLicenses], f.License) {
+		return false
+	}
+	if f.Platform != "" && img.Platform != f.Platform && !strings.HasPrefix(img.Platform, f.Platform+"/") {
+		return false
+	}
+	if f.Digest != "" && img.Digest != f.Digest && img.ConfigDigest != f.Digest {
+		return false
+	}
+	for key, value := range f.Labels {
+		actual, ok := img.Labels[key]
+		if !ok || (value != "" && actual != value) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasLicense(expression, license string) bool {
+	for _, l := range licenseSeparator.Split(expression, -1) {
+		if strings.EqualFold(l, license) {
+			return true
+		}
+	}
+	return false
+}
+
+//SortImages orders images by created time, size or name. Images without a created time are listed last.
+func SortImages(images []SimpleImage, order string) error {
+	created := func(img SimpleImage) time.Time {
+		t, err := time.Parse(time.RFC3339Nano, img.Created)
+		if err != nil {
+			return time.Time{}
+		}
+		return t
+	}
+
+	var less func(a, b SimpleImage) bool
+	switch order {
+	case SortNewest:
+		less = func(a, b SimpleImage) bool { return created(a).After(created(b)) }
+	case SortOldest:
+		less = func(a, b SimpleImage) bool {
+			ta, tb := created(a), created(b)
+			if ta.IsZero() != tb.IsZero() {
+				return tb.IsZero()
+			}
+			return ta.Before(tb)
+		}
+	case SortSize:
+		less = func(a, b SimpleImage) bool { return a.Size > b.Size }
+	case SortName:
+		less = func(a, b SimpleImage) bool { return a.Name < b.Name }
+	default:
+		return fmt.Errorf("Invalid sort %q: must be one of %s, %s, %s or %s", order, SortNewest, SortOldest,
+			SortSize, SortName)
+	}
+
+	sort.SliceStable(images, func(i, j int) bool { return less(images[i], images[j]) })
+	return nil
+}
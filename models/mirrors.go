@@ -0,0 +1,130 @@
+package models
+
+import (
+	"database/sql"
+	"sort"
+)
+
+//Mirror is a copy of an image's manifest in a registry
+type Mirror struct {
+	ImageId    int
+	RegistryId int
+	Registry   string
+	Org        string
+	Name       string
+	Preferred  bool
+}
+
+//MirrorRanks returns the registry preference used to choose between mirrors, from SILO_REGISTRY_ORDER
+func MirrorRanks(db Queryer) map[int]int {
+	registries, err := GetRegistries(db)
+	if err != nil {
+		return map[int]int{}
+	}
+	return RegistryRanks(registries, RegistryOrder())
+}
+
+//preferMirror returns true if image a should be shown instead of its mirror b. Registries earlier in the preference
+//order come first, then registries added first, then the canonical tag within a registry.
+func preferMirror(a, b SimpleImage, ranks map[int]int) bool {
+	if ra, rb := registryRank(ranks, a.RegistryId), registryRank(ranks, b.RegistryId); ra != rb {
+		return ra < rb
+	}
+	if a.RegistryId != b.RegistryId {
+		return a.RegistryId < b.RegistryId
+	}
+	return preferCanonical(a, b)
+}
+
+//mirrorKey groups images with the same manifest. Images without a digest are not grouped.
+func mirrorKey(img SimpleImage) string {
+	if img.Digest == "" {
+		return aliasKey(img)
+	}
+	return img.Digest
+}
+
+//ImageMirrors lists one image for each registry carrying the same manifest as an image, preferred registry first
+func ImageMirrors(db *sql.DB, img Image, ranks map[int]int) []Mirror {
+	key := mirrorKey(SimplifyImage(img))
+	best := map[int]SimpleImage{}
+	for _, other := range ReadSimpleImages(db) {
+		if mirrorKey(other) != key {
+			continue
+		}
+		if current, ok := best[other.RegistryId]; !ok || preferMirror(other, current, ranks) {
+			best[other.RegistryId] = other
+		}
+	}
+
+	copies := []SimpleImage{}
+	for _, other := range best {
+		copies = append(copies, other)
+	}
+	if len(copies) == 0 {
+		copies = append(copies, SimplifyImage(img))
+	}
+	sort.Slice(copies, func(i, j int) bool { return preferMirror(copies[i], copies[j], ranks) })
+
+	mirrors := []Mirror{}
+	for i, c := range copies {
+		mirrors = append(mirrors, Mirror{ImageId: c.ID, RegistryId: c.RegistryId, Registry: c.Registry, Org: c.Org,
+			Name: c.Name, Preferred: i == 0})
+	}
+	return mirrors
+}
+
+//CollapseMirrors replaces the images with the same manifest by the image in the preferred registry, at the position
+//of the first of them. The image kept lists every registry carrying the manifest in Mirrors.
+func CollapseMirrors(images []SimpleImage, ranks map[int]int) []SimpleImage {
+	groups := map[string][]SimpleImage{}
+	order := []string{}
+	for _, img := range images {
+		key := mirrorKey(img)
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], img)
+	}
+
+	result := []SimpleImage{}
+	for _, key := range order {
+		group := groups[key]
+		sort.SliceStable(group, func(i, j int) bool { return preferMirror(group[i], group[j], ranks) })
+
+		hit := group[0]
+		hit.Mirrors = []Mirror{}
+		seen := map[int]bool{}
+		for _, img := range group {
+			if seen[img.RegistryId] {
+				continue
+			}
+			seen[img.RegistryId] = true
+			hit.Mirrors = append(hit.Mirrors, Mirror{ImageId: img.ID, RegistryId: img.RegistryId,
+				Registry: img.Registry, Org: img.Org, Name: img.Name, Preferred: len(hit.Mirrors) == 0})
+		}
+		result = append(result, hit)
+	}
+	return result
+}
+
+//mirrorNamespaces maps each manifest digest to the job namespace of its image in the preferred registry, so that
+//mirrors are merged into the same job whatever the merge policy
+func mirrorNamespaces(images []Image, policy string, ranks map[int]int) map[string]string {
+	preferred := map[string]Image{}
+	for _, img := range images {
+		if img.Digest == "" {
+			continue
+		}
+		current, ok := preferred[img.Digest]
+		if !ok || preferMirror(SimplifyImage(img), SimplifyImage(current), ranks) {
+			preferred[img.Digest] = img
+		}
+	}
+
+	namespaces := map[string]string{}
+	for digest, img := range preferred {
+		namespaces[digest] = JobNamespace(img, policy)
+	}
+	return namespaces
+}
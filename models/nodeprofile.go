@@ -0,0 +1,122 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"strings"
+)
+
+//NodeProfile describes the scalar resources available on a cluster node, e.g. {"cpus": 16, "mem": 65536}. Resource
+//names and units match the resources section of seed manifests.
+type NodeProfile struct {
+	ID        int                `db:"id"`
+	Name      string             `db:"name"`
+	Resources map[string]float64 `db:"resources"`
+}
+
+func CreateNodeProfileTable(db *sql.DB, dbType string) {
+	// create table if it does not exist
+	sql_table := `
+	CREATE TABLE IF NOT EXISTS NodeProfile(
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		resources TEXT
+	);
+	`
+
+	if dbType == "postgres" {
+		sql_table = strings.Replace(sql_table, "id INTEGER PRIMARY KEY AUTOINCREMENT", "id SERIAL PRIMARY KEY", 1)
+	}
+
+	_, err := db.Exec(sql_table)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func AddNodeProfileLite(db *sql.DB, p NodeProfile) (int, error) {
+	resources, err := json.Marshal(p.Resources)
+	if err != nil {
+		return -1, err
+	}
+
+	stmt, err := db.Prepare(`INSERT INTO NodeProfile(name, resources) values(?, ?)`)
+	if err != nil {
+		return -1, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.Exec(p.Name, string(resources))
+
+	id := -1
+	var id64 int64
+	if err == nil {
+		id64, err = result.LastInsertId()
+		id = int(id64)
+	}
+
+	return id, err
+}
+
+func AddNodeProfilePg(db *sql.DB, p NodeProfile) (int, error) {
+	resources, err := json.Marshal(p.Resources)
+	if err != nil {
+		return -1, err
+	}
+
+	query := `INSERT INTO NodeProfile(name, resources) VALUES($1, $2) RETURNING id;`
+
+	var id int
+	err = db.QueryRow(query, p.Name, string(resources)).Scan(&id)
+
+	return id, err
+}
+
+func DeleteNodeProfile(db *sql.DB, id int) error {
+	_, err := db.Exec("DELETE FROM NodeProfile WHERE id=$1", id)
+
+	return err
+}
+
+func GetNodeProfile(db *sql.DB, id int) (NodeProfile, error) {
+	return scanNodeProfile(db.QueryRow("SELECT * FROM NodeProfile WHERE id=$1", id))
+}
+
+func GetNodeProfileByName(db *sql.DB, name string) (NodeProfile, error) {
+	return scanNodeProfile(db.QueryRow("SELECT * FROM NodeProfile WHERE name=$1", name))
+}
+
+func scanNodeProfile(row *sql.Row) (NodeProfile, error) {
+	var result NodeProfile
+	var resources string
+	err := row.Scan(&result.ID, &result.Name, &resources)
+	if err == nil {
+		err = json.Unmarshal([]byte(resources), &result.Resources)
+	}
+
+	return result, err
+}
+
+func GetNodeProfiles(db *sql.DB) ([]NodeProfile, error) {
+	rows, err := db.Query("SELECT * FROM NodeProfile ORDER BY id ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []NodeProfile
+	for rows.Next() {
+		item := NodeProfile{}
+		var resources string
+		err2 := rows.Scan(&item.ID, &item.Name, &resources)
+		if err2 != nil {
+			panic(err2)
+		}
+		if err2 = json.Unmarshal([]byte(resources), &item.Resources); err2 != nil {
+			log.Printf("Error unmarshalling resources for node profile %s: %s \n", item.Name, err2.Error())
+		}
+		result = append(result, item)
+	}
+	return result, err
+}
@@ -0,0 +1,163 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+//Operation states
+const (
+	OperationRunning   = "running"
+	OperationSucceeded = "succeeded"
+	OperationFailed    = "failed"
+)
+
+//Operation types
+const (
+	OperationPromote = "promote"
+	OperationImport  = "import"
+)
+
+//Operation is a long running task started by a request, such as promoting an image to another registry. Done counts
+//the steps finished out of Total; Skipped counts the steps that had nothing to do. Result is set on success.
+type Operation struct {
+	ID         int    `db:"id"`
+	Type       string `db:"type"`
+	Status     string `db:"status"`
+	ImageId    int    `db:"image_id"`
+	RegistryId int    `db:"registry_id"` //target registry
+	Target     string `db:"target"`      //target image reference
+	Done       int    `db:"done"`
+	Total      int    `db:"total"`
+	Skipped    int    `db:"skipped"`
+	Message    string `db:"message"`
+	Error      string `db:"error"`
+	Result     string `db:"result"`
+	Created    string `db:"created"`
+	Updated    string `db:"updated"`
+}
+
+func CreateOperationTable(db *sql.DB, dbType string) {
+	// create table if it does not exist
+	sql_table := `
+	CREATE TABLE IF NOT EXISTS Operation(
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		type TEXT,
+		status TEXT,
+		image_id INTEGER,
+		registry_id INTEGER,
+		target TEXT,
+		done INTEGER,
+		total INTEGER,
+		skipped INTEGER,
+		message TEXT,
+		error TEXT,
+		result TEXT,
+		created TEXT,
+		updated TEXT
+	);
+	`
+
+	if dbType == "postgres" {
+		sql_table = strings.Replace(sql_table, "id INTEGER PRIMARY KEY AUTOINCREMENT", "id SERIAL PRIMARY KEY", 1)
+	}
+
+	_, err := db.Exec(sql_table)
+	if err != nil {
+		panic(err)
+	}
+
+	// operations run in the server process so any still running were interrupted by a restart
+	_, err = db.Exec("UPDATE Operation SET status=$1, error=$2 WHERE status=$3", OperationFailed,
+		"Interrupted by a restart", OperationRunning)
+	if err != nil {
+		panic(err)
+	}
+}
+
+//NewOperation returns a running operation of the given type
+func NewOperation(operationType string) Operation {
+	now := time.Now().UTC().Format(time.RFC3339)
+	return Operation{Type: operationType, Status: OperationRunning, Created: now, Updated: now}
+}
+
+func AddOperationLite(db *sql.DB, op Operation) (int, error) {
+	stmt, err := db.Prepare(`INSERT INTO Operation(type, status, image_id, registry_id, target, done, total, skipped,
+		message, error, result, created, updated) values(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return -1, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.Exec(op.Type, op.Status, op.ImageId, op.RegistryId, op.Target, op.Done, op.Total, op.Skipped,
+		op.Message, op.Error, op.Result, op.Created, op.Updated)
+
+	id := -1
+	var id64 int64
+	if err == nil {
+		id64, err = result.LastInsertId()
+		id = int(id64)
+	}
+
+	return id, err
+}
+
+func AddOperationPg(db *sql.DB, op Operation) (int, error) {
+	query := `INSERT INTO Operation(type, status, image_id, registry_id, target, done, total, skipped, message, error,
+		result, created, updated) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id;`
+
+	var id int
+	err := db.QueryRow(query, op.Type, op.Status, op.ImageId, op.RegistryId, op.Target, op.Done, op.Total, op.Skipped,
+		op.Message, op.Error, op.Result, op.Created, op.Updated).Scan(&id)
+
+	return id, err
+}
+
+//UpdateOperation stores the progress of an operation
+func UpdateOperation(db *sql.DB, op Operation) error {
+	op.Updated = time.Now().UTC().Format(time.RFC3339)
+	_, err := db.Exec(`UPDATE Operation SET status=$1, target=$2, done=$3, total=$4, skipped=$5, message=$6, error=$7,
+		result=$8, updated=$9 WHERE id=$10`, op.Status, op.Target, op.Done, op.Total, op.Skipped, op.Message, op.Error,
+		op.Result, op.Updated, op.ID)
+
+	return err
+}
+
+func GetOperation(db *sql.DB, id int) (Operation, error) {
+	row := db.QueryRow("SELECT * FROM Operation WHERE id=$1", id)
+
+	var op Operation
+	err := row.Scan(&op.ID, &op.Type, &op.Status, &op.ImageId, &op.RegistryId, &op.Target, &op.Done, &op.Total,
+		&op.Skipped, &op.Message, &op.Error, &op.Result, &op.Created, &op.Updated)
+
+	return op, err
+}
+
+func GetOperations(db *sql.DB) []Operation {
+	rows, err := db.Query("SELECT * FROM Operation ORDER BY id DESC")
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+
+	result := []Operation{}
+	for rows.Next() {
+		var op Operation
+		err2 := rows.Scan(&op.ID, &op.Type, &op.Status, &op.ImageId, &op.RegistryId, &op.Target, &op.Done, &op.Total,
+			&op.Skipped, &op.Message, &op.Error, &op.Result, &op.Created, &op.Updated)
+		if err2 != nil {
+			panic(err2)
+		}
+		result = append(result, op)
+	}
+	return result
+}
+
+//PromoteRequest is the body of a request to promote an image to another registry. The tag defaults to the image's
+//tag and the repository to the image's repository name without any namespace.
+type PromoteRequest struct {
+	RegistryId int
+	Tag        string
+	Repository string
+}
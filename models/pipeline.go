@@ -0,0 +1,422 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/ngageoint/seed-common/objects"
+)
+
+const (
+	IssueUnresolvedJob        = "unresolved-job"
+	IssueDuplicateStep        = "duplicate-step"
+	IssueUnknownStep          = "unknown-step"
+	IssueUnknownPort          = "unknown-port"
+	IssueUnmatchedInput       = "unmatched-input"
+	IssueMediaTypeMismatch    = "media-type-mismatch"
+	IssueMultiplicityConflict = "multiplicity-conflict"
+	IssueCycle                = "cycle"
+)
+
+//JobConnection links a file output of one job to a compatible file input of another
+type JobConnection struct {
+	Output          string
+	OutputMediaType string
+	Input           string
+	InputMediaType  string
+}
+
+//CompatibleJob is a job that can be connected to another job, with the compatible inputs and outputs
+type CompatibleJob struct {
+	JobId       int
+	JobName     string
+	Connections []JobConnection
+}
+
+type PipelineStep struct {
+	Name           string //defaults to the job name
+	JobVersionId   int    //either a job version id or a job name and version constraints
+	Job            string
+	Namespace      string //namespace of the job, needed if the job name is used in several namespaces
+	Version        string
+	PackageVersion string
+}
+
+type PipelineConnection struct {
+	From   string
+	Output string
+	To     string
+	Input  string
+}
+
+//PipelineRequest describes a pipeline to validate. If no connections are given the steps are treated as an ordered
+//list and each step's inputs are connected to compatible outputs of the previous step.
+type PipelineRequest struct {
+	Steps       []PipelineStep
+	Connections []PipelineConnection
+}
+
+type ResolvedStep struct {
+	Name           string
+	Job            string
+	JobVersion     string
+	PackageVersion string
+	ImageId        int
+}
+
+type PipelineIssue struct {
+	Type    string
+	Step    string
+	Input   string `json:",omitempty"`
+	Output  string `json:",omitempty"`
+	Message string
+}
+
+type PipelineValidation struct {
+	Valid       bool
+	Steps       []ResolvedStep
+	Connections []PipelineConnection
+	Issues      []PipelineIssue
+}
+
+//filePort is a file input or output of a seed interface
+type filePort struct {
+	name       string
+	mediaTypes []string
+	multiple   bool
+	required   bool
+}
+
+func inputPorts(seed objects.Seed) []filePort {
+	ports := []filePort{}
+	for _, in := range seed.Job.Interface.Inputs.Files {
+		ports = append(ports, filePort{name: in.Name, mediaTypes: in.MediaTypes, multiple: in.Multiple, required: in.Required})
+	}
+	return ports
+}
+
+func outputPorts(seed objects.Seed) []filePort {
+	ports := []filePort{}
+	for _, out := range seed.Job.Interface.Outputs.Files {
+		port := filePort{name: out.Name, multiple: out.Multiple, required: out.Required}
+		if out.MediaType != "" {
+			port.mediaTypes = []string{out.MediaType}
+		}
+		ports = append(ports, port)
+	}
+	return ports
+}
+
+func findPort(ports []filePort, name string) (filePort, bool) {
+	for _, p := range ports {
+		if p.name == name {
+			return p, true
+		}
+	}
+	return filePort{}, false
+}
+
+//portsCompatible reports whether an output can feed an input. Ports without declared media types are assumed to
+//be compatible.
+func portsCompatible(out, in filePort) bool {
+	if len(out.mediaTypes) == 0 || len(in.mediaTypes) == 0 {
+		return true
+	}
+	_, _, ok := matchingMediaTypes(out, in)
+	return ok
+}
+
+func matchingMediaTypes(out, in filePort) (string, string, bool) {
+	for _, o := range out.mediaTypes {
+		for _, i := range in.mediaTypes {
+			if MediaTypeMatches(o, i) {
+				return o, i, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+type jobPort struct {
+	jobId     int
+	name      string
+	mediaType string
+}
+
+func readJobPorts(db *sql.DB, table string) []jobPort {
+	query := `SELECT DISTINCT i.job_id, p.name, p.media_type FROM ` + table + ` p
+		JOIN Image i ON p.image_id = i.id
+		WHERE p.kind='file' AND p.media_type <> ''`
+
+	rows, err := db.Query(query)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+
+	var result []jobPort
+	for rows.Next() {
+		item := jobPort{}
+		err2 := rows.Scan(&item.jobId, &item.name, &item.mediaType)
+		if err2 != nil {
+			panic(err2)
+		}
+		result = append(result, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Fatal(err)
+	}
+	return result
+}
+
+//DownstreamJobs returns the jobs with file inputs accepting one of the given job's file outputs
+func DownstreamJobs(db *sql.DB, jobId int) []CompatibleJob {
+	return compatibleJobs(db, jobId, true)
+}
+
+//UpstreamJobs returns the jobs with file outputs accepted by one of the given job's file inputs
+func UpstreamJobs(db *sql.DB, jobId int) []CompatibleJob {
+	return compatibleJobs(db, jobId, false)
+}
+
+func compatibleJobs(db *sql.DB, jobId int, downstream bool) []CompatibleJob {
+	outputs := readJobPorts(db, "InterfaceOutput")
+	inputs := readJobPorts(db, "InterfaceInput")
+
+	names := make(map[int]string)
+	for _, job := range ReadJobs(db) {
+		names[job.ID] = job.Name
+	}
+
+	result := []CompatibleJob{}
+	index := make(map[int]int)
+	seen := make(map[JobConnection]map[int]bool)
+	for _, out := range outputs {
+		for _, in := range inputs {
+			if out.jobId == in.jobId || !MediaTypeMatches(out.mediaType, in.mediaType) {
+				continue
+			}
+			other := in.jobId
+			if downstream && out.jobId != jobId {
+				continue
+			}
+			if !downstream {
+				if in.jobId != jobId {
+					continue
+				}
+				other = out.jobId
+			}
+
+			conn := JobConnection{Output: out.name, OutputMediaType: out.mediaType, Input: in.name, InputMediaType: in.mediaType}
+			if seen[conn] == nil {
+				seen[conn] = make(map[int]bool)
+			}
+			if seen[conn][other] {
+				continue
+			}
+			seen[conn][other] = true
+
+			i, ok := index[other]
+			if !ok {
+				i = len(result)
+				index[other] = i
+				result = append(result, CompatibleJob{JobId: other, JobName: names[other]})
+			}
+			result[i].Connections = append(result[i].Connections, conn)
+		}
+	}
+
+	return result
+}
+
+//ValidatePipeline resolves the steps of a pipeline and checks that every connection joins compatible ports
+func ValidatePipeline(db *sql.DB, request PipelineRequest) PipelineValidation {
+	result := PipelineValidation{Steps: []ResolvedStep{}, Connections: []PipelineConnection{}, Issues: []PipelineIssue{}}
+	addIssue := func(issueType, step, input, output, msg string) {
+		result.Issues = append(result.Issues, PipelineIssue{Type: issueType, Step: step, Input: input, Output: output, Message: msg})
+	}
+
+	seeds := make(map[string]objects.Seed)
+	names := []string{}
+	for i, step := range request.Steps {
+		query := ResolveQuery{Job: step.Job, Namespace: step.Namespace, Version: step.Version,
+			PackageVersion: step.PackageVersion}
+		if step.JobVersionId != 0 {
+			jv, err := ReadJobVersion(db, step.JobVersionId)
+			if err != nil {
+				addIssue(IssueUnresolvedJob, step.Name, "", "", fmt.Sprintf("No job version found with ID %d", step.JobVersionId))
+				names = append(names, step.Name)
+				continue
+			}
+			query.Job = jv.JobName
+			query.Version = "=" + jv.JobVersion
+			query.JobVersionId = jv.ID
+		}
+
+		name := step.Name
+		if name == "" {
+			name = query.Job
+		}
+		if name == "" {
+			name = fmt.Sprintf("step%d", i+1)
+		}
+		names = append(names, name)
+		if _, ok := seeds[name]; ok {
+			addIssue(IssueDuplicateStep, name, "", "", "Step names must be unique")
+			continue
+		}
+
+		res, err := ResolveJob(db, query)
+		if err != nil {
+			addIssue(IssueUnresolvedJob, name, "", "", fmt.Sprintf("Unable to resolve %s %s: %s", query.Job, query.Version, err.Error()))
+			continue
+		}
+		seeds[name] = res.Manifest
+		result.Steps = append(result.Steps, ResolvedStep{Name: name, Job: res.JobName, JobVersion: res.JobVersion,
+			PackageVersion: res.PackageVersion, ImageId: res.ImageId})
+	}
+
+	connections := request.Connections
+	hasUpstream := make(map[string]bool)
+	if len(connections) == 0 {
+		for i := 1; i < len(names); i++ {
+			from, to := names[i-1], names[i]
+			hasUpstream[to] = true
+			fromSeed, ok1 := seeds[from]
+			toSeed, ok2 := seeds[to]
+			if !ok1 || !ok2 {
+				continue
+			}
+			for _, in := range inputPorts(toSeed) {
+				for _, out := range outputPorts(fromSeed) {
+					if _, _, ok := matchingMediaTypes(out, in); ok {
+						connections = append(connections, PipelineConnection{From: from, Output: out.name, To: to, Input: in.name})
+						break
+					}
+				}
+			}
+		}
+	} else {
+		for _, conn := range connections {
+			hasUpstream[conn.To] = true
+		}
+		if cycle := findCycle(names, connections); cycle != "" {
+			addIssue(IssueCycle, cycle, "", "", "Connections must not form a cycle")
+		}
+	}
+
+	connected := make(map[string]map[string]int)
+	for _, conn := range connections {
+		result.Connections = append(result.Connections, conn)
+
+		fromSeed, ok1 := seeds[conn.From]
+		toSeed, ok2 := seeds[conn.To]
+		if !ok1 || !ok2 {
+			missing := conn.From
+			if ok1 {
+				missing = conn.To
+			}
+			if !containsName(names, missing) {
+				addIssue(IssueUnknownStep, missing, conn.Input, conn.Output, "No step named "+missing)
+			}
+			continue
+		}
+
+		out, ok := findPort(outputPorts(fromSeed), conn.Output)
+		if !ok {
+			addIssue(IssueUnknownPort, conn.From, "", conn.Output, "No file output named "+conn.Output)
+			continue
+		}
+		in, ok := findPort(inputPorts(toSeed), conn.Input)
+		if !ok {
+			addIssue(IssueUnknownPort, conn.To, conn.Input, "", "No file input named "+conn.Input)
+			continue
+		}
+
+		if connected[conn.To] == nil {
+			connected[conn.To] = make(map[string]int)
+		}
+		connected[conn.To][conn.Input]++
+
+		if !portsCompatible(out, in) {
+			addIssue(IssueMediaTypeMismatch, conn.To, conn.Input, conn.From+"."+conn.Output,
+				fmt.Sprintf("Output media types %v are not accepted by input media types %v", out.mediaTypes, in.mediaTypes))
+		}
+		if out.multiple && !in.multiple {
+			addIssue(IssueMultiplicityConflict, conn.To, conn.Input, conn.From+"."+conn.Output,
+				"Output produces multiple files but input accepts a single file")
+		}
+	}
+
+	for _, name := range names {
+		seed, ok := seeds[name]
+		if !ok {
+			continue
+		}
+		for _, in := range inputPorts(seed) {
+			count := connected[name][in.name]
+			if count > 1 && !in.multiple {
+				addIssue(IssueMultiplicityConflict, name, in.name, "",
+					fmt.Sprintf("Input accepts a single file but is connected to %d outputs", count))
+			}
+			// inputs of steps without upstream steps are supplied to the pipeline directly
+			if count == 0 && in.required && hasUpstream[name] {
+				addIssue(IssueUnmatchedInput, name, in.name, "", "Required input is not connected to any output")
+			}
+		}
+	}
+
+	result.Valid = len(result.Issues) == 0
+	return result
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+//findCycle returns the name of a step on a cycle formed by the connections, or an empty string
+func findCycle(names []string, connections []PipelineConnection) string {
+	edges := make(map[string][]string)
+	for _, conn := range connections {
+		edges[conn.From] = append(edges[conn.From], conn.To)
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+	var visit func(string) string
+	visit = func(name string) string {
+		state[name] = visiting
+		for _, next := range edges[name] {
+			switch state[next] {
+			case visiting:
+				return next
+			case unvisited:
+				if c := visit(next); c != "" {
+					return c
+				}
+			}
+		}
+		state[name] = done
+		return ""
+	}
+
+	for _, name := range names {
+		if state[name] == unvisited {
+			if c := visit(name); c != "" {
+				return c
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,256 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/ngageoint/seed-common/objects"
+)
+
+//Types of versioning policy finding
+const (
+	PolicyBreakingWithoutMajor = "breaking-change-without-major-bump"
+	PolicyInterfaceInPackage   = "interface-change-in-package"
+	PolicyIdenticalManifest    = "identical-manifest"
+)
+
+//Severities of policy findings
+const (
+	PolicyError   = "error"
+	PolicyWarning = "warning"
+)
+
+//PolicyFinding is a violation of semantic versioning between two consecutive versions of a job. Job versions are
+//compared using their latest packages; packages are compared within a job version.
+type PolicyFinding struct {
+	ID                 int    `db:"id"`
+	JobId              int    `db:"job_id"`
+	JobName            string `db:"job_name"`
+	ImageId            int    `db:"image_id"` //image of the newer version
+	Type               string `db:"type"`
+	Severity           string `db:"severity"`
+	FromJobVersion     string `db:"from_job_version"`
+	FromPackageVersion string `db:"from_package_version"`
+	ToJobVersion       string `db:"to_job_version"`
+	ToPackageVersion   string `db:"to_package_version"`
+	Message            string `db:"message"`
+}
+
+func CreatePolicyTable(db *sql.DB, dbType string) {
+	// create table if it does not exist
+	sql_table := `
+	CREATE TABLE IF NOT EXISTS PolicyFinding(
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		job_id INTEGER NOT NULL,
+		job_name TEXT,
+		image_id INTEGER,
+		type TEXT,
+		severity TEXT,
+		from_job_version TEXT,
+		from_package_version TEXT,
+		to_job_version TEXT,
+		to_package_version TEXT,
+		message TEXT,
+		CONSTRAINT fk_policy_job_id
+		    FOREIGN KEY (job_id)
+		    REFERENCES Job (id)
+		    ON DELETE CASCADE
+	);
+	`
+
+	if dbType == "postgres" {
+		sql_table = strings.Replace(sql_table, "id INTEGER PRIMARY KEY AUTOINCREMENT", "id SERIAL PRIMARY KEY", 1)
+	}
+
+	_, err := db.Exec(sql_table)
+	if err != nil {
+		panic(err)
+	}
+}
+
+//BuildPolicyFindings checks the versions of every job against the versioning policy, replacing any previous findings
+func BuildPolicyFindings(db Queryer) {
+	_, err := db.Exec("DELETE FROM PolicyFinding")
+	if err != nil {
+		panic(err)
+	}
+
+	// the same package may be in several registries; they share a manifest so only the first is checked
+	jobs := map[int][]Image{}
+	seen := map[string]bool{}
+	for _, img := range ReadImages(db) {
+		key := fmt.Sprintf("%d %s %s", img.JobId, img.JobVersion, img.PackageVersion)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		jobs[img.JobId] = append(jobs[img.JobId], img)
+	}
+
+	query := `INSERT INTO PolicyFinding(job_id, job_name, image_id, type, severity, from_job_version,
+		from_package_version, to_job_version, to_package_version, message) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	for _, images := range jobs {
+		for _, f := range CheckVersionPolicy(images) {
+			_, err := db.Exec(query, f.JobId, f.JobName, f.ImageId, f.Type, f.Severity, f.FromJobVersion,
+				f.FromPackageVersion, f.ToJobVersion, f.ToPackageVersion, f.Message)
+			if err != nil {
+				log.Printf("Error storing policy finding for %s: %s \n", f.JobName, err.Error())
+			}
+		}
+	}
+}
+
+//CheckVersionPolicy compares consecutive packages of each job version, and the latest packages of consecutive job
+//versions, of one job's images. A breaking change needs a new major job version, or a new minor version before 1.0.0.
+//Any interface change needs a new job version, and a new job version should change the manifest.
+func CheckVersionPolicy(images []Image) []PolicyFinding {
+	findings := []PolicyFinding{}
+	images = append([]Image(nil), images...)
+	sort.Slice(images, func(i, j int) bool {
+		if c := CompareVersions(images[i].JobVersion, images[j].JobVersion); c != 0 {
+			return c < 0
+		}
+		return CompareVersions(images[i].PackageVersion, images[j].PackageVersion) < 0
+	})
+	add := func(from, to Image, findingType, severity, message string) {
+		findings = append(findings, PolicyFinding{JobId: to.JobId, JobName: to.ShortName, ImageId: to.ID,
+			Type: findingType, Severity: severity, FromJobVersion: from.JobVersion, FromPackageVersion: from.PackageVersion,
+			ToJobVersion: to.JobVersion, ToPackageVersion: to.PackageVersion, Message: message})
+	}
+
+	var latest []Image
+	for i, img := range images {
+		if i+1 < len(images) && images[i+1].JobVersion == img.JobVersion {
+			next := images[i+1]
+			if changes := DiffManifests(img.Seed, next.Seed); len(changes) > 0 {
+				severity := PolicyWarning
+				if hasBreaking(changes) {
+					severity = PolicyError
+				}
+				add(img, next, PolicyInterfaceInPackage, severity, fmt.Sprintf("Package %s changes the job interface "+
+					"without a new job version: %s", next.PackageVersion, describeChanges(changes)))
+			}
+			continue
+		}
+		latest = append(latest, img)
+	}
+
+	for i := 1; i < len(latest); i++ {
+		from, to := latest[i-1], latest[i]
+		changes := DiffManifests(from.Seed, to.Seed)
+		if hasBreaking(changes) && !majorBump(from.JobVersion, to.JobVersion) {
+			add(from, to, PolicyBreakingWithoutMajor, PolicyError, fmt.Sprintf("Job version %s has breaking changes "+
+				"from %s without a major version bump: %s", to.JobVersion, from.JobVersion, describeBreaking(changes)))
+		}
+		if sameManifest(from.Seed, to.Seed) {
+			add(from, to, PolicyIdenticalManifest, PolicyWarning, fmt.Sprintf("Job version %s has the same manifest "+
+				"as %s", to.JobVersion, from.JobVersion))
+		}
+	}
+
+	return findings
+}
+
+func hasBreaking(changes []ManifestChange) bool {
+	for _, c := range changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+func describeChanges(changes []ManifestChange) string {
+	descriptions := []string{}
+	for _, c := range changes {
+		descriptions = append(descriptions, describeChange(c))
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+func describeBreaking(changes []ManifestChange) string {
+	breaking := []ManifestChange{}
+	for _, c := range changes {
+		if c.Breaking {
+			breaking = append(breaking, c)
+		}
+	}
+	return describeChanges(breaking)
+}
+
+func describeChange(c ManifestChange) string {
+	if c.Kind == ChangeChanged {
+		return fmt.Sprintf("%s %s %s changed from %q to %q", c.Section, c.Name, c.Field, c.From, c.To)
+	}
+	return fmt.Sprintf("%s %s %s", c.Kind, c.Section, c.Name)
+}
+
+//majorBump returns true if the version to is incompatible with from under semantic versioning
+func majorBump(from, to string) bool {
+	f, err1 := ParseVersion(from)
+	t, err2 := ParseVersion(to)
+	if err1 != nil || err2 != nil {
+		return from != to
+	}
+	if t.Major != f.Major {
+		return true
+	}
+	return t.Major == 0 && t.Minor != f.Minor
+}
+
+//sameManifest returns true if two manifests only differ in their job and package versions
+func sameManifest(a, b objects.Seed) bool {
+	a.Job.JobVersion, a.Job.PackageVersion = "", ""
+	b.Job.JobVersion, b.Job.PackageVersion = "", ""
+	aJson, err1 := json.Marshal(a)
+	bJson, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && string(aJson) == string(bJson)
+}
+
+func GetJobPolicyFindings(db *sql.DB, jobId int) []PolicyFinding {
+	rows, err := db.Query("SELECT * FROM PolicyFinding WHERE job_id=$1 ORDER BY id ASC", jobId)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+
+	result := []PolicyFinding{}
+	for rows.Next() {
+		item := PolicyFinding{}
+		err2 := rows.Scan(&item.ID, &item.JobId, &item.JobName, &item.ImageId, &item.Type, &item.Severity,
+			&item.FromJobVersion, &item.FromPackageVersion, &item.ToJobVersion, &item.ToPackageVersion, &item.Message)
+		if err2 != nil {
+			panic(err2)
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+//CountRegistryPolicyFindings returns the number of findings of each type for the jobs with images in a registry
+func CountRegistryPolicyFindings(db *sql.DB, registryId int) map[string]int {
+	query := `SELECT type, COUNT(*) FROM PolicyFinding
+		WHERE job_id IN (SELECT DISTINCT job_id FROM Image WHERE registry_id=$1)
+		GROUP BY type`
+	rows, err := db.Query(query, registryId)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+
+	result := map[string]int{}
+	for rows.Next() {
+		var findingType string
+		var count int
+		err2 := rows.Scan(&findingType, &count)
+		if err2 != nil {
+			panic(err2)
+		}
+		result[findingType] = count
+	}
+	return result
+}
@@ -2,25 +2,43 @@ package models
 
 import (
 	"database/sql"
+	"encoding/json"
 	"log"
 	"strings"
 )
 
 //TODO: find better way to store credentials for low side registries
 type RegistryInfo struct {
-	ID       int    `db:"id"`
-	Name     string `db:"name"`
-	Url      string `db:"url"`
-	Org      string `db:"org"`
-	Username string `db:"username"`
-	Password string `db:"password"`
+	ID           int      `db:"id"`
+	Name         string   `db:"name"`
+	Url          string   `db:"url"`
+	Org          string   `db:"org"`  //default org, also scanned
+	Orgs         []string `db:"orgs"` //further orgs or glob patterns scanned
+	Username     string   `db:"username"`
+	Password     string   `db:"password"`
+	Repositories []string `db:"repositories"` //repository names or glob patterns scanned instead of the catalog
 }
 
 type DisplayRegistry struct {
-	ID   int    `db:"id"`
-	Name string `db:"name"`
-	Url  string `db:"url"`
-	Org  string `db:"org"`
+	ID           int      `db:"id"`
+	Name         string   `db:"name"`
+	Url          string   `db:"url"`
+	Org          string   `db:"org"`
+	Orgs         []string `db:"orgs"`
+	Repositories []string `db:"repositories"`
+}
+
+//ScanOrgs returns the orgs scanned in a registry: its default org followed by its further orgs
+func (r RegistryInfo) ScanOrgs() []string {
+	orgs := []string{}
+	seen := map[string]bool{}
+	for _, org := range append([]string{r.Org}, r.Orgs...) {
+		if org != "" && !seen[org] {
+			seen[org] = true
+			orgs = append(orgs, org)
+		}
+	}
+	return orgs
 }
 
 func CreateRegistryTable(db *sql.DB, dbType string) {
@@ -32,7 +50,9 @@ func CreateRegistryTable(db *sql.DB, dbType string) {
 		url TEXT,
 		org TEXT,
 		username TEXT,
-		password TEXT
+		password TEXT,
+		repositories TEXT NOT NULL DEFAULT '[]',
+		orgs TEXT NOT NULL DEFAULT '[]'
 	);
 	`
 
@@ -44,17 +64,63 @@ func CreateRegistryTable(db *sql.DB, dbType string) {
 	if err != nil {
 		panic(err)
 	}
+	migrateRegistryTable(db)
+}
+
+//migrateRegistryTable adds the repository and org list columns to a registry table created before they existed
+func migrateRegistryTable(db *sql.DB) {
+	for _, column := range []string{"repositories", "orgs"} {
+		if _, err := db.Exec("SELECT " + column + " FROM RegistryInfo LIMIT 1"); err == nil {
+			continue
+		}
+
+		log.Printf("Adding %s lists to the registry table", column)
+		if _, err := db.Exec("ALTER TABLE RegistryInfo ADD COLUMN " + column + " TEXT NOT NULL DEFAULT '[]'"); err != nil {
+			panic(err)
+		}
+	}
 }
 
-func AddRegistryLite(db *sql.DB, r RegistryInfo) (int, error) {
+//listJson serializes a registry's repository or org list for storage
+func listJson(list []string) string {
+	if list == nil {
+		return "[]"
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return "[]"
+	}
+	return string(data)
+}
+
+//registryColumns are the columns read by scanRegistry
+const registryColumns = "id, name, url, org, username, password, repositories, orgs"
+
+//scanRegistry reads a registry selected with registryColumns
+func scanRegistry(scan func(dest ...interface{}) error) (RegistryInfo, error) {
+	var result RegistryInfo
+	var repositories, orgs string
+	err := scan(&result.ID, &result.Name, &result.Url, &result.Org, &result.Username, &result.Password, &repositories,
+		&orgs)
+	if err == nil {
+		json.Unmarshal([]byte(repositories), &result.Repositories)
+		json.Unmarshal([]byte(orgs), &result.Orgs)
+	}
+
+	return result, err
+}
+
+func AddRegistryLite(db Queryer, r RegistryInfo) (int, error) {
 	sql_addreg := `
 	INSERT INTO RegistryInfo(
 		name,
 		url,
 	    org,
 		username,
-		password
-	) values(?, ?, ?, ?, ?)
+		password,
+		repositories,
+		orgs
+	) values(?, ?, ?, ?, ?, ?, ?)
 	`
 
 	stmt, err := db.Prepare(sql_addreg)
@@ -63,7 +129,7 @@ func AddRegistryLite(db *sql.DB, r RegistryInfo) (int, error) {
 	}
 	defer stmt.Close()
 
-	result, err := stmt.Exec(r.Name, r.Url, r.Org, r.Username, r.Password)
+	result, err := stmt.Exec(r.Name, r.Url, r.Org, r.Username, r.Password, listJson(r.Repositories), listJson(r.Orgs))
 
 	id := -1
 	var id64 int64
@@ -75,17 +141,18 @@ func AddRegistryLite(db *sql.DB, r RegistryInfo) (int, error) {
 	return id, err
 }
 
-func AddRegistryPg(db *sql.DB, r RegistryInfo) (int, error) {
-	query := `INSERT INTO RegistryInfo(name, url, org, username, password) 
-			VALUES($1, $2, $3, $4, $5) RETURNING id;`
+func AddRegistryPg(db Queryer, r RegistryInfo) (int, error) {
+	query := `INSERT INTO RegistryInfo(name, url, org, username, password, repositories, orgs) 
+			VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id;`
 
 	var id int
-	err := db.QueryRow(query, r.Name, r.Url, r.Org, r.Username, r.Password).Scan(&id)
+	err := db.QueryRow(query, r.Name, r.Url, r.Org, r.Username, r.Password, listJson(r.Repositories),
+		listJson(r.Orgs)).Scan(&id)
 
 	return id, err
 }
 
-func DeleteRegistry(db *sql.DB, id int) error {
+func DeleteRegistry(db Queryer, id int) error {
 	_, err := db.Exec("DELETE FROM RegistryInfo WHERE id=$1", id)
 
 	return err
@@ -94,7 +161,7 @@ func DeleteRegistry(db *sql.DB, id int) error {
 //Get list of registries without username/password for display
 func DisplayRegistries(db *sql.DB) ([]DisplayRegistry, error) {
 	sql_readall := `
-	SELECT id, name, url, org FROM RegistryInfo
+	SELECT id, name, url, org, repositories, orgs FROM RegistryInfo
 	ORDER BY id ASC
 	`
 
@@ -107,10 +174,13 @@ func DisplayRegistries(db *sql.DB) ([]DisplayRegistry, error) {
 	var result []DisplayRegistry
 	for rows.Next() {
 		item := DisplayRegistry{}
-		err2 := rows.Scan(&item.ID, &item.Name, &item.Url, &item.Org)
+		var repositories, orgs string
+		err2 := rows.Scan(&item.ID, &item.Name, &item.Url, &item.Org, &repositories, &orgs)
 		if err2 != nil {
 			return nil, err
 		}
+		json.Unmarshal([]byte(repositories), &item.Repositories)
+		json.Unmarshal([]byte(orgs), &item.Orgs)
 		result = append(result, item)
 	}
 
@@ -121,16 +191,13 @@ func DisplayRegistries(db *sql.DB) ([]DisplayRegistry, error) {
 }
 
 func GetRegistry(db *sql.DB, id int) (RegistryInfo, error) {
-	row := db.QueryRow("SELECT * FROM RegistryInfo WHERE id=$1", id)
+	row := db.QueryRow("SELECT "+registryColumns+" FROM RegistryInfo WHERE id=$1", id)
 
-	var result RegistryInfo
-	err := row.Scan(&result.ID, &result.Name, &result.Url, &result.Org, &result.Username, &result.Password)
-
-	return result, err
+	return scanRegistry(row.Scan)
 }
 
-func GetRegistries(db *sql.DB) ([]RegistryInfo, error) {
-	rows, err := db.Query("SELECT * FROM RegistryInfo")
+func GetRegistries(db Queryer) ([]RegistryInfo, error) {
+	rows, err := db.Query("SELECT " + registryColumns + " FROM RegistryInfo")
 	if err != nil {
 		return nil, err
 	}
@@ -138,8 +205,7 @@ func GetRegistries(db *sql.DB) ([]RegistryInfo, error) {
 
 	var result []RegistryInfo
 	for rows.Next() {
-		item := RegistryInfo{}
-		err2 := rows.Scan(&item.ID, &item.Name, &item.Url, &item.Org, &item.Username, &item.Password)
+		item, err2 := scanRegistry(rows.Scan)
 		if err2 != nil {
 			panic(err2)
 		}
@@ -147,3 +213,29 @@ func GetRegistries(db *sql.DB) ([]RegistryInfo, error) {
 	}
 	return result, err
 }
+
+//RegistryStatus summarizes what has been scanned from a registry
+type RegistryStatus struct {
+	Registry       DisplayRegistry
+	Images         int
+	Jobs           int
+	PolicyFindings map[string]int //number of versioning policy findings of each type for the registry's jobs
+}
+
+func GetRegistryStatus(db *sql.DB, id int) (RegistryStatus, error) {
+	var result RegistryStatus
+	reg, err := GetRegistry(db, id)
+	if err != nil {
+		return result, err
+	}
+	result.Registry = DisplayRegistry{ID: reg.ID, Name: reg.Name, Url: reg.Url, Org: reg.Org, Orgs: reg.Orgs,
+		Repositories: reg.Repositories}
+
+	row := db.QueryRow("SELECT COUNT(*), COUNT(DISTINCT job_id) FROM Image WHERE registry_id=$1", id)
+	if err = row.Scan(&result.Images, &result.Jobs); err != nil {
+		return result, err
+	}
+	result.PolicyFindings = CountRegistryPolicyFindings(db, id)
+
+	return result, nil
+}
@@ -0,0 +1,251 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ngageoint/seed-common/objects"
+)
+
+//ErrNoMatch is returned when no image satisfies a resolve query
+var ErrNoMatch = errors.New("No image found matching the given job and version constraints")
+
+//AmbiguousJobError is returned when a resolve query names a job in several namespaces without giving its namespace
+type AmbiguousJobError struct {
+	Job        string
+	Namespaces []string
+}
+
+func (e AmbiguousJobError) Error() string {
+	return fmt.Sprintf("Job %s is in several namespaces, give one of: %s", e.Job, strings.Join(e.Namespaces, ", "))
+}
+
+//InvalidConstraintError is returned when a version constraint of a resolve query can't be parsed
+type InvalidConstraintError struct {
+	Err error
+}
+
+func (e InvalidConstraintError) Error() string {
+	return e.Err.Error()
+}
+
+type ResolveQuery struct {
+	Job            string
+	Namespace      string //namespace of the job, needed if the job name is used in several namespaces
+	Version        string
+	PackageVersion string
+	Registries     []string //registry names or urls in order of preference; images in other registries are not considered
+	Preferred      []string //registry names or urls in order of preference, only breaking ties when Registries is empty
+	JobVersionId   int      //only the images of this job version are considered, if set
+}
+
+type Resolution struct {
+	JobId          int
+	JobName        string
+	JobVersionId   int
+	JobVersion     string
+	PackageVersion string
+	ImageId        int
+	RegistryId     int
+	Registry       string
+	Org            string
+	Name           string
+	Digest         string
+	Reference      string //fully qualified pullable reference, e.g. registry/org/name:tag@digest
+	Manifest       objects.Seed
+}
+
+//RegistryOrder returns the default registry preference order from the comma separated SILO_REGISTRY_ORDER variable
+func RegistryOrder() []string {
+	return SplitList(os.Getenv("SILO_REGISTRY_ORDER"))
+}
+
+//SplitList splits a comma separated list, dropping empty entries
+func SplitList(list string) []string {
+	result := []string{}
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+//ResolveJob finds the image with the highest job and package versions satisfying the query. Images in registries
+//earlier in the query's registry list, or its preferred registries if it lists none, are preferred when versions are
+//equal. If the query lists registries, images in other registries are not considered.
+func ResolveJob(db *sql.DB, query ResolveQuery) (Resolution, error) {
+	result := Resolution{}
+	jobConstraint, err := ParseConstraint(query.Version)
+	if err != nil {
+		return result, InvalidConstraintError{err}
+	}
+	packageConstraint, err := ParseConstraint(query.PackageVersion)
+	if err != nil {
+		return result, InvalidConstraintError{err}
+	}
+
+	registries, err := GetRegistries(db)
+	if err != nil {
+		return result, err
+	}
+	ranks := RegistryRanks(registries, query.Registries)
+	if len(query.Registries) == 0 {
+		ranks = RegistryRanks(registries, query.Preferred)
+	}
+	// job names are only unique within a namespace, so a job version is matched by its own images and a job name
+	// by the images of the job in the query's namespace
+	var jobVersionImages, jobIds map[int]bool
+	if query.JobVersionId != 0 {
+		jobVersionImages = map[int]bool{}
+		for _, id := range GetJobVersionImageIds(db, query.JobVersionId) {
+			jobVersionImages[id] = true
+		}
+	} else if jobIds, err = queryJobs(db, query); err != nil {
+		return result, err
+	}
+
+	candidates := []Image{}
+	for _, img := range ReadImages(db) {
+		if img.Seed.Job.Name != query.Job {
+			continue
+		}
+		if jobVersionImages != nil && !jobVersionImages[img.ID] {
+			continue
+		}
+		if jobIds != nil && !jobIds[img.JobId] {
+			continue
+		}
+		if _, ok := ranks[img.RegistryId]; !ok && len(query.Registries) > 0 {
+			continue
+		}
+		if !jobConstraint.Check(img.Seed.Job.JobVersion) || !packageConstraint.Check(img.Seed.Job.PackageVersion) {
+			continue
+		}
+		candidates = append(candidates, img)
+	}
+
+	if len(candidates) == 0 {
+		return result, ErrNoMatch
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if c := CompareVersions(a.Seed.Job.JobVersion, b.Seed.Job.JobVersion); c != 0 {
+			return c > 0
+		}
+		if c := CompareVersions(a.Seed.Job.PackageVersion, b.Seed.Job.PackageVersion); c != 0 {
+			return c > 0
+		}
+		return registryRank(ranks, a.RegistryId) < registryRank(ranks, b.RegistryId)
+	})
+
+	best := candidates[0]
+	result.JobId = best.JobId
+	result.JobName = best.Seed.Job.Name
+	result.JobVersionId = best.JobVersionId
+	result.JobVersion = best.Seed.Job.JobVersion
+	result.PackageVersion = best.Seed.Job.PackageVersion
+	result.ImageId = best.ID
+	result.RegistryId = best.RegistryId
+	result.Registry = best.Registry
+	result.Org = best.Org
+	result.Name = best.FullName
+	result.Reference = ImageReference(best.Registry, best.Org, best.FullName, "")
+	result.Manifest = best.Seed
+
+	return result, nil
+}
+
+//queryJobs returns the ids of the jobs whose images a query resolves among: the job in the query's namespace if it
+//gives one, otherwise nil to consider every image of the job name as long as only one job has it
+func queryJobs(db *sql.DB, query ResolveQuery) (map[int]bool, error) {
+	jobs, err := ReadJobsByName(db, query.Job)
+	if err != nil {
+		return nil, err
+	}
+	if query.Namespace != "" {
+		ids := map[int]bool{}
+		for _, job := range jobs {
+			if job.Namespace == query.Namespace {
+				ids[job.ID] = true
+			}
+		}
+		return ids, nil
+	}
+	if len(jobs) > 1 {
+		namespaces := []string{}
+		for _, job := range jobs {
+			namespaces = append(namespaces, job.Namespace)
+		}
+		return nil, AmbiguousJobError{Job: query.Job, Namespaces: namespaces}
+	}
+	return nil, nil
+}
+
+//RegistryRanks maps registry ids to their position in the preference list. Registries are matched by name, url or
+//host. Registries missing from the list are omitted from the map.
+func RegistryRanks(registries []RegistryInfo, preferred []string) map[int]int {
+	ranks := make(map[int]int)
+	for rank, pref := range preferred {
+		for _, reg := range registries {
+			if _, ok := ranks[reg.ID]; ok {
+				continue
+			}
+			if pref == reg.Name || pref == reg.Url || RegistryHost(pref) == RegistryHost(reg.Url) {
+				ranks[reg.ID] = rank
+			}
+		}
+	}
+	return ranks
+}
+
+//registryRank returns the rank of a registry, ranking registries missing from the preference order after all others
+func registryRank(ranks map[int]int, registryId int) int {
+	if rank, ok := ranks[registryId]; ok {
+		return rank
+	}
+	last := -1
+	for _, rank := range ranks {
+		if rank > last {
+			last = rank
+		}
+	}
+	return last + 1
+}
+
+//RegistryHost strips the scheme and any trailing slash from a registry url
+func RegistryHost(url string) string {
+	host := strings.TrimPrefix(url, "https://")
+	host = strings.TrimPrefix(host, "http://")
+	return strings.TrimSuffix(host, "/")
+}
+
+//SplitImageName splits an image name into its repository and tag, defaulting the tag to latest
+func SplitImageName(fullName string) (string, string) {
+	i := strings.LastIndex(fullName, ":")
+	if i < 0 || strings.Contains(fullName[i:], "/") {
+		return fullName, "latest"
+	}
+	return fullName[:i], fullName[i+1:]
+}
+
+//ImageReference builds a pullable reference (registry/org/name:tag@digest) for an image
+func ImageReference(registry, org, fullName, digest string) string {
+	name := fullName
+	if org != "" && !strings.HasPrefix(name, org+"/") {
+		name = org + "/" + name
+	}
+	ref := name
+	if host := RegistryHost(registry); host != "" {
+		ref = host + "/" + name
+	}
+	if digest != "" {
+		ref += "@" + digest
+	}
+	return ref
+}
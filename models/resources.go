@@ -0,0 +1,143 @@
+package models
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+)
+
+//ScalarResource is a resource requirement declared in the resources.scalar section of a seed manifest
+type ScalarResource struct {
+	Name            string
+	Value           float64
+	InputMultiplier float64 `json:",omitempty"`
+}
+
+//Required returns the amount of the resource needed for the given total input size in MiB
+func (r ScalarResource) Required(inputSize float64) float64 {
+	return r.Value + r.InputMultiplier*inputSize
+}
+
+func CreateResourceTable(db *sql.DB, dbType string) {
+	// create table if it does not exist
+	sql_table := `
+	CREATE TABLE IF NOT EXISTS ImageResource(
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		image_id INTEGER NOT NULL,
+		name TEXT,
+		value REAL,
+		input_multiplier REAL,
+		CONSTRAINT fk_resource_image_id
+		    FOREIGN KEY (image_id)
+		    REFERENCES Image (id)
+		    ON DELETE CASCADE
+	);
+	`
+
+	if dbType == "postgres" {
+		sql_table = strings.Replace(sql_table, "id INTEGER PRIMARY KEY AUTOINCREMENT", "id SERIAL PRIMARY KEY", 1)
+	}
+
+	_, err := db.Exec(sql_table)
+	if err != nil {
+		panic(err)
+	}
+}
+
+//BuildResourceIndex rebuilds the resource table from the seed manifests of all images
+func BuildResourceIndex(db Queryer) {
+	_, err := db.Exec("DELETE FROM ImageResource")
+	if err != nil {
+		panic(err)
+	}
+
+	query := `INSERT INTO ImageResource(image_id, name, value, input_multiplier) VALUES($1, $2, $3, $4)`
+	for _, img := range ReadImages(db) {
+		for _, s := range img.Seed.Job.Resources.Scalar {
+			_, err := db.Exec(query, img.ID, s.Name, s.Value, s.InputMultiplier)
+			if err != nil {
+				log.Printf("Error indexing resources for %s: %s \n", img.FullName, err.Error())
+				break
+			}
+		}
+	}
+}
+
+//jobVersionResourcesQuery selects the resources of the latest packages of job versions with their job version and
+//image ids
+const jobVersionResourcesQuery = `SELECT i.job_version_id, r.image_id, r.name, r.value, r.input_multiplier
+	FROM ImageResource r
+	JOIN Image i ON r.image_id = i.id
+	JOIN JobVersion jv ON i.job_version_id = jv.id
+	WHERE i.package_version = jv.latest_package_version`
+
+//GetJobVersionResources returns the resources declared by the latest package of a job version
+func GetJobVersionResources(db *sql.DB, jobVersionId int, packageVersion string) []ScalarResource {
+	query := `SELECT i.job_version_id, r.image_id, r.name, r.value, r.input_multiplier FROM ImageResource r
+		JOIN Image i ON r.image_id = i.id
+		WHERE i.job_version_id=$1 AND i.package_version=$2
+		ORDER BY r.image_id ASC, r.id ASC`
+
+	return jobVersionResources(readJobVersionResources(db, query, jobVersionId, packageVersion), jobVersionId)
+}
+
+//GetJobResources returns the resources declared by the latest package of every version of a job, by job version id
+func GetJobResources(db *sql.DB, jobId int) map[int][]ScalarResource {
+	query := jobVersionResourcesQuery + " AND jv.job_id=$1 ORDER BY i.job_version_id ASC, r.image_id ASC, r.id ASC"
+	return readJobVersionResources(db, query, jobId)
+}
+
+//ReadJobVersionResources returns the resources declared by the latest package of every job version, by job version
+//id, in a single query
+func ReadJobVersionResources(db *sql.DB) map[int][]ScalarResource {
+	query := jobVersionResourcesQuery + " ORDER BY i.job_version_id ASC, r.image_id ASC, r.id ASC"
+	return readJobVersionResources(db, query)
+}
+
+//readJobVersionResources reads resources selected with their job version and image ids, ordered by both
+func readJobVersionResources(db *sql.DB, query string, args ...interface{}) map[int][]ScalarResource {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+
+	// the same package may be in several registries; they share a manifest so only the first is used
+	result := map[int][]ScalarResource{}
+	firstImage := map[int]int{}
+	for rows.Next() {
+		item := ScalarResource{}
+		var jobVersionId, imageId int
+		err2 := rows.Scan(&jobVersionId, &imageId, &item.Name, &item.Value, &item.InputMultiplier)
+		if err2 != nil {
+			panic(err2)
+		}
+		if first, ok := firstImage[jobVersionId]; !ok {
+			firstImage[jobVersionId] = imageId
+		} else if imageId != first {
+			continue
+		}
+		result[jobVersionId] = append(result[jobVersionId], item)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Fatal(err)
+	}
+	return result
+}
+
+//ResourcesFit reports whether the resources fit on the node profile for the given input size in MiB. Resources
+//the profile does not declare only fit if none of them are required.
+func ResourcesFit(resources []ScalarResource, profile NodeProfile, inputSize float64) bool {
+	for _, r := range resources {
+		required := r.Required(inputSize)
+		if required <= 0 {
+			continue
+		}
+		available, ok := profile.Resources[r.Name]
+		if !ok || required > available {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,169 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+//TagChange records a scan finding a tag pointing to a different manifest than the previous scan, as happens when a
+//moving tag like latest is pushed again
+type TagChange struct {
+	ID         int    `db:"id"`
+	RegistryId int    `db:"registry_id"`
+	Org        string `db:"org"`
+	Repository string `db:"repository"`
+	Tag        string `db:"tag"`
+	FromDigest string `db:"from_digest"`
+	ToDigest   string `db:"to_digest"`
+	Scanned    string `db:"scanned"`
+}
+
+func CreateTagHistoryTable(db *sql.DB, dbType string) {
+	// create table if it does not exist
+	sql_table := `
+	CREATE TABLE IF NOT EXISTS TagHistory(
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		registry_id INTEGER NOT NULL,
+		org TEXT,
+		repository TEXT,
+		tag TEXT,
+		from_digest TEXT,
+		to_digest TEXT,
+		scanned TEXT,
+		CONSTRAINT fk_tag_registry_id
+		    FOREIGN KEY (registry_id)
+		    REFERENCES RegistryInfo (id)
+		    ON DELETE CASCADE
+	);
+	`
+
+	if dbType == "postgres" {
+		sql_table = strings.Replace(sql_table, "id INTEGER PRIMARY KEY AUTOINCREMENT", "id SERIAL PRIMARY KEY", 1)
+	}
+
+	_, err := db.Exec(sql_table)
+	if err != nil {
+		panic(err)
+	}
+}
+
+//tagKey identifies a tag of a repository in a registry
+func tagKey(img Image) string {
+	return fmt.Sprintf("%d %s %s", img.RegistryId, img.Org, img.FullName)
+}
+
+//RecordTagChanges stores a change for every tag whose digest differs between the images from the previous scan and
+//the images just scanned. Tags without a digest on either side are skipped.
+func RecordTagChanges(db *sql.DB, previous, scanned []Image) {
+	digests := map[string]string{}
+	for _, img := range previous {
+		digests[tagKey(img)] = img.Digest
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	query := `INSERT INTO TagHistory(registry_id, org, repository, tag, from_digest, to_digest, scanned)
+		VALUES($1, $2, $3, $4, $5, $6, $7)`
+	for _, img := range scanned {
+		from := digests[tagKey(img)]
+		if from == "" || img.Digest == "" || from == img.Digest {
+			continue
+		}
+		repo, tag := SplitImageName(img.FullName)
+		_, err := db.Exec(query, img.RegistryId, img.Org, repo, tag, from, img.Digest, now)
+		if err != nil {
+			log.Printf("Error recording tag change for %s: %s \n", img.FullName, err.Error())
+		}
+	}
+}
+
+//GetTagHistory returns the recorded tag changes of a repository, oldest first
+func GetTagHistory(db *sql.DB, registryId int, org, repository string) []TagChange {
+	query := `SELECT * FROM TagHistory WHERE registry_id=$1 AND org=$2 AND repository=$3 ORDER BY id ASC`
+	rows, err := db.Query(query, registryId, org, repository)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+
+	result := []TagChange{}
+	for rows.Next() {
+		item := TagChange{}
+		err2 := rows.Scan(&item.ID, &item.RegistryId, &item.Org, &item.Repository, &item.Tag, &item.FromDigest,
+			&item.ToDigest, &item.Scanned)
+		if err2 != nil {
+			panic(err2)
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+//aliasKey groups the tags of a repository that point to the same manifest. Images without a digest are not grouped.
+func aliasKey(img SimpleImage) string {
+	if img.Digest == "" {
+		return fmt.Sprintf("image %d", img.ID)
+	}
+	repo, _ := SplitImageName(img.Name)
+	return fmt.Sprintf("%d %s %s %s", img.RegistryId, img.Org, repo, img.Digest)
+}
+
+//preferCanonical returns true if image a should represent its tags rather than image b. Version tags are preferred
+//over moving tags like latest, then the image found first.
+func preferCanonical(a, b SimpleImage) bool {
+	_, aTag := SplitImageName(a.Name)
+	_, bTag := SplitImageName(b.Name)
+	_, aErr := ParseVersion(aTag)
+	_, bErr := ParseVersion(bTag)
+	if (aErr == nil) != (bErr == nil) {
+		return aErr == nil
+	}
+	return a.ID < b.ID
+}
+
+//CanonicalImages returns one image for each group of tags pointing to the same manifest
+func CanonicalImages(images []SimpleImage) []SimpleImage {
+	canonical := map[string]SimpleImage{}
+	for _, img := range images {
+		key := aliasKey(img)
+		if current, ok := canonical[key]; !ok || preferCanonical(img, current) {
+			canonical[key] = img
+		}
+	}
+
+	result := []SimpleImage{}
+	for _, img := range images {
+		if canonical[aliasKey(img)].ID == img.ID {
+			result = append(result, img)
+		}
+	}
+	return result
+}
+
+//ImageAliases returns the canonical image for an image's manifest and every tag of its repository pointing to it
+func ImageAliases(db *sql.DB, img Image) (int, []string) {
+	simple := SimplifyImage(img)
+	key := aliasKey(simple)
+	canonical := simple
+	tags := []string{}
+	for _, other := range ReadSimpleImages(db) {
+		if aliasKey(other) != key {
+			continue
+		}
+		if preferCanonical(other, canonical) {
+			canonical = other
+		}
+		_, tag := SplitImageName(other.Name)
+		tags = append(tags, tag)
+	}
+	if len(tags) == 0 {
+		_, tag := SplitImageName(img.FullName)
+		tags = append(tags, tag)
+	}
+
+	sort.Strings(tags)
+	return canonical.ID, tags
+}
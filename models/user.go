@@ -66,7 +66,7 @@ func CreateUser(db *sql.DB, dbType, admin, password string) {
 	}
 }
 
-func AddUserLite(db *sql.DB, r SiloUser) (int, error) {
+func AddUserLite(db Queryer, r SiloUser) (int, error) {
 	sql_addreg := `
 	INSERT INTO SiloUser(
 		username,
@@ -95,7 +95,7 @@ func AddUserLite(db *sql.DB, r SiloUser) (int, error) {
 	return id, err
 }
 
-func AddUserPg(db *sql.DB, r SiloUser) (int, error) {
+func AddUserPg(db Queryer, r SiloUser) (int, error) {
 	hash, err := HashPassword(r.Password)
 	query := `INSERT INTO SiloUser(username, password, role) 
 			VALUES($1, $2, $3) RETURNING id;`
@@ -151,7 +151,7 @@ func GetUserById(db *sql.DB, id int) (DisplayUser, error) {
 	return item, err
 }
 
-func GetUserByName(db *sql.DB, username string) (DisplayUser, error) {
+func GetUserByName(db Queryer, username string) (DisplayUser, error) {
 	row := db.QueryRow("SELECT id, username, role FROM SiloUser WHERE username=$1", username)
 
 	var item DisplayUser
@@ -0,0 +1,366 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed semantic version (https://semver.org) as used by the Seed jobVersion and packageVersion fields
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// ParseVersion parses a semantic version string. A leading 'v' and build metadata are ignored.
+func ParseVersion(str string) (Version, error) {
+	v := Version{}
+	s := strings.TrimPrefix(strings.TrimSpace(str), "v")
+	if i := strings.Index(s, "+"); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.Index(s, "-"); i >= 0 {
+		v.Prerelease = s[i+1:]
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("Invalid semantic version: %s", str)
+	}
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("Invalid semantic version: %s", str)
+		}
+		*nums[i] = n
+	}
+
+	return v, nil
+}
+
+// Compare returns -1, 0 or 1 if v is less than, equal to or greater than o
+func (v Version) Compare(o Version) int {
+	if c := compareInts(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := compareInts(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := compareInts(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+	return comparePrerelease(v.Prerelease, o.Prerelease)
+}
+
+func compareInts(a, b int) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+// comparePrerelease compares dot separated prerelease identifiers; a version without a prerelease has higher precedence
+func comparePrerelease(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return 1
+	}
+	if b == "" {
+		return -1
+	}
+	ap := strings.Split(a, ".")
+	bp := strings.Split(b, ".")
+	for i := 0; i < len(ap) && i < len(bp); i++ {
+		an, aErr := strconv.Atoi(ap[i])
+		bn, bErr := strconv.Atoi(bp[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if c := compareInts(an, bn); c != 0 {
+				return c
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(ap[i], bp[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInts(len(ap), len(bp))
+}
+
+// CompareVersions compares two version strings semantically, falling back to a plain string comparison when either
+// is not a valid semantic version
+func CompareVersions(a, b string) int {
+	av, aErr := ParseVersion(a)
+	bv, bErr := ParseVersion(b)
+	if aErr != nil || bErr != nil {
+		return strings.Compare(a, b)
+	}
+	return av.Compare(bv)
+}
+
+type versionComparator struct {
+	op      string
+	version Version
+}
+
+func (c versionComparator) check(v Version) bool {
+	cmp := v.Compare(c.version)
+	switch c.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case "!=":
+		return cmp != 0
+	}
+	return cmp == 0
+}
+
+// VersionConstraint is a set of semver ranges such as "^1.2", "~1.2.3", ">=1.0.0 <2.0.0", "1.x" or "1.2 || 2.x".
+// A version satisfies the constraint when it satisfies all comparators of at least one '||' separated range.
+type VersionConstraint struct {
+	raw    string
+	exact  string //set for "=" constraints on versions that are not semantic versions
+	ranges [][]versionComparator
+}
+
+func (c *VersionConstraint) String() string {
+	return c.raw
+}
+
+// ParseConstraint parses a version constraint. An empty constraint, "*" or "latest" matches every version. An "="
+// constraint on a version that is not a semantic version only matches that exact string.
+func ParseConstraint(str string) (*VersionConstraint, error) {
+	c := &VersionConstraint{raw: str}
+	s := strings.TrimSpace(str)
+	if s == "" || s == "*" || strings.EqualFold(s, "latest") {
+		return c, nil
+	}
+	if strings.HasPrefix(s, "=") {
+		if _, _, err := parsePartialVersion(strings.TrimPrefix(s[1:], "v")); err != nil {
+			c.exact = strings.TrimSpace(s[1:])
+			return c, nil
+		}
+	}
+
+	for _, r := range strings.Split(s, "||") {
+		comparators := []versionComparator{}
+		fields := strings.Fields(strings.Replace(r, ",", " ", -1))
+		for i := 0; i < len(fields); i++ {
+			f := fields[i]
+			// allow a space between the operator and the version, e.g. ">= 1.2.0"
+			if strings.Trim(f, "<>=!~^") == "" && i+1 < len(fields) {
+				f += fields[i+1]
+				i++
+			}
+			// hyphen ranges, e.g. "1.2.0 - 1.4.0"
+			if i+2 < len(fields) && fields[i+1] == "-" {
+				lower, err := expandComparator(">=" + f)
+				if err != nil {
+					return nil, err
+				}
+				upper, err := expandComparator("<=" + fields[i+2])
+				if err != nil {
+					return nil, err
+				}
+				comparators = append(comparators, lower...)
+				comparators = append(comparators, upper...)
+				i += 2
+				continue
+			}
+			expanded, err := expandComparator(f)
+			if err != nil {
+				return nil, err
+			}
+			comparators = append(comparators, expanded...)
+		}
+		c.ranges = append(c.ranges, comparators)
+	}
+
+	return c, nil
+}
+
+// Check reports whether the given version string satisfies the constraint. Versions that are not valid semantic
+// versions only satisfy the empty constraint or an exact string match.
+func (c *VersionConstraint) Check(version string) bool {
+	if c.exact != "" {
+		return version == c.exact
+	}
+	if len(c.ranges) == 0 {
+		return true
+	}
+	v, err := ParseVersion(version)
+	if err != nil {
+		return strings.TrimPrefix(strings.TrimSpace(c.raw), "=") == version
+	}
+	for _, r := range c.ranges {
+		ok := true
+		for _, comp := range r {
+			if !comp.check(v) {
+				ok = false
+				break
+			}
+		}
+		// prereleases only match ranges that explicitly mention a prerelease of the same version
+		if ok && v.Prerelease != "" && !allowsPrerelease(r, v) {
+			ok = false
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func allowsPrerelease(comparators []versionComparator, v Version) bool {
+	for _, comp := range comparators {
+		cv := comp.version
+		if cv.Prerelease != "" && cv.Major == v.Major && cv.Minor == v.Minor && cv.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+// expandComparator converts a single range term into one or more primitive comparators
+func expandComparator(term string) ([]versionComparator, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", "!=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(term, prefix) {
+			op = prefix
+			break
+		}
+	}
+	partial := strings.TrimPrefix(strings.TrimSpace(strings.TrimPrefix(term, op)), "v")
+
+	base, specified, err := parsePartialVersion(partial)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid version constraint %s: %s", term, err.Error())
+	}
+
+	lower := versionComparator{op: ">=", version: base}
+	switch op {
+	case "^":
+		upper := Version{}
+		switch {
+		case base.Major > 0 || specified < 2:
+			upper.Major = base.Major + 1
+		case base.Minor > 0 || specified < 3:
+			upper.Minor = base.Minor + 1
+		default:
+			upper.Patch = base.Patch + 1
+		}
+		if specified == 0 {
+			return []versionComparator{}, nil
+		}
+		return []versionComparator{lower, {op: "<", version: upper}}, nil
+	case "~":
+		if specified == 0 {
+			return []versionComparator{}, nil
+		}
+		upper := Version{Major: base.Major + 1}
+		if specified > 1 {
+			upper = Version{Major: base.Major, Minor: base.Minor + 1}
+		}
+		return []versionComparator{lower, {op: "<", version: upper}}, nil
+	case "", "=":
+		if specified == 3 {
+			return []versionComparator{{op: "=", version: base}}, nil
+		}
+		return wildcardRange(base, specified), nil
+	case ">", "<=":
+		// a partial version in an exclusive lower or inclusive upper bound covers the whole wildcard range
+		if specified < 3 {
+			r := wildcardRange(base, specified)
+			if len(r) < 2 {
+				if op == ">" {
+					return []versionComparator{{op: "<", version: Version{}}}, nil
+				}
+				return []versionComparator{}, nil
+			}
+			if op == ">" {
+				return []versionComparator{{op: ">=", version: r[1].version}}, nil
+			}
+			return []versionComparator{r[1]}, nil
+		}
+	}
+
+	return []versionComparator{{op: op, version: base}}, nil
+}
+
+// wildcardRange returns the comparators matching every version that starts with the specified components
+func wildcardRange(base Version, specified int) []versionComparator {
+	switch specified {
+	case 0:
+		return []versionComparator{}
+	case 1:
+		return []versionComparator{{op: ">=", version: base}, {op: "<", version: Version{Major: base.Major + 1}}}
+	default:
+		return []versionComparator{{op: ">=", version: base},
+			{op: "<", version: Version{Major: base.Major, Minor: base.Minor + 1}}}
+	}
+}
+
+// parsePartialVersion parses versions such as "1", "1.2", "1.2.x" or "1.2.3-rc.1" and returns the number of
+// numeric components that were specified
+func parsePartialVersion(s string) (Version, int, error) {
+	v := Version{}
+	if s == "" || s == "*" || s == "x" || s == "X" {
+		return v, 0, nil
+	}
+	if i := strings.Index(s, "+"); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.Index(s, "-"); i >= 0 {
+		v.Prerelease = s[i+1:]
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return v, 0, fmt.Errorf("too many version components")
+	}
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	specified := 0
+	for i, p := range parts {
+		if p == "x" || p == "X" || p == "*" {
+			break
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, 0, fmt.Errorf("invalid version component %s", p)
+		}
+		*nums[i] = n
+		specified++
+	}
+	if specified < 3 {
+		v.Prerelease = ""
+	}
+
+	return v, specified, nil
+}
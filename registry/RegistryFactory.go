@@ -7,7 +7,9 @@ import (
 	"github.com/ngageoint/seed-common/objects"
 	"github.com/ngageoint/seed-silo/registry/containeryard"
 	"github.com/ngageoint/seed-silo/registry/dockerhub"
+	"github.com/ngageoint/seed-silo/registry/filesystem"
 	gitlab "github.com/ngageoint/seed-silo/registry/gitlab"
+	"github.com/ngageoint/seed-silo/registry/silo"
 	v2 "github.com/ngageoint/seed-silo/registry/v2"
 )
 
@@ -21,8 +23,76 @@ type RepositoryRegistry interface {
 	GetImageManifest(repoName, tag string) (string, error)
 }
 
+//V2Backed is implemented by registries whose images can be accessed through the docker v2 API
+type V2Backed interface {
+	V2Base() *v2.V2registry
+	RepositoryPath(org, repoName string) string
+}
+
+//ErrManifestUnknown is returned when a registry no longer has a manifest for an image
+var ErrManifestUnknown = v2.ErrManifestUnknown
+
 type RepoRegistryFactory func(url, org, username, password string) (RepositoryRegistry, error)
 
+//ImageDigest returns the manifest digest of the given image from the registry
+func ImageDigest(reg RepositoryRegistry, org, repoName, tag string) (string, error) {
+	backed, ok := reg.(V2Backed)
+	if !ok {
+		return "", fmt.Errorf("ERROR: Registry %s does not support manifest digests", reg.Name())
+	}
+
+	d, err := backed.V2Base().ManifestDigest(backed.RepositoryPath(org, repoName), tag)
+	return d.String(), err
+}
+
+//SetRepositoryList makes a registry scan the given repositories, or those matching the given glob patterns, instead of
+//its whole catalog. Only registries accessed through the docker v2 API support repository lists.
+func SetRepositoryList(reg RepositoryRegistry, repositories []string) error {
+	if len(repositories) == 0 {
+		return nil
+	}
+	list := v2.RepositoryList(repositories)
+	if err := list.Validate(); err != nil {
+		return err
+	}
+	if orgs, ok := reg.(*orgsRegistry); ok {
+		for _, member := range orgs.regs {
+			backed, ok := member.(V2Backed)
+			if !ok {
+				return fmt.Errorf("ERROR: Registry %s does not support repository lists", member.Name())
+			}
+			backed.V2Base().RepositoryList = list
+		}
+		return nil
+	}
+	backed, ok := reg.(V2Backed)
+	if !ok {
+		return fmt.Errorf("ERROR: Registry %s does not support repository lists", reg.Name())
+	}
+
+	backed.V2Base().RepositoryList = list
+	return nil
+}
+
+//ImageMetadata describes an image manifest and its config blob
+type ImageMetadata = v2.ImageMetadata
+
+//MetadataSource is implemented by registries that report the metadata of their images recorded elsewhere instead of
+//through the docker v2 API. Images are identified by the registry and org they were scanned from and their name.
+type MetadataSource interface {
+	ImageMetadata(registry, org, name string) (ImageMetadata, bool)
+}
+
+//GetImageMetadata returns the digests, size, created time, platform and labels of the given image from the registry
+func GetImageMetadata(reg RepositoryRegistry, org, repoName, tag string) (ImageMetadata, error) {
+	backed, ok := reg.(V2Backed)
+	if !ok {
+		return ImageMetadata{}, fmt.Errorf("ERROR: Registry %s does not support image metadata", reg.Name())
+	}
+
+	return backed.V2Base().ImageMetadata(backed.RepositoryPath(org, repoName), tag)
+}
+
 func NewV2Registry(url, org, username, password string) (RepositoryRegistry, error) {
 	v2registry, err := v2.New(url, org, username, password)
 	if err != nil {
@@ -74,7 +144,39 @@ func NewGitLabRegistry(url, org, username, password string) (RepositoryRegistry,
 	return git, err
 }
 
+//NewSiloRegistry Creates a read-only registry over the images of another silo
+func NewSiloRegistry(url, org, username, password string) (RepositoryRegistry, error) {
+	return silo.New(url, org, username, password)
+}
+
+//NewFilesystemRegistry Creates a registry over the OCI image layouts and docker save tarballs in a directory
+func NewFilesystemRegistry(url, org, username, password string) (RepositoryRegistry, error) {
+	return filesystem.New(url, org)
+}
+
 func CreateRegistry(url, org, username, password string) (RepositoryRegistry, error) {
+	if checkRegistryType(url) == "filesystem" {
+		fs, err := NewFilesystemRegistry(url, org, username, password)
+		if err == nil {
+			err = fs.Ping()
+			if err == nil {
+				return fs, nil
+			}
+		}
+		return nil, fmt.Errorf("ERROR: Could not create registry filesystem: %s", err.Error())
+	}
+
+	if checkRegistryType(url) == "silo" {
+		upstream, err := NewSiloRegistry(url, org, username, password)
+		if err == nil {
+			err = upstream.Ping()
+			if err == nil {
+				return upstream, nil
+			}
+		}
+		return nil, fmt.Errorf("ERROR: Could not create registry silo: %s", err.Error())
+	}
+
 	if !strings.HasPrefix(url, "http") {
 		url = "https://" + url
 	}
@@ -149,6 +251,12 @@ func CreateRegistry(url, org, username, password string) (RepositoryRegistry, er
 }
 
 func checkRegistryType(url string) string {
+	if strings.HasPrefix(url, silo.Scheme) {
+		return "silo"
+	}
+	if strings.HasPrefix(url, filesystem.Scheme) {
+		return "filesystem"
+	}
 	if strings.Contains(url, "hub.docker.com") {
 		return "dockerhub"
 	}
@@ -30,6 +30,19 @@ func (r *ContainerYardRegistry) Name() string {
 	return "ContainerYardRegistry"
 }
 
+//V2Base returns the registry used to access images through the docker v2 API
+func (r *ContainerYardRegistry) V2Base() *v2.V2registry {
+	return r.v2Base
+}
+
+//RepositoryPath returns the v2 repository path for an image. Scanned image names have their org split off.
+func (r *ContainerYardRegistry) RepositoryPath(org, repoName string) string {
+	if org == "" || strings.HasPrefix(repoName, org+"/") {
+		return repoName
+	}
+	return org + "/" + repoName
+}
+
 //New creates a new docker hub registry from the given URL
 func New(registryUrl, org, username, password string) (*ContainerYardRegistry, error) {
 	if util.PrintUtil == nil {
@@ -1,7 +1,6 @@
 package containeryard
 
 import (
-	"errors"
 	"strings"
 
 	"github.com/ngageoint/seed-common/objects"
@@ -107,6 +106,9 @@ func (registry *ContainerYardRegistry) ImagesWithManifests() ([]objects.Image, e
 				registry.Print("Skipping image %s because it does not belong to org %s", repoName, registry.Org)
 				continue
 			}
+			if len(registry.v2Base.RepositoryList) > 0 && !registry.v2Base.RepositoryList.Selects(repoName) {
+				continue
+			}
 			manifestLabel := ""
 			for name, value := range image.Labels {
 				if name == "com.ngageoint.seed.manifest" {
@@ -142,6 +144,9 @@ func (registry *ContainerYardRegistry) ImagesWithManifests() ([]objects.Image, e
 				registry.Print("Skipping image %s because it does not belong to org %s", repoName, registry.Org)
 				continue
 			}
+			if len(registry.v2Base.RepositoryList) > 0 && !registry.v2Base.RepositoryList.Selects(repoName) {
+				continue
+			}
 			manifestLabel := ""
 			for name, value := range image.Labels {
 				if name == "com.ngageoint.seed.manifest" {
@@ -177,18 +182,5 @@ func (registry *ContainerYardRegistry) ImagesWithManifests() ([]objects.Image, e
 }
 
 func (registry *ContainerYardRegistry) GetImageManifest(repoName, tag string) (string, error) {
-	manifest := ""
-	mv2, err := registry.v2Base.ManifestV2(repoName, tag)
-	if err == nil {
-		resp, err := registry.v2Base.DownloadLayer(repoName, mv2.Config.Digest)
-		if err == nil {
-			manifest, err = objects.GetSeedManifestFromBlob(resp)
-		}
-	}
-
-	if err == nil && manifest == "" {
-		err = errors.New("Empty seed manifest!")
-	}
-
-	return manifest, err
+	return registry.v2Base.SeedManifest(repoName, tag)
 }
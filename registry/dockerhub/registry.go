@@ -57,6 +57,19 @@ func (r *DockerHubRegistry) Name() string {
 	return "DockerHubRegistry"
 }
 
+//V2Base returns the registry used to access images through the docker v2 API
+func (r *DockerHubRegistry) V2Base() *v2.V2registry {
+	return r.v2Base
+}
+
+//RepositoryPath returns the v2 repository path for an image in the given org
+func (r *DockerHubRegistry) RepositoryPath(org, repoName string) string {
+	if org == "" {
+		org = r.Org
+	}
+	return org + "/" + repoName
+}
+
 func (r *DockerHubRegistry) Ping() error {
 	url := r.url("/v2/repositories/%s/", constants.DefaultOrg)
 	resp, err := r.Client.Get(url)
@@ -1,7 +1,6 @@
 package dockerhub
 
 import (
-	"errors"
 	"strings"
 
 	"github.com/ngageoint/seed-common/objects"
@@ -30,7 +29,7 @@ func (registry *DockerHubRegistry) Repositories() ([]string, error) {
 		response.Next = ""
 		url, err = registry.getDockerHubPaginatedJson(url, &response)
 		for _, r := range response.Results {
-			if !strings.HasSuffix(r.Name, "-seed") {
+			if !registry.v2Base.RepositoryList.Selects(r.Name) {
 				continue
 			}
 			repos = append(repos, r.Name)
@@ -74,7 +73,7 @@ func (registry *DockerHubRegistry) Images() ([]string, error) {
 		response.Next = ""
 		url, err = registry.getDockerHubPaginatedJson(url, &response)
 		for _, r := range response.Results {
-			if !strings.HasSuffix(r.Name, "-seed") {
+			if !registry.v2Base.RepositoryList.Selects(r.Name) {
 				continue
 			}
 			// Add all tags if found
@@ -123,19 +122,5 @@ func (registry *DockerHubRegistry) ImagesWithManifests() ([]objects.Image, error
 }
 
 func (registry *DockerHubRegistry) GetImageManifest(repoName, tag string) (string, error) {
-	manifest := ""
-	orgRepoName := registry.Org + "/" + repoName
-	mv2, err := registry.v2Base.ManifestV2(orgRepoName, tag)
-	if err == nil {
-		resp, err := registry.v2Base.DownloadLayer(orgRepoName, mv2.Config.Digest)
-		if err == nil {
-			manifest, err = objects.GetSeedManifestFromBlob(resp)
-		}
-	}
-
-	if err == nil && manifest == "" {
-		err = errors.New("Empty seed manifest!")
-	}
-
-	return manifest, err
+	return registry.v2Base.SeedManifest(registry.RepositoryPath("", repoName), tag)
 }
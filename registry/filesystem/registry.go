@@ -0,0 +1,82 @@
+package filesystem
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ngageoint/seed-common/util"
+	v2 "github.com/ngageoint/seed-silo/registry/v2"
+)
+
+//Scheme prefixes the url of a filesystem registry, e.g. file:///media/algorithms
+const Scheme = "file://"
+
+//FilesystemRegistry type representing a directory of OCI image layouts and docker save tarballs. The directory is
+//read again whenever repositories or images are listed so added, changed and removed files are picked up; tags and
+//manifests come from the last listing.
+type FilesystemRegistry struct {
+	URL    string
+	Path   string
+	Org    string           //only images under this namespace are listed, if set
+	Orgs   v2.OrgList       //only images under these namespaces are listed instead, if set
+	listed map[string]image //images of the last scan by name
+	Print  util.PrintCallback
+}
+
+func (r *FilesystemRegistry) Name() string {
+	return "FilesystemRegistry"
+}
+
+//New creates a new filesystem registry from the given file:// URL
+func New(registryUrl, org string) (*FilesystemRegistry, error) {
+	if util.PrintUtil == nil {
+		util.InitPrinter(util.PrintErr, os.Stderr, os.Stdout)
+	}
+	if !strings.HasPrefix(registryUrl, Scheme) {
+		return nil, fmt.Errorf("ERROR: Filesystem registry url %s does not start with %s", registryUrl, Scheme)
+	}
+
+	registry := &FilesystemRegistry{
+		URL:   registryUrl,
+		Path:  strings.TrimPrefix(registryUrl, Scheme),
+		Org:   org,
+		Print: util.PrintUtil,
+	}
+
+	return registry, nil
+}
+
+//SetOrgs makes the registry list the images under several namespaces
+func (r *FilesystemRegistry) SetOrgs(orgs []string) {
+	r.Orgs = orgs
+}
+
+//orgs returns the namespaces whose images are listed: the list of orgs if set, otherwise the org
+func (r *FilesystemRegistry) orgs() v2.OrgList {
+	if len(r.Orgs) > 0 {
+		return r.Orgs
+	}
+	if r.Org != "" {
+		return v2.OrgList{r.Org}
+	}
+	return nil
+}
+
+func (r *FilesystemRegistry) Ping() error {
+	info, err := os.Stat(r.Path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("ERROR: %s is not a directory", r.Path)
+	}
+	return nil
+}
+
+//ImageMetadata returns the digests, size, created time, platform and labels read from disk for an image of the last
+//scan. Image names are unique within the registry, so the registry and org are not needed to find an image.
+func (r *FilesystemRegistry) ImageMetadata(registry, org, name string) (v2.ImageMetadata, bool) {
+	img, ok := r.listed[name]
+	return img.Metadata, ok
+}
@@ -0,0 +1,403 @@
+package filesystem
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker/distribution/digest"
+	"github.com/ngageoint/seed-common/objects"
+	v2 "github.com/ngageoint/seed-silo/registry/v2"
+)
+
+//seedManifestLabel holds the seed manifest, which silo already stores on its own
+const seedManifestLabel = "com.ngageoint.seed.manifest"
+
+//Annotations naming an image in the index of an OCI image layout
+const (
+	refNameAnnotation        = "org.opencontainers.image.ref.name"
+	containerdNameAnnotation = "io.containerd.image.name"
+)
+
+//Media types of image indexes, which list a manifest per platform
+const (
+	ociIndexMediaType     = "application/vnd.oci.image.index.v1+json"
+	manifestListMediaType = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+//errNoSeedManifest is returned for images on disk that are not seed images
+var errNoSeedManifest = errors.New("Empty seed manifest!")
+
+//image is an image found on disk with its seed manifest
+type image struct {
+	Name     string
+	Manifest string
+	Metadata v2.ImageMetadata
+}
+
+type descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations"`
+	Platform    struct {
+		Architecture string `json:"architecture"`
+		OS           string `json:"os"`
+	} `json:"platform"`
+}
+
+//index is the index.json of an OCI image layout or an image index blob
+type index struct {
+	Manifests []descriptor `json:"manifests"`
+}
+
+//manifest is an OCI or docker v2 image manifest blob
+type manifest struct {
+	Config descriptor   `json:"config"`
+	Layers []descriptor `json:"layers"`
+}
+
+//saveManifest is an entry of the manifest.json of a docker save tarball
+type saveManifest struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+//imageConfig is the part of an image config blob silo records
+type imageConfig struct {
+	Created      string `json:"created"`
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant"`
+	Config       struct {
+		Labels map[string]string `json:"Labels"`
+	} `json:"config"`
+}
+
+//scan reads every image layout directory and tarball under the registry path. Images without a seed manifest and
+//outside the registry's orgs are skipped.
+func (r *FilesystemRegistry) scan() ([]image, error) {
+	found := []image{}
+	err := filepath.Walk(r.Path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			r.Print("ERROR: Error reading %s: %s\n Skipping.\n", p, err.Error())
+			return nil
+		}
+
+		var images []image
+		switch {
+		case info.IsDir():
+			if _, err := os.Stat(filepath.Join(p, "oci-layout")); err != nil {
+				return nil
+			}
+			images, err = readLayout(dirSource(p), r.repoName(p))
+		case isTarball(info.Name()):
+			var src *tarSource
+			if src, err = readTarball(p); err == nil {
+				images, err = readArchive(src, r.repoName(p))
+			}
+		default:
+			return nil
+		}
+		if err != nil {
+			r.Print("ERROR: Error reading images from %s: %s\n Skipping.\n", p, err.Error())
+		}
+		found = append(found, images...)
+
+		if info.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+
+	images := []image{}
+	r.listed = map[string]image{}
+	for _, img := range found {
+		if !r.orgs().Selects(namespace(img.Name)) {
+			continue
+		}
+		if _, ok := r.listed[img.Name]; ok {
+			r.Print("ERROR: Image %s found more than once.\n Skipping.\n", img.Name)
+			continue
+		}
+		images = append(images, img)
+		r.listed[img.Name] = img
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].Name < images[j].Name })
+
+	return images, err
+}
+
+//repoName returns the repository name given to images with a tag but no name, from their file path under the
+//registry path
+func (r *FilesystemRegistry) repoName(p string) string {
+	rel, err := filepath.Rel(r.Path, p)
+	if err != nil || rel == "." {
+		rel = filepath.Base(p)
+	}
+	rel = filepath.ToSlash(rel)
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar"} {
+		rel = strings.TrimSuffix(rel, ext)
+	}
+	return strings.ToLower(rel)
+}
+
+//namespace returns the namespace of an image, leaving out any registry host it is named with
+func namespace(name string) string {
+	repo, _ := splitName(name)
+	parts := strings.SplitN(repo, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		repo = parts[1]
+	}
+	return v2.Namespace(repo)
+}
+
+func splitName(name string) (string, string) {
+	i := strings.LastIndex(name, ":")
+	if i < 0 || strings.Contains(name[i:], "/") {
+		return name, "latest"
+	}
+	return name[:i], name[i+1:]
+}
+
+//readArchive reads the images of a tarball, which is either an OCI image layout or a docker save tarball
+func readArchive(src source, repo string) ([]image, error) {
+	if _, err := src.read("oci-layout"); err == nil {
+		return readLayout(src, repo)
+	}
+
+	data, err := src.read("manifest.json")
+	if err != nil {
+		return nil, errors.New("ERROR: Tarball is neither an image layout nor a docker save tarball")
+	}
+	var entries []saveManifest
+	if err = json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	images := []image{}
+	for _, entry := range entries {
+		config, err := src.read(entry.Config)
+		if err != nil {
+			return images, err
+		}
+		metadata := v2.ImageMetadata{ConfigDigest: digest.FromBytes(config).String()}
+		for _, layer := range entry.Layers {
+			metadata.Size += src.size(layer)
+		}
+		for _, name := range entry.RepoTags {
+			if img, err := newImage(name, config, metadata); err == nil {
+				images = append(images, img)
+			}
+		}
+	}
+	return images, nil
+}
+
+//readLayout reads the images named in the index of an OCI image layout. Images named only by a tag are given the
+//repository name of the layout.
+func readLayout(src source, repo string) ([]image, error) {
+	data, err := src.read("index.json")
+	if err != nil {
+		return nil, err
+	}
+	idx := index{}
+	if err = json.Unmarshal(data, &idx); err != nil {
+		return nil, err
+	}
+
+	images := []image{}
+	for _, desc := range idx.Manifests {
+		name := desc.Annotations[containerdNameAnnotation]
+		if name == "" {
+			name = desc.Annotations[refNameAnnotation]
+			if name == "" {
+				continue
+			}
+			if !strings.Contains(name, ":") {
+				name = repo + ":" + name
+			}
+		}
+
+		img, err := readLayoutImage(src, name, desc)
+		if err == errNoSeedManifest {
+			continue
+		}
+		if err != nil {
+			return images, fmt.Errorf("%s: %s", name, err.Error())
+		}
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+//readLayoutImage reads an image of a layout from its manifest, choosing the linux/amd64 manifest of an image index
+func readLayoutImage(src source, name string, desc descriptor) (image, error) {
+	if desc.MediaType == ociIndexMediaType || desc.MediaType == manifestListMediaType {
+		data, err := src.read(blobPath(desc.Digest))
+		if err != nil {
+			return image{}, err
+		}
+		idx := index{}
+		if err = json.Unmarshal(data, &idx); err != nil {
+			return image{}, err
+		}
+		if len(idx.Manifests) == 0 {
+			return image{}, errors.New("Empty image index")
+		}
+		chosen := idx.Manifests[0]
+		for _, m := range idx.Manifests {
+			if m.Platform.OS == "linux" && m.Platform.Architecture == "amd64" {
+				chosen = m
+				break
+			}
+		}
+		desc = chosen
+	}
+
+	data, err := src.read(blobPath(desc.Digest))
+	if err != nil {
+		return image{}, err
+	}
+	m := manifest{}
+	if err = json.Unmarshal(data, &m); err != nil {
+		return image{}, err
+	}
+	config, err := src.read(blobPath(m.Config.Digest))
+	if err != nil {
+		return image{}, err
+	}
+
+	metadata := v2.ImageMetadata{Digest: desc.Digest, ConfigDigest: m.Config.Digest}
+	for _, layer := range m.Layers {
+		metadata.Size += layer.Size
+	}
+	return newImage(name, config, metadata)
+}
+
+//blobPath returns the path of a blob in an image layout
+func blobPath(d string) string {
+	parts := strings.SplitN(d, ":", 2)
+	if len(parts) != 2 {
+		return d
+	}
+	return path.Join("blobs", parts[0], parts[1])
+}
+
+//newImage reads the seed manifest, created time, platform and labels from the config blob of an image
+func newImage(name string, config []byte, metadata v2.ImageMetadata) (image, error) {
+	seedManifest, err := objects.GetSeedManifestFromBlob(ioutil.NopCloser(bytes.NewReader(config)))
+	if err == nil && seedManifest == "" {
+		err = errNoSeedManifest
+	}
+	if err != nil {
+		return image{}, err
+	}
+
+	cfg := imageConfig{}
+	if err = json.Unmarshal(config, &cfg); err != nil {
+		return image{}, err
+	}
+	metadata.Created = cfg.Created
+	if cfg.OS != "" {
+		metadata.Platform = cfg.OS + "/" + cfg.Architecture
+		if cfg.Variant != "" {
+			metadata.Platform += "/" + cfg.Variant
+		}
+	}
+	metadata.Labels = map[string]string{}
+	for key, value := range cfg.Config.Labels {
+		if key == seedManifestLabel {
+			continue
+		}
+		metadata.Labels[key] = value
+	}
+
+	return image{Name: name, Manifest: seedManifest, Metadata: metadata}, nil
+}
+
+func (r *FilesystemRegistry) Repositories() ([]string, error) {
+	images, err := r.scan()
+
+	seen := map[string]bool{}
+	repositories := []string{}
+	for _, img := range images {
+		repo, _ := splitName(img.Name)
+		if !seen[repo] {
+			seen[repo] = true
+			repositories = append(repositories, repo)
+		}
+	}
+	return repositories, err
+}
+
+//lastScan returns the images of the last scan, scanning only if there has been none
+func (r *FilesystemRegistry) lastScan() ([]image, error) {
+	if r.listed == nil {
+		return r.scan()
+	}
+	images := []image{}
+	for _, img := range r.listed {
+		images = append(images, img)
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].Name < images[j].Name })
+	return images, nil
+}
+
+//Tags returns the tags of a repository from the last scan so listing the tags of every repository reads the
+//directory once
+func (r *FilesystemRegistry) Tags(repository string) ([]string, error) {
+	images, err := r.lastScan()
+
+	tags := []string{}
+	for _, img := range images {
+		if repo, tag := splitName(img.Name); repo == repository {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, err
+}
+
+func (r *FilesystemRegistry) Images() ([]string, error) {
+	images, err := r.scan()
+
+	names := []string{}
+	for _, img := range images {
+		names = append(names, img.Name)
+	}
+	return names, err
+}
+
+//ImagesWithManifests returns the seed images found on disk. The seed manifests come from the image configs so no
+//further reads are needed.
+func (r *FilesystemRegistry) ImagesWithManifests() ([]objects.Image, error) {
+	images, err := r.scan()
+	r.Print("Found %d Seed images in %s", len(images), r.Path)
+
+	result := []objects.Image{}
+	for _, img := range images {
+		result = append(result, objects.Image{Name: img.Name, Registry: r.URL, Org: namespace(img.Name),
+			Manifest: img.Manifest})
+	}
+	return result, err
+}
+
+//GetImageManifest returns the seed manifest of an image of the last scan
+func (r *FilesystemRegistry) GetImageManifest(repoName, tag string) (string, error) {
+	if _, err := r.lastScan(); err != nil {
+		return "", err
+	}
+	img, ok := r.listed[repoName+":"+tag]
+	if !ok {
+		return "", fmt.Errorf("ERROR: No image %s:%s in %s", repoName, tag, r.Path)
+	}
+	return img.Manifest, nil
+}
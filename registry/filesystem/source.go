@@ -0,0 +1,178 @@
+package filesystem
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+//maxMetadataSize is the largest file read from a tarball. Index, manifest and config files are far smaller; other
+//files are never read, only sized from their tar headers.
+const maxMetadataSize = 8 * 1024 * 1024
+
+//source reads the files of an image layout or docker save tarball by their slash separated path within it
+type source interface {
+	read(name string) ([]byte, error)
+	size(name string) int64
+}
+
+//cleanName confines a path read from a layout or tarball to it
+func cleanName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+//dirSource reads an image layout directory
+type dirSource string
+
+func (d dirSource) read(name string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(string(d), filepath.FromSlash(cleanName(name))))
+}
+
+func (d dirSource) size(name string) int64 {
+	info, err := os.Stat(filepath.Join(string(d), filepath.FromSlash(cleanName(name))))
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+//tarSource indexes the files of a tarball by their headers. The manifest.json, index.json and oci-layout files are
+//kept as the tarball is indexed; any other file is read from the tarball when it is asked for.
+type tarSource struct {
+	file    string
+	gzipped bool
+	entries map[string]tarEntry
+	files   map[string][]byte //files read so far
+}
+
+//tarEntry is the size of a file in a tarball and, for uncompressed tarballs, the offset of its contents
+type tarEntry struct {
+	offset int64
+	size   int64
+}
+
+//layoutFiles are the files kept as a tarball is indexed since every image layout or docker save tarball is read
+//from them
+var layoutFiles = map[string]bool{"manifest.json": true, "index.json": true, "oci-layout": true}
+
+//readTarball indexes a tarball, gzip compressed or not, in a single pass
+func readTarball(file string) (*tarSource, error) {
+	f, tr, err := openTarball(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	src := &tarSource{file: file, gzipped: isGzipped(file), entries: map[string]tarEntry{}, files: map[string][]byte{}}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := cleanName(hdr.Name)
+		entry := tarEntry{size: hdr.Size}
+		if !src.gzipped {
+			//the tar reader stops at the start of the contents of each file it returns
+			if entry.offset, err = f.Seek(0, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		}
+		src.entries[name] = entry
+		if layoutFiles[name] && hdr.Size <= maxMetadataSize {
+			if src.files[name], err = ioutil.ReadAll(tr); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return src, nil
+}
+
+//openTarball opens a tarball for reading, decompressing it if it is gzip compressed
+func openTarball(file string) (*os.File, *tar.Reader, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !isGzipped(file) {
+		return f, tar.NewReader(f), nil
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, tar.NewReader(gz), nil
+}
+
+func isGzipped(file string) bool {
+	return strings.HasSuffix(file, ".gz") || strings.HasSuffix(file, ".tgz")
+}
+
+func (t *tarSource) read(name string) ([]byte, error) {
+	name = cleanName(name)
+	if data, ok := t.files[name]; ok {
+		return data, nil
+	}
+	entry, ok := t.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("ERROR: No file %s in tarball", name)
+	}
+	if entry.size > maxMetadataSize {
+		return nil, fmt.Errorf("ERROR: File %s in tarball is too large to read", name)
+	}
+
+	data, err := t.readEntry(name, entry)
+	if err != nil {
+		return nil, err
+	}
+	t.files[name] = data
+	return data, nil
+}
+
+//readEntry reads a file from the tarball, directly at its offset if the tarball is uncompressed and otherwise by
+//decompressing the tarball up to it
+func (t *tarSource) readEntry(name string, entry tarEntry) ([]byte, error) {
+	f, tr, err := openTarball(t.file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if !t.gzipped {
+		return ioutil.ReadAll(io.NewSectionReader(f, entry.offset, entry.size))
+	}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("ERROR: No file %s in tarball", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag == tar.TypeReg && cleanName(hdr.Name) == name {
+			return ioutil.ReadAll(tr)
+		}
+	}
+}
+
+func (t *tarSource) size(name string) int64 {
+	return t.entries[cleanName(name)].size
+}
+
+//isTarball reports whether a file is named as a tarball
+func isTarball(name string) bool {
+	return strings.HasSuffix(name, ".tar") || strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz")
+}
@@ -28,6 +28,28 @@ func (r *GitLabRegistry) Name() string {
 	return "GitLabRegistry"
 }
 
+//V2Base returns the registry used to access images through the docker v2 API
+func (r *GitLabRegistry) V2Base() *v2.V2registry {
+	return r.v2Base
+}
+
+//RepositoryPath returns the v2 repository path for an image. The org of a gitlab image is its group and project path.
+func (r *GitLabRegistry) RepositoryPath(org, repoName string) string {
+	if strings.TrimSpace(org) != "" {
+		return fmt.Sprintf("%s/%s", org, repoName)
+	}
+
+	fullRepo := repoName
+	if strings.TrimSpace(r.Org) != "" && strings.TrimSpace(r.Path) != "" {
+		fullRepo = fmt.Sprintf("%s/%s/%s", r.Org, r.Path, repoName)
+	} else if strings.TrimSpace(r.Org) != "" {
+		fullRepo = fmt.Sprintf("%s/%s", r.Org, repoName)
+	} else if strings.TrimSpace(r.Path) != "" {
+		fullRepo = fmt.Sprintf("%s/%s", r.Path, repoName)
+	}
+	return fullRepo
+}
+
 //New creates a new gitlab container registry from the given URL
 func New(registryUrl, org, path, username, password string) (*GitLabRegistry, error) {
 	if util.PrintUtil == nil {
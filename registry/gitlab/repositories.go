@@ -1,7 +1,6 @@
 package gitlab
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -51,7 +50,7 @@ func (registry *GitLabRegistry) Repositories() ([]string, error) {
 	err = registry.getGitLabJson(url, &response)
 	if err == nil {
 		for _, r := range response {
-			if !strings.HasSuffix(r.Name, "-seed") {
+			if !registry.v2Base.RepositoryList.Selects(r.Name) {
 				continue
 			}
 			repos = append(repos, r.Name)
@@ -110,7 +109,7 @@ func (registry *GitLabRegistry) Images() ([]string, error) {
 	err := registry.getGitLabJson(url, &response.Results)
 	repos := []string{}
 	for _, r := range response.Results {
-		if !strings.HasSuffix(r.Name, "-seed") {
+		if !registry.v2Base.RepositoryList.Selects(r.Name) {
 			continue
 		}
 		if len(r.Tags) > 0 {
@@ -169,30 +168,7 @@ func (registry *GitLabRegistry) ImagesWithManifests() ([]objects.Image, error) {
 
 //GetImageManifest returns the image manifest from a gitlab repo
 func (registry *GitLabRegistry) GetImageManifest(repoName, tag string) (string, error) {
-	fullRepo := repoName
-	if strings.TrimSpace(registry.Org) != "" && strings.TrimSpace(registry.Path) != "" {
-		fullRepo = fmt.Sprintf("%s/%s/%s", registry.Org, registry.Path, repoName)
-	} else if strings.TrimSpace(registry.Org) != "" {
-		fullRepo = fmt.Sprintf("%s/%s", registry.Org, repoName)
-	} else if strings.TrimSpace(registry.Path) != "" {
-		fullRepo = fmt.Sprintf("%s/%s", registry.Path, repoName)
-	}
-
-	manifest := ""
-
-	mv2, err := registry.v2Base.ManifestV2(fullRepo, tag)
-	if err == nil {
-		resp, err := registry.v2Base.DownloadLayer(fullRepo, mv2.Config.Digest)
-		if err == nil {
-			manifest, err = objects.GetSeedManifestFromBlob(resp)
-		}
-	}
-
-	if err == nil && manifest == "" {
-		err = errors.New("Empty seed manifest!")
-	}
-
-	return manifest, err
+	return registry.v2Base.SeedManifest(registry.RepositoryPath("", repoName), tag)
 }
 
 //GetRepositoryInfo returns the id for a given repository located in the GitLab registry
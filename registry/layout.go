@@ -0,0 +1,266 @@
+package registry
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/digest"
+	manifestV2 "github.com/docker/distribution/manifest/schema2"
+	"github.com/ngageoint/seed-common/objects"
+)
+
+//RefNameAnnotation names an image in the index of an OCI image layout
+const RefNameAnnotation = "org.opencontainers.image.ref.name"
+
+//layoutDescriptor is a descriptor in the index of an OCI image layout
+type layoutDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type layoutIndex struct {
+	SchemaVersion int                `json:"schemaVersion"`
+	Manifests     []layoutDescriptor `json:"manifests"`
+}
+
+//LayoutWriter writes an OCI image layout into a directory of a tar archive. Blobs shared by several images are
+//written once.
+type LayoutWriter struct {
+	tw        *tar.Writer
+	dir       string
+	blobs     map[string]bool
+	manifests []layoutDescriptor
+}
+
+func NewLayoutWriter(tw *tar.Writer, dir string) *LayoutWriter {
+	return &LayoutWriter{tw: tw, dir: dir, blobs: map[string]bool{}}
+}
+
+//AddImage copies the manifest and blobs of an image into the layout under the given reference name and returns the
+//manifest digest. Every blob is checked against its digest as it is written.
+func (lw *LayoutWriter) AddImage(src RepositoryRegistry, org, repo, tag, refName string) (string, error) {
+	backed, ok := src.(V2Backed)
+	if !ok {
+		return "", fmt.Errorf("ERROR: Registry %s does not support exporting images", src.Name())
+	}
+	from, srcPath := backed.V2Base(), backed.RepositoryPath(org, repo)
+
+	manifest, err := from.ManifestV2(srcPath, tag)
+	if err != nil {
+		return "", err
+	}
+	mediaType, payload, err := manifest.Payload()
+	if err != nil {
+		return "", err
+	}
+
+	for _, blob := range append([]distribution.Descriptor{manifest.Config}, manifest.Layers...) {
+		if lw.blobs[blob.Digest.String()] {
+			continue
+		}
+		content, err := from.DownloadLayer(srcPath, blob.Digest)
+		if err == nil && content == nil {
+			err = errors.New("Unauthorized")
+		}
+		if err != nil {
+			return "", fmt.Errorf("ERROR: Unable to download blob %s: %s", blob.Digest, err.Error())
+		}
+		err = lw.writeBlob(blob.Digest, blob.Size, content)
+		content.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	d := digest.FromBytes(payload)
+	if !lw.blobs[d.String()] {
+		if err = lw.writeBlob(d, int64(len(payload)), bytes.NewReader(payload)); err != nil {
+			return "", err
+		}
+	}
+	lw.manifests = append(lw.manifests, layoutDescriptor{MediaType: mediaType, Digest: d.String(),
+		Size: int64(len(payload)), Annotations: map[string]string{RefNameAnnotation: refName}})
+
+	return d.String(), nil
+}
+
+//Close writes the oci-layout and index.json files of the layout
+func (lw *LayoutWriter) Close() error {
+	if err := writeTarFile(lw.tw, path.Join(lw.dir, "oci-layout"), []byte(`{"imageLayoutVersion":"1.0.0"}`)); err != nil {
+		return err
+	}
+	index, err := json.Marshal(layoutIndex{SchemaVersion: 2, Manifests: lw.manifests})
+	if err != nil {
+		return err
+	}
+	return writeTarFile(lw.tw, path.Join(lw.dir, "index.json"), index)
+}
+
+//writeBlob copies a blob of the given size into the layout, failing if its content does not match the digest
+func (lw *LayoutWriter) writeBlob(d digest.Digest, size int64, content io.Reader) error {
+	verifier, err := digest.NewDigestVerifier(d)
+	if err != nil {
+		return err
+	}
+	header := &tar.Header{Name: layoutBlobPath(lw.dir, d), Mode: 0644, Size: size}
+	if err = lw.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	n, err := io.Copy(io.MultiWriter(lw.tw, verifier), io.LimitReader(content, size))
+	if err != nil {
+		return err
+	}
+	if n != size || !verifier.Verified() {
+		return fmt.Errorf("ERROR: Blob %s does not match its digest", d)
+	}
+	lw.blobs[d.String()] = true
+	return nil
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data))}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func layoutBlobPath(dir string, d digest.Digest) string {
+	return path.Join(dir, "blobs", string(d.Algorithm()), d.Hex())
+}
+
+//LayoutImage is an image read from an OCI image layout extracted to a directory
+type LayoutImage struct {
+	Digest    string
+	MediaType string
+	Payload   []byte
+	Blobs     []distribution.Descriptor
+}
+
+//Steps returns the number of steps needed to push the image: one per blob and one for the manifest
+func (img LayoutImage) Steps() int {
+	return len(img.Blobs) + 1
+}
+
+//VerifyLayout checks that every blob of an OCI image layout extracted to a directory matches its digest
+func VerifyLayout(dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, "oci-layout")); err != nil {
+		return errors.New("ERROR: Missing oci-layout file")
+	}
+	blobsDir := filepath.Join(dir, "blobs")
+	algorithms, err := ioutil.ReadDir(blobsDir)
+	if err != nil {
+		return err
+	}
+	for _, algorithm := range algorithms {
+		files, err := ioutil.ReadDir(filepath.Join(blobsDir, algorithm.Name()))
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			d := digest.NewDigestFromHex(algorithm.Name(), file.Name())
+			if err = verifyLayoutBlob(dir, d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func verifyLayoutBlob(dir string, d digest.Digest) error {
+	verifier, err := digest.NewDigestVerifier(d)
+	if err != nil {
+		return fmt.Errorf("ERROR: Invalid blob %s: %s", d, err.Error())
+	}
+	f, err := os.Open(filepath.FromSlash(layoutBlobPath(dir, d)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err = io.Copy(verifier, f); err != nil {
+		return err
+	}
+	if !verifier.Verified() {
+		return fmt.Errorf("ERROR: Blob %s does not match its digest", d)
+	}
+	return nil
+}
+
+//ReadLayoutImage reads the manifest with the given digest from an OCI image layout extracted to a directory
+func ReadLayoutImage(dir, manifestDigest string) (LayoutImage, error) {
+	img := LayoutImage{Digest: manifestDigest}
+	d, err := digest.ParseDigest(manifestDigest)
+	if err != nil {
+		return img, err
+	}
+	payload, err := ioutil.ReadFile(filepath.FromSlash(layoutBlobPath(dir, d)))
+	if err != nil {
+		return img, fmt.Errorf("ERROR: Missing manifest %s", manifestDigest)
+	}
+	if digest.FromBytes(payload) != d {
+		return img, fmt.Errorf("ERROR: Manifest %s does not match its digest", manifestDigest)
+	}
+
+	manifest := &manifestV2.DeserializedManifest{}
+	if err = manifest.UnmarshalJSON(payload); err != nil {
+		return img, err
+	}
+	if manifest.SchemaVersion != 2 || manifest.Config.Digest == "" {
+		return img, fmt.Errorf("ERROR: %s is not an image manifest", manifestDigest)
+	}
+	img.MediaType = manifest.MediaType
+	img.Payload = payload
+	img.Blobs = append([]distribution.Descriptor{manifest.Config}, manifest.Layers...)
+	for _, blob := range img.Blobs {
+		if err = blob.Digest.Validate(); err != nil {
+			return img, fmt.Errorf("ERROR: Invalid blob %s of manifest %s: %s", blob.Digest, manifestDigest, err.Error())
+		}
+		if _, err = os.Stat(filepath.FromSlash(layoutBlobPath(dir, blob.Digest))); err != nil {
+			return img, fmt.Errorf("ERROR: Missing blob %s of manifest %s", blob.Digest, manifestDigest)
+		}
+	}
+	return img, nil
+}
+
+//LayoutSeedManifest returns the seed manifest labelling the config of an image read from an OCI image layout
+func LayoutSeedManifest(dir string, img LayoutImage) (string, error) {
+	if len(img.Blobs) == 0 {
+		return "", fmt.Errorf("ERROR: Manifest %s has no config", img.Digest)
+	}
+	f, err := os.Open(filepath.FromSlash(layoutBlobPath(dir, img.Blobs[0].Digest)))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	manifest, err := objects.GetSeedManifestFromBlob(f)
+	if err == nil && manifest == "" {
+		err = errors.New("Empty seed manifest!")
+	}
+	return manifest, err
+}
+
+//PushLayoutImage pushes an image read from an OCI image layout to a registry supporting the docker v2 API and returns
+//the manifest digest. Blobs the target already has are not pushed.
+func PushLayoutImage(dir string, img LayoutImage, dst RepositoryRegistry, org, repo, tag string,
+	progress func(CopyStep)) (string, error) {
+	backed, ok := dst.(V2Backed)
+	if !ok {
+		return "", fmt.Errorf("ERROR: Registry %s does not support pushing images", dst.Name())
+	}
+
+	return pushImage(backed.V2Base(), backed.RepositoryPath(org, repo), tag, img.MediaType, img.Payload, img.Blobs,
+		func(blob distribution.Descriptor) (io.ReadCloser, error) {
+			return os.Open(filepath.FromSlash(layoutBlobPath(dir, blob.Digest)))
+		}, progress)
+}
@@ -0,0 +1,174 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ngageoint/seed-common/objects"
+	v2 "github.com/ngageoint/seed-silo/registry/v2"
+)
+
+//OrgsSetter is implemented by registries that filter their repositories by a list of orgs or namespace patterns
+type OrgsSetter interface {
+	SetOrgs(orgs []string)
+}
+
+//ValidateOrgs checks the glob patterns of a list of orgs and that the registry at the given url supports them
+func ValidateOrgs(url string, orgs []string) error {
+	list := v2.OrgList(orgs)
+	if err := list.Validate(); err != nil {
+		return fmt.Errorf("ERROR: Invalid org pattern: %s", err.Error())
+	}
+	switch regtype := checkRegistryType(url); regtype {
+	case "v2", "silo", "filesystem":
+	default:
+		if list.HasPatterns() {
+			return fmt.Errorf("ERROR: Registry type %s does not support org patterns", regtype)
+		}
+	}
+	return nil
+}
+
+//CreateOrgsRegistry creates a registry scanning the repositories of the given orgs, the first of which is its default
+//org. Registries that only list a single org at a time are created once per org and scanned together; they do not
+//support glob patterns.
+func CreateOrgsRegistry(url string, orgs []string, username, password string) (RepositoryRegistry, error) {
+	if err := ValidateOrgs(url, orgs); err != nil {
+		return nil, err
+	}
+	if len(orgs) == 0 {
+		return CreateRegistry(url, "", username, password)
+	}
+
+	reg, err := CreateRegistry(url, orgs[0], username, password)
+	if reg == nil || err != nil {
+		return reg, err
+	}
+	if setter, ok := reg.(OrgsSetter); ok {
+		setter.SetOrgs(orgs)
+		return reg, nil
+	}
+	if len(orgs) == 1 {
+		return reg, nil
+	}
+
+	base, ok := reg.(V2Backed)
+	if !ok {
+		return nil, fmt.Errorf("ERROR: Registry %s does not support scanning several orgs", reg.Name())
+	}
+	regs := []RepositoryRegistry{reg}
+	for _, org := range orgs[1:] {
+		reg, err = CreateRegistry(url, org, username, password)
+		if reg == nil && err == nil {
+			err = fmt.Errorf("ERROR: Could not create registry for org %s", org)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := reg.(V2Backed); !ok {
+			return nil, fmt.Errorf("ERROR: Registry %s does not support scanning several orgs", reg.Name())
+		}
+		regs = append(regs, reg)
+	}
+	return &orgsRegistry{regs: regs, base: base}, nil
+}
+
+//orgsRegistry scans a registry once per org for registries that only list a single org at a time. Its members are all
+//accessed through the docker v2 API and the first one is used for the default org.
+type orgsRegistry struct {
+	regs []RepositoryRegistry
+	base V2Backed //the member of the default org
+}
+
+func (r *orgsRegistry) Name() string {
+	return r.regs[0].Name()
+}
+
+func (r *orgsRegistry) Ping() error {
+	for _, reg := range r.regs {
+		if err := reg.Ping(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *orgsRegistry) Repositories() ([]string, error) {
+	repositories := []string{}
+	var errs []string
+	for _, reg := range r.regs {
+		repos, err := reg.Repositories()
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		repositories = append(repositories, repos...)
+	}
+	return repositories, joinErrors(errs)
+}
+
+//Tags returns the tags of a repository from the first org that has any
+func (r *orgsRegistry) Tags(repository string) ([]string, error) {
+	var err error
+	for _, reg := range r.regs {
+		var tags []string
+		if tags, err = reg.Tags(repository); err == nil && len(tags) > 0 {
+			return tags, nil
+		}
+	}
+	return []string{}, err
+}
+
+func (r *orgsRegistry) Images() ([]string, error) {
+	images := []string{}
+	var errs []string
+	for _, reg := range r.regs {
+		names, err := reg.Images()
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		images = append(images, names...)
+	}
+	return images, joinErrors(errs)
+}
+
+func (r *orgsRegistry) ImagesWithManifests() ([]objects.Image, error) {
+	images := []objects.Image{}
+	var errs []string
+	for _, reg := range r.regs {
+		imgs, err := reg.ImagesWithManifests()
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		images = append(images, imgs...)
+	}
+	return images, joinErrors(errs)
+}
+
+//GetImageManifest returns the seed manifest of an image from the first org that has it
+func (r *orgsRegistry) GetImageManifest(repoName, tag string) (string, error) {
+	var err error
+	for _, reg := range r.regs {
+		var manifest string
+		if manifest, err = reg.GetImageManifest(repoName, tag); err == nil {
+			return manifest, nil
+		}
+	}
+	return "", err
+}
+
+func (r *orgsRegistry) V2Base() *v2.V2registry {
+	return r.base.V2Base()
+}
+
+func (r *orgsRegistry) RepositoryPath(org, repoName string) string {
+	return r.base.RepositoryPath(org, repoName)
+}
+
+//joinErrors combines the errors of scanning several orgs, returning nil if there were none
+func joinErrors(errs []string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(errs, "\n"))
+}
@@ -0,0 +1,94 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/digest"
+	v2 "github.com/ngageoint/seed-silo/registry/v2"
+)
+
+//CopyStep reports a blob, or finally the manifest, having been copied. Skipped is set for blobs the target already had.
+type CopyStep struct {
+	Done    int
+	Total   int
+	Digest  string
+	Skipped bool
+}
+
+//CopyImage copies the blobs and manifest of an image between registries supporting the docker v2 API and returns
+//the manifest digest. Blobs the target already has are not copied. The manifest is pushed unchanged so the digest is
+//the same in both registries.
+func CopyImage(src RepositoryRegistry, srcOrg, srcRepo, srcTag string, dst RepositoryRegistry, dstOrg, dstRepo,
+	dstTag string, progress func(CopyStep)) (string, error) {
+	srcBacked, ok := src.(V2Backed)
+	if !ok {
+		return "", fmt.Errorf("ERROR: Registry %s does not support copying images", src.Name())
+	}
+	dstBacked, ok := dst.(V2Backed)
+	if !ok {
+		return "", fmt.Errorf("ERROR: Registry %s does not support copying images", dst.Name())
+	}
+	from, to := srcBacked.V2Base(), dstBacked.V2Base()
+	srcPath, dstPath := srcBacked.RepositoryPath(srcOrg, srcRepo), dstBacked.RepositoryPath(dstOrg, dstRepo)
+
+	manifest, err := from.ManifestV2(srcPath, srcTag)
+	if err != nil {
+		return "", err
+	}
+	mediaType, payload, err := manifest.Payload()
+	if err != nil {
+		return "", err
+	}
+
+	blobs := append([]distribution.Descriptor{manifest.Config}, manifest.Layers...)
+	return pushImage(to, dstPath, dstTag, mediaType, payload, blobs, func(blob distribution.Descriptor) (io.ReadCloser, error) {
+		content, err := from.DownloadLayer(srcPath, blob.Digest)
+		if err == nil && content == nil {
+			err = errors.New("Unauthorized")
+		}
+		return content, err
+	}, progress)
+}
+
+//pushImage pushes the blobs the target does not already have, then the manifest, and returns the manifest digest
+func pushImage(to *v2.V2registry, dstPath, dstTag, mediaType string, payload []byte, blobs []distribution.Descriptor,
+	open func(distribution.Descriptor) (io.ReadCloser, error), progress func(CopyStep)) (string, error) {
+	step := CopyStep{Total: len(blobs) + 1}
+	for _, blob := range blobs {
+		step.Digest, step.Skipped = blob.Digest.String(), false
+		exists, err := to.HasLayer(dstPath, blob.Digest)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			step.Skipped = true
+		} else if err = pushBlob(to, dstPath, blob, open); err != nil {
+			return "", fmt.Errorf("ERROR: Unable to copy blob %s: %s", blob.Digest, err.Error())
+		}
+		step.Done++
+		progress(step)
+	}
+
+	if err := to.PutManifestV2(dstPath, dstTag, mediaType, payload); err != nil {
+		return "", err
+	}
+	step.Done++
+	step.Digest, step.Skipped = digest.FromBytes(payload).String(), false
+	progress(step)
+
+	return step.Digest, nil
+}
+
+func pushBlob(to *v2.V2registry, dstPath string, blob distribution.Descriptor,
+	open func(distribution.Descriptor) (io.ReadCloser, error)) error {
+	content, err := open(blob)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	return to.UploadLayer(dstPath, blob.Digest, content, blob.Size)
+}
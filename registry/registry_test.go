@@ -1,14 +1,26 @@
 package registry
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"testing"
 
+	"github.com/docker/distribution/digest"
+
 	"github.com/ngageoint/seed-common/objects"
 	"github.com/ngageoint/seed-common/util"
+	v2 "github.com/ngageoint/seed-silo/registry/v2"
 )
 
 func TestMain(m *testing.M) {
@@ -268,6 +280,568 @@ func TestGetImageManifest(t *testing.T) {
 	}
 }
 
+func TestFilesystemRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "silo-fs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeTestLayout(t, filepath.Join(dir, "extractor-0.1.0-seed"), "extractor", "0.1.0")
+	writeTestSaveTarball(t, filepath.Join(dir, "my-job.tar"), "geointseed/my-job-0.1.0-seed:0.1.0", "my-job")
+	writeTestSaveTarball(t, filepath.Join(dir, "other.tar.gz"), "other/my-job-0.1.0-seed:0.2.0", "my-job")
+
+	reg, err := CreateRegistry("file://"+dir, "", "", "")
+	if err != nil {
+		t.Fatalf("CreateRegistry returned an error: %v\n", err)
+	}
+
+	images, err := reg.Images()
+	expected := "[extractor-0.1.0-seed:0.1.0 geointseed/my-job-0.1.0-seed:0.1.0 other/my-job-0.1.0-seed:0.2.0]"
+	if err != nil || fmt.Sprint(images) != expected {
+		t.Errorf("Images returned %v, %v, expected %v\n", images, err, expected)
+	}
+
+	//layers are sized from the tarballs without being read
+	for _, name := range []string{"geointseed/my-job-0.1.0-seed:0.1.0", "other/my-job-0.1.0-seed:0.2.0"} {
+		metadata, found := reg.(MetadataSource).ImageMetadata("", "", name)
+		if !found || metadata.Size != 1000 || metadata.ConfigDigest == "" {
+			t.Errorf("ImageMetadata of %s returned %v, %v, expected a size of 1000\n", name, metadata, found)
+		}
+	}
+
+	manifest, err := reg.GetImageManifest("geointseed/my-job-0.1.0-seed", "0.1.0")
+	seed, err2 := objects.SeedFromManifestString(manifest)
+	if err != nil || err2 != nil || seed.Job.Name != "my-job" {
+		t.Errorf("GetImageManifest returned job %v, %v, %v, expected my-job\n", seed.Job.Name, err, err2)
+	}
+
+	//tags come from the last listing and removed files are dropped on the next one
+	os.Remove(filepath.Join(dir, "my-job.tar"))
+	tags, err := reg.Tags("geointseed/my-job-0.1.0-seed")
+	if err != nil || fmt.Sprint(tags) != "[0.1.0]" {
+		t.Errorf("Tags returned %v, %v, expected [0.1.0]\n", tags, err)
+	}
+	withManifests, err := reg.ImagesWithManifests()
+	if err != nil || len(withManifests) != 2 || withManifests[0].Name != "extractor-0.1.0-seed:0.1.0" {
+		t.Errorf("ImagesWithManifests returned %v, %v after removing a tarball\n", withManifests, err)
+	}
+
+	_, err = CreateRegistry("file://"+filepath.Join(dir, "missing"), "", "", "")
+	if err == nil {
+		t.Errorf("CreateRegistry did not return an error for a missing directory")
+	}
+}
+
+func TestSiloRegistry(t *testing.T) {
+	//the upstream silo scanned images of the same name from two registries and two orgs
+	images := `[
+		{"ID": 1, "Name": "my-job-0.1.0-seed:0.1.0", "Registry": "docker.io", "Org": "geointseed", "Digest": "sha256:aaa"},
+		{"ID": 2, "Name": "my-job-0.1.0-seed:0.1.0", "Registry": "quay.io", "Org": "geointseed", "Digest": "sha256:bbb"},
+		{"ID": 3, "Name": "my-job-0.1.0-seed:0.1.0", "Registry": "docker.io", "Org": "other", "Digest": "sha256:ccc"}
+	]`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `[]`)
+		case "/images":
+			fmt.Fprint(w, images)
+		case "/images/1/manifest", "/images/2/manifest", "/images/3/manifest":
+			fmt.Fprint(w, `{"seedVersion": "1.0.0", "job": {"name": "my-job"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	reg, err := CreateRegistry("silo+"+server.URL, "", "", "")
+	if err != nil {
+		t.Fatalf("CreateRegistry returned an error: %v\n", err)
+	}
+	withManifests, err := reg.ImagesWithManifests()
+	if err != nil || len(withManifests) != 3 {
+		t.Fatalf("ImagesWithManifests returned %v, %v, expected 3 images\n", withManifests, err)
+	}
+
+	source, ok := reg.(MetadataSource)
+	if !ok {
+		t.Fatalf("Silo registry is not a metadata source")
+	}
+	cases := []struct {
+		registry string
+		org      string
+		digest   string
+		found    bool
+	}{
+		{"docker.io", "geointseed", "sha256:aaa", true},
+		{"quay.io", "geointseed", "sha256:bbb", true},
+		{"docker.io", "other", "sha256:ccc", true},
+		{"quay.io", "other", "", false},
+	}
+	for _, c := range cases {
+		metadata, found := source.ImageMetadata(c.registry, c.org, "my-job-0.1.0-seed:0.1.0")
+		if found != c.found || metadata.Digest != c.digest {
+			t.Errorf("ImageMetadata of %s/%s returned %v, %v, expected %v, %v\n", c.registry, c.org, metadata.Digest,
+				found, c.digest, c.found)
+		}
+	}
+}
+
+func TestRepositoryList(t *testing.T) {
+	//a registry allowing pulls with credentials but forbidding catalog listing
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "testuser" || pass != "testpassword" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/":
+			w.Write([]byte(`{}`))
+		case "/v2/_catalog":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":[{"code":"DENIED","message":"catalog listing forbidden"}]}`))
+		case "/v2/seed/my-job/tags/list":
+			w.Write([]byte(`{"name":"seed/my-job","tags":["0.1.0","1.0.0"]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	reg, err := CreateRegistry(server.URL, "", "", "")
+	if reg != nil && err == nil {
+		t.Errorf("CreateRegistry did not fail without credentials")
+	}
+
+	reg, err = CreateRegistry(server.URL, "", "testuser", "testpassword")
+	if reg == nil || err != nil {
+		t.Fatalf("CreateRegistry returned an error for a registry forbidding catalog listing: %v\n", err)
+	}
+
+	if err = SetRepositoryList(reg, []string{"seed/my-job"}); err != nil {
+		t.Fatalf("SetRepositoryList returned an error: %v\n", err)
+	}
+	images, err := reg.Images()
+	expected := "[seed/my-job:0.1.0 seed/my-job:1.0.0]"
+	if err != nil || fmt.Sprint(images) != expected {
+		t.Errorf("Images returned %v, %v, expected %v\n", images, err, expected)
+	}
+
+	//patterns need the catalog
+	SetRepositoryList(reg, []string{"seed/*"})
+	_, err = reg.Repositories()
+	if err == nil || !strings.Contains(err.Error(), "catalog listing forbidden") {
+		t.Errorf("Repositories returned %v, expected the catalog error\n", err)
+	}
+
+	if err = SetRepositoryList(reg, []string{"seed/["}); err == nil {
+		t.Errorf("SetRepositoryList did not return an error for a bad pattern")
+	}
+
+	//registries scanned once per org all need the docker v2 API
+	dir, err := ioutil.TempDir("", "silo-fs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fs, err := CreateRegistry("file://"+dir, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	orgs := &orgsRegistry{regs: []RepositoryRegistry{reg, fs}, base: reg.(V2Backed)}
+	if err = SetRepositoryList(orgs, []string{"seed/my-job"}); err == nil {
+		t.Errorf("SetRepositoryList did not return an error for a member without the docker v2 API")
+	}
+}
+
+func TestOrgs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/":
+			w.Write([]byte(`{}`))
+		case r.URL.Path == "/v2/_catalog":
+			w.Write([]byte(`{"repositories":["seed/a-seed","seed/nested/b-seed","other/c-seed","team-x/d-seed","e-seed"]}`))
+		case strings.HasSuffix(r.URL.Path, "/tags/list"):
+			name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v2/"), "/tags/list")
+			w.Write([]byte(fmt.Sprintf(`{"name":"%s","tags":["1.0.0"]}`, name)))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		orgs     []string
+		expected string
+	}{
+		{nil, "[seed/a-seed:1.0.0 seed/nested/b-seed:1.0.0 other/c-seed:1.0.0 team-x/d-seed:1.0.0 e-seed:1.0.0]"},
+		{[]string{"seed"}, "[seed/a-seed:1.0.0 seed/nested/b-seed:1.0.0]"},
+		{[]string{"other", "team-*"}, "[other/c-seed:1.0.0 team-x/d-seed:1.0.0]"},
+		{[]string{"missing"}, "[]"},
+	}
+
+	for _, c := range cases {
+		reg, err := CreateOrgsRegistry(server.URL, c.orgs, "", "")
+		if reg == nil || err != nil {
+			t.Fatalf("CreateOrgsRegistry returned an error for orgs %v: %v\n", c.orgs, err)
+		}
+		images, err := reg.Images()
+		if err != nil || fmt.Sprint(images) != c.expected {
+			t.Errorf("Images for orgs %v returned %v, %v, expected %v\n", c.orgs, images, err, c.expected)
+		}
+	}
+
+	if err := ValidateOrgs(server.URL, []string{"seed/["}); err == nil {
+		t.Errorf("ValidateOrgs did not return an error for a bad pattern")
+	}
+	if err := ValidateOrgs("hub.docker.com", []string{"team-*"}); err == nil {
+		t.Errorf("ValidateOrgs did not return an error for a pattern on docker hub")
+	}
+	if err := ValidateOrgs("hub.docker.com", []string{"geointseed", "other"}); err != nil {
+		t.Errorf("ValidateOrgs returned an error for a list of docker hub orgs: %v\n", err)
+	}
+
+	for repo, expected := range map[string]string{"seed/nested/b-seed": "seed/nested", "e-seed": ""} {
+		if org := v2.Namespace(repo); org != expected {
+			t.Errorf("Namespace of %s returned %v, expected %v\n", repo, org, expected)
+		}
+	}
+}
+
+func TestImageMetadata(t *testing.T) {
+	config := testConfig(t, "my-job")
+	configDigest := digest.FromBytes(config)
+	manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json","config":{"mediaType":"application/vnd.docker.container.image.v1+json","digest":"%s","size":%d},"layers":[{"mediaType":"application/vnd.docker.image.rootfs.diff.tar.gzip","digest":"%s","size":42}]}`,
+		configDigest, len(config), digest.FromBytes([]byte("layer"))))
+
+	fetches := map[string]int{} //authorized requests, leaving out the unauthorized ones probing for a token
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorized := 0
+		if r.Header.Get("Authorization") != "" {
+			authorized = 1
+		}
+		switch r.URL.Path {
+		case "/v2/":
+			w.Write([]byte(`{}`))
+		case "/v2/_catalog":
+			w.Write([]byte(`{"repositories":["seed/my-job-seed"]}`))
+		case "/v2/seed/my-job-seed/tags/list":
+			w.Write([]byte(`{"name":"seed/my-job-seed","tags":["1.0.0"]}`))
+		case "/v2/seed/my-job-seed/manifests/1.0.0":
+			fetches["manifest"] += authorized
+			w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+			w.Write(manifest)
+		case "/v2/seed/my-job-seed/blobs/" + configDigest.String():
+			fetches["config"] += authorized
+			w.Write(config)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	reg, err := CreateRegistry(server.URL, "", "", "")
+	if reg == nil || err != nil {
+		t.Fatalf("CreateRegistry returned an error: %v\n", err)
+	}
+	images, err := reg.ImagesWithManifests()
+	if err != nil || len(images) != 1 {
+		t.Fatalf("ImagesWithManifests returned %v, %v, expected one image\n", images, err)
+	}
+
+	//the scan already read the manifest and config
+	metadata, err := GetImageMetadata(reg, images[0].Org, "seed/my-job-seed", "1.0.0")
+	if err != nil || metadata.Digest != digest.FromBytes(manifest).String() || metadata.ConfigDigest != configDigest.String() ||
+		metadata.Size != 42 || metadata.Platform != "linux/amd64" {
+		t.Errorf("GetImageMetadata returned %v, %v\n", metadata, err)
+	}
+	if fetches["manifest"] != 1 || fetches["config"] != 1 {
+		t.Errorf("Scanning fetched the manifest %d and config %d times, expected once each\n", fetches["manifest"], fetches["config"])
+	}
+
+	//images that were not scanned are fetched
+	if _, err = GetImageMetadata(reg, images[0].Org, "seed/my-job-seed", "1.0.0"); err != nil || fetches["manifest"] != 2 {
+		t.Errorf("GetImageMetadata returned %v after fetching the manifest %d times\n", err, fetches["manifest"])
+	}
+	if _, err = GetImageMetadata(reg, "seed", "seed/missing-seed", "1.0.0"); err == nil {
+		t.Errorf("GetImageMetadata did not return an error for a missing image")
+	}
+}
+
+func TestRepositoryPath(t *testing.T) {
+	reg, _ := v2.New("https://registry.example.com", "", "", "")
+	cases := []struct {
+		org, repoName, expected string
+	}{
+		{"", "my-job-seed", "my-job-seed"},
+		{"seed", "my-job-seed", "seed/my-job-seed"},
+		{"seed", "seed/my-job-seed", "seed/my-job-seed"},
+		{"seed/nested", "seed/nested/my-job-seed", "seed/nested/my-job-seed"},
+		{"seed", "seedling/my-job-seed", "seed/seedling/my-job-seed"},
+	}
+	for _, c := range cases {
+		if path := reg.RepositoryPath(c.org, c.repoName); path != c.expected {
+			t.Errorf("RepositoryPath(%q, %q) returned %v, expected %v\n", c.org, c.repoName, path, c.expected)
+		}
+	}
+}
+
+func TestRegistryTokens(t *testing.T) {
+	//two registries handing out their own tokens for the base endpoint, the catalog and a repository of the same name
+	newServer := func(token string) *httptest.Server {
+		var server *httptest.Server
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/token":
+				w.Write([]byte(fmt.Sprintf(`{"token":"%s","expires_in":300}`, token)))
+			case "/v2/seed/my-job-seed/manifests/1.0.0":
+				if r.Header.Get("Authorization") != "Bearer "+token {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.WriteHeader(http.StatusCreated)
+			case "/v2/", "/v2/_catalog":
+				if r.Header.Get("Authorization") != "Bearer "+token {
+					w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test"`, server.URL))
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.Write([]byte(`{"repositories":["seed/my-job-seed"]}`))
+			default:
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test"`, server.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}))
+		return server
+	}
+	first, second := newServer("first-token"), newServer("second-token")
+	defer first.Close()
+	defer second.Close()
+
+	for _, server := range []*httptest.Server{first, second, first} {
+		reg, _ := v2.New(server.URL, "seed", "", "")
+		if err := reg.CheckAuth(); err != nil {
+			t.Errorf("CheckAuth of %s returned an error: %v\n", server.URL, err)
+		}
+		if repos, err := reg.Repositories(); err != nil || len(repos) != 1 {
+			t.Errorf("Repositories of %s returned %v, %v\n", server.URL, repos, err)
+		}
+		err := reg.PutManifestV2("seed/my-job-seed", "1.0.0", "application/vnd.docker.distribution.manifest.v2+json", []byte(`{}`))
+		if err != nil {
+			t.Errorf("PutManifestV2 to %s returned an error: %v\n", server.URL, err)
+		}
+	}
+}
+
+func TestLayout(t *testing.T) {
+	config := testConfig(t, "my-job")
+	layer := []byte("layer")
+	manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json","config":{"mediaType":"application/vnd.docker.container.image.v1+json","digest":"%s","size":%d},"layers":[{"mediaType":"application/vnd.docker.image.rootfs.diff.tar.gzip","digest":"%s","size":%d}]}`,
+		digest.FromBytes(config), len(config), digest.FromBytes(layer), len(layer)))
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/", "/v2/_catalog":
+			w.Write([]byte(`{"repositories":["seed/my-job-seed"]}`))
+		case "/v2/seed/my-job-seed/manifests/1.0.0":
+			w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+			w.Write(manifest)
+		case "/v2/seed/my-job-seed/blobs/" + digest.FromBytes(config).String():
+			w.Write(config)
+		case "/v2/seed/my-job-seed/blobs/" + digest.FromBytes(layer).String():
+			w.Write(layer)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer source.Close()
+
+	//write the image into a layout twice under different names
+	src, err := CreateRegistry(source.URL, "", "", "")
+	if err != nil {
+		t.Fatalf("CreateRegistry returned an error: %v\n", err)
+	}
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	lw := NewLayoutWriter(tw, "layout")
+	d, err := lw.AddImage(src, "seed", "seed/my-job-seed", "1.0.0", "my-job-seed:1.0.0")
+	if err != nil || d != digest.FromBytes(manifest).String() {
+		t.Fatalf("AddImage returned %v, %v, expected %v\n", d, err, digest.FromBytes(manifest))
+	}
+	if _, err = lw.AddImage(src, "seed", "seed/my-job-seed", "1.0.0", "my-job-seed:latest"); err != nil {
+		t.Fatalf("AddImage returned an error: %v\n", err)
+	}
+	if err = lw.Close(); err != nil {
+		t.Fatalf("Close returned an error: %v\n", err)
+	}
+	tw.Close()
+
+	dir, err := ioutil.TempDir("", "silo-layout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	names := []string{}
+	tr := tar.NewReader(&archive)
+	for header, err := tr.Next(); err == nil; header, err = tr.Next() {
+		names = append(names, header.Name)
+		data, _ := ioutil.ReadAll(tr)
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, header.Name)), 0755)
+		ioutil.WriteFile(filepath.Join(dir, header.Name), data, 0644)
+	}
+	if len(names) != 5 {
+		t.Errorf("Layout holds %v, expected the config, layer and manifest blobs once and the index files\n", names)
+	}
+	layoutDir := filepath.Join(dir, "layout")
+	index, _ := ioutil.ReadFile(filepath.Join(layoutDir, "index.json"))
+	if !strings.Contains(string(index), "my-job-seed:1.0.0") || !strings.Contains(string(index), "my-job-seed:latest") {
+		t.Errorf("Layout index %s does not name both images\n", index)
+	}
+
+	if err = VerifyLayout(layoutDir); err != nil {
+		t.Errorf("VerifyLayout returned an error: %v\n", err)
+	}
+	img, err := ReadLayoutImage(layoutDir, d)
+	if err != nil || img.Steps() != 3 || img.Blobs[0].Digest != digest.FromBytes(config) {
+		t.Fatalf("ReadLayoutImage returned %v, %v\n", img, err)
+	}
+	if seed, err := LayoutSeedManifest(layoutDir, img); err != nil || !strings.Contains(seed, `"name":"my-job"`) {
+		t.Errorf("LayoutSeedManifest returned %v, %v\n", seed, err)
+	}
+	if _, err = ReadLayoutImage(layoutDir, digest.FromBytes(config).String()); err == nil {
+		t.Errorf("ReadLayoutImage did not return an error for a blob that is not a manifest")
+	}
+	if _, err = ReadLayoutImage(layoutDir, digest.FromBytes([]byte("missing")).String()); err == nil {
+		t.Errorf("ReadLayoutImage did not return an error for a missing manifest")
+	}
+
+	//import the image into the org of another registry
+	pushed := map[string][]byte{}
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		switch {
+		case r.URL.Path == "/v2/" || r.URL.Path == "/v2/_catalog":
+			w.Write([]byte(`{"repositories":[]}`))
+		case r.Method == "HEAD":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == "POST" && r.URL.Path == "/v2/target/my-job-seed/blobs/uploads/":
+			w.Header().Set("Location", "/upload")
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == "PUT" && r.URL.Path == "/upload" && digest.FromBytes(body).String() == r.URL.Query().Get("digest"):
+			pushed[r.URL.Query().Get("digest")] = body
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == "PUT" && r.URL.Path == "/v2/target/my-job-seed/manifests/1.0.0":
+			pushed["manifest"] = body
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer target.Close()
+
+	dst, err := CreateRegistry(target.URL, "target", "", "")
+	if err != nil {
+		t.Fatalf("CreateRegistry returned an error: %v\n", err)
+	}
+	steps := 0
+	pushedDigest, err := PushLayoutImage(layoutDir, img, dst, "target", "my-job-seed", "1.0.0", func(CopyStep) { steps++ })
+	if err != nil || pushedDigest != d || steps != 3 || len(pushed) != 3 || string(pushed["manifest"]) != string(manifest) {
+		t.Errorf("PushLayoutImage returned %v, %v after %d steps pushing %d blobs and manifests\n", pushedDigest, err,
+			steps, len(pushed))
+	}
+
+	//tampered and misnamed blobs are rejected
+	layerPath := filepath.Join(layoutDir, "blobs", "sha256", digest.FromBytes(layer).Hex())
+	ioutil.WriteFile(layerPath, []byte("tampered"), 0644)
+	if err = VerifyLayout(layoutDir); err == nil || !strings.Contains(err.Error(), "does not match its digest") {
+		t.Errorf("VerifyLayout returned %v for a tampered blob\n", err)
+	}
+	os.Remove(layerPath)
+	ioutil.WriteFile(filepath.Join(layoutDir, "blobs", "sha256", "not-a-digest"), layer, 0644)
+	if err = VerifyLayout(layoutDir); err == nil {
+		t.Errorf("VerifyLayout did not return an error for a blob named by a bad digest")
+	}
+	if _, err = ReadLayoutImage(layoutDir, d); err == nil {
+		t.Errorf("ReadLayoutImage did not return an error for a missing blob")
+	}
+}
+
+//testConfig returns an image config blob labelled with a seed manifest for the given job
+func testConfig(t *testing.T, job string) []byte {
+	seed := fmt.Sprintf(`{"seedVersion":"1.0.0","job":{"name":"%s","jobVersion":"0.1.0","packageVersion":"0.1.0"}}`, job)
+	config, err := json.Marshal(map[string]interface{}{
+		"architecture": "amd64",
+		"os":           "linux",
+		"config":       map[string]interface{}{"Labels": map[string]string{"com.ngageoint.seed.manifest": seed}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+//writeTestLayout writes an OCI image layout holding one image named by its tag
+func writeTestLayout(t *testing.T, dir, job, tag string) {
+	config := testConfig(t, job)
+	configDigest := digest.FromBytes(config)
+	manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,"config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"%s","size":%d},"layers":[]}`,
+		configDigest, len(config)))
+	manifestDigest := digest.FromBytes(manifest)
+	index := fmt.Sprintf(`{"schemaVersion":2,"manifests":[{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"%s","size":%d,"annotations":{"org.opencontainers.image.ref.name":"%s"}}]}`,
+		manifestDigest, len(manifest), tag)
+
+	files := map[string][]byte{
+		"oci-layout": []byte(`{"imageLayoutVersion":"1.0.0"}`),
+		"index.json": []byte(index),
+		filepath.Join("blobs", "sha256", configDigest.Hex()):   config,
+		filepath.Join("blobs", "sha256", manifestDigest.Hex()): manifest,
+	}
+	for name, data := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+//writeTestSaveTarball writes a docker save tarball holding one image with a 1000 byte layer, gzip compressed if the
+//file name ends in .gz
+func writeTestSaveTarball(t *testing.T, file, name, job string) {
+	config := testConfig(t, job)
+	configName := digest.FromBytes(config).Hex() + ".json"
+	manifest := []byte(fmt.Sprintf(`[{"Config":"%s","RepoTags":["%s"],"Layers":["layer/layer.tar"]}]`, configName,
+		name))
+
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var w io.Writer = f
+	if strings.HasSuffix(file, ".gz") {
+		gz := gzip.NewWriter(f)
+		defer gz.Close()
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+	for _, entry := range []struct {
+		name string
+		data []byte
+	}{{"layer/layer.tar", make([]byte, 1000)}, {configName, config}, {"manifest.json", manifest}} {
+		if err = tw.WriteHeader(&tar.Header{Name: entry.name, Mode: 0644, Size: int64(len(entry.data))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = tw.Write(entry.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func CreateTestRegistries() ([]RepositoryRegistry, error) {
 	cases := []struct {
 		url      string
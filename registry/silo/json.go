@@ -0,0 +1,29 @@
+package silo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+//getSiloJson decodes the JSON response of a call to the upstream silo
+func (r *SiloRegistry) getSiloJson(url string, response interface{}) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	if r.token != "" {
+		req.Header.Set("Authorization", "token "+r.token)
+	}
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("http: non-successful response (status=%v)", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(response)
+}
@@ -0,0 +1,113 @@
+package silo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/ngageoint/seed-common/util"
+	v2 "github.com/ngageoint/seed-silo/registry/v2"
+)
+
+//Scheme prefixes the url of an upstream silo, e.g. silo+https://silo.example.com
+const Scheme = "silo+"
+
+//SiloRegistry type representing another silo instance whose images are read through its REST API. The registry and
+//org of each image are those recorded by the upstream silo.
+type SiloRegistry struct {
+	URL      string
+	Client   *http.Client
+	Org      string     //only images from this org are listed, if set
+	Orgs     v2.OrgList //only images from these orgs are listed instead, if set
+	Username string
+	Password string
+	token    string
+	listed   map[imageKey]Image //images of the last listing by registry, org and name
+	Print    util.PrintCallback
+}
+
+func (r *SiloRegistry) Name() string {
+	return "SiloRegistry"
+}
+
+//New creates a new upstream silo registry from the given URL, with or without the silo+ prefix. Given a username and
+//password it logs in to the upstream silo; given only a password it uses the password as the token.
+func New(registryUrl, org, username, password string) (*SiloRegistry, error) {
+	if util.PrintUtil == nil {
+		util.InitPrinter(util.PrintErr, os.Stderr, os.Stdout)
+	}
+	url := strings.TrimSuffix(strings.TrimPrefix(registryUrl, Scheme), "/")
+
+	registry := &SiloRegistry{
+		URL:      url,
+		Client:   &http.Client{},
+		Org:      org,
+		Username: username,
+		Password: password,
+		Print:    util.PrintUtil,
+	}
+
+	if username != "" {
+		err := registry.login()
+		return registry, err
+	}
+	registry.token = password
+
+	return registry, nil
+}
+
+//SetOrgs makes the registry list the images of several orgs
+func (r *SiloRegistry) SetOrgs(orgs []string) {
+	r.Orgs = orgs
+}
+
+//orgs returns the orgs whose images are listed: the list of orgs if set, otherwise the org
+func (r *SiloRegistry) orgs() v2.OrgList {
+	if len(r.Orgs) > 0 {
+		return r.Orgs
+	}
+	if r.Org != "" {
+		return v2.OrgList{r.Org}
+	}
+	return nil
+}
+
+func (r *SiloRegistry) url(pathTemplate string, args ...interface{}) string {
+	pathSuffix := fmt.Sprintf(pathTemplate, args...)
+	url := fmt.Sprintf("%s%s", r.URL, pathSuffix)
+	return url
+}
+
+//login gets a token from the upstream silo
+func (r *SiloRegistry) login() error {
+	body, err := json.Marshal(map[string]string{"username": r.Username, "password": r.Password})
+	if err != nil {
+		return err
+	}
+	resp, err := r.Client.Post(r.url("/login"), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("http: unable to log in to %s (status=%v)", r.URL, resp.StatusCode)
+	}
+
+	var token struct {
+		Token string `json:"token"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return err
+	}
+	r.token = token.Token
+	return nil
+}
+
+func (r *SiloRegistry) Ping() error {
+	//the index lists the routes of the upstream silo
+	var routes []interface{}
+	return r.getSiloJson(r.url("/"), &routes)
+}
@@ -0,0 +1,152 @@
+package silo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ngageoint/seed-common/objects"
+	v2 "github.com/ngageoint/seed-silo/registry/v2"
+)
+
+//Image is an image as listed by the upstream silo
+type Image struct {
+	ID       int
+	Name     string
+	Registry string
+	Org      string
+	Digest   string
+	Size     int64
+	Created  string
+	Platform string
+}
+
+//imageKey identifies an upstream image: the upstream silo may list images of the same name from several registries
+//or orgs
+type imageKey struct {
+	Registry string
+	Org      string
+	Name     string
+}
+
+//images lists the images of the upstream silo, keeping only those of the registry's orgs if it has any
+func (r *SiloRegistry) images() ([]Image, error) {
+	var all []Image
+	if err := r.getSiloJson(r.url("/images"), &all); err != nil {
+		return nil, err
+	}
+
+	images := []Image{}
+	r.listed = map[imageKey]Image{}
+	for _, img := range all {
+		if r.orgs().Selects(img.Org) {
+			images = append(images, img)
+			r.listed[imageKey{img.Registry, img.Org, img.Name}] = img
+		}
+	}
+	return images, nil
+}
+
+func splitName(name string) (string, string) {
+	i := strings.LastIndex(name, ":")
+	if i < 0 || strings.Contains(name[i:], "/") {
+		return name, "latest"
+	}
+	return name[:i], name[i+1:]
+}
+
+func (r *SiloRegistry) Repositories() ([]string, error) {
+	images, err := r.images()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	repositories := []string{}
+	for _, img := range images {
+		repo, _ := splitName(img.Name)
+		if !seen[repo] {
+			seen[repo] = true
+			repositories = append(repositories, repo)
+		}
+	}
+	return repositories, nil
+}
+
+func (r *SiloRegistry) Tags(repository string) ([]string, error) {
+	images, err := r.images()
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []string{}
+	for _, img := range images {
+		if repo, tag := splitName(img.Name); repo == repository {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+func (r *SiloRegistry) Images() ([]string, error) {
+	images, err := r.images()
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, img := range images {
+		names = append(names, img.Name)
+	}
+	return names, nil
+}
+
+//ImagesWithManifests returns the upstream images with their seed manifests and the registry and org the upstream silo
+//scanned them from
+func (r *SiloRegistry) ImagesWithManifests() ([]objects.Image, error) {
+	images, err := r.images()
+	if err != nil {
+		return nil, err
+	}
+	r.Print("Getting Manifests for %d images in upstream silo %s", len(images), r.URL)
+
+	result := []objects.Image{}
+	for _, img := range images {
+		manifest, err := r.manifest(img.ID)
+		if err != nil {
+			r.Print("ERROR: Error reading manifest for %s: %s\n Skipping.\n", img.Name, err.Error())
+			continue
+		}
+		result = append(result, objects.Image{Name: img.Name, Registry: img.Registry, Org: img.Org, Manifest: manifest})
+	}
+	return result, nil
+}
+
+func (r *SiloRegistry) GetImageManifest(repoName, tag string) (string, error) {
+	images, err := r.images()
+	if err != nil {
+		return "", err
+	}
+	for _, img := range images {
+		if img.Name == repoName+":"+tag {
+			return r.manifest(img.ID)
+		}
+	}
+	return "", fmt.Errorf("ERROR: No image %s:%s in upstream silo %s", repoName, tag, r.URL)
+}
+
+//ImageMetadata returns the digest, size, created time and platform the upstream silo recorded for an image it listed
+//from the given registry and org
+func (r *SiloRegistry) ImageMetadata(registry, org, name string) (v2.ImageMetadata, bool) {
+	img, ok := r.listed[imageKey{registry, org, name}]
+	if !ok {
+		return v2.ImageMetadata{}, false
+	}
+	return v2.ImageMetadata{Digest: img.Digest, Size: img.Size, Created: img.Created, Platform: img.Platform}, true
+}
+
+func (r *SiloRegistry) manifest(id int) (string, error) {
+	var manifest json.RawMessage
+	err := r.getSiloJson(r.url("/images/%d/manifest", id), &manifest)
+	return string(manifest), err
+}
@@ -0,0 +1,127 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+
+	"github.com/docker/distribution/digest"
+	"github.com/ngageoint/seed-common/objects"
+)
+
+//seedManifestLabel holds the seed manifest, which silo already stores on its own
+const seedManifestLabel = "com.ngageoint.seed.manifest"
+
+//ImageMetadata describes an image manifest and its config blob
+type ImageMetadata struct {
+	Digest       string
+	ConfigDigest string
+	Size         int64 //total compressed size of the layers
+	Created      string
+	Platform     string
+	Labels       map[string]string
+}
+
+//imageConfig is the part of an image config blob silo records
+type imageConfig struct {
+	Created      string `json:"created"`
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant"`
+	Config       struct {
+		Labels map[string]string `json:"Labels"`
+	} `json:"config"`
+}
+
+//ImageMetadata reads the digests, size, created time, platform and labels of an image from its manifest and config,
+//unless they were already read along with its seed manifest
+func (registry *V2registry) ImageMetadata(repository, reference string) (ImageMetadata, error) {
+	key := repository + ":" + reference
+	registry.scannedMu.Lock()
+	metadata, ok := registry.scanned[key]
+	delete(registry.scanned, key)
+	registry.scannedMu.Unlock()
+	if ok {
+		return metadata, nil
+	}
+
+	metadata, _, err := registry.readImage(repository, reference)
+	return metadata, err
+}
+
+//SeedManifest returns the seed manifest label of an image config, keeping the rest of the image metadata for the
+//ImageMetadata call of the scan that follows so the manifest and config are only fetched once
+func (registry *V2registry) SeedManifest(repository, reference string) (string, error) {
+	metadata, config, err := registry.readImage(repository, reference)
+	if err != nil {
+		return "", err
+	}
+	manifest, err := objects.GetSeedManifestFromBlob(ioutil.NopCloser(bytes.NewReader(config)))
+	if err != nil {
+		return "", err
+	}
+	if manifest == "" {
+		return "", errors.New("Empty seed manifest!")
+	}
+
+	registry.scannedMu.Lock()
+	if registry.scanned == nil {
+		registry.scanned = map[string]ImageMetadata{}
+	}
+	registry.scanned[repository+":"+reference] = metadata
+	registry.scannedMu.Unlock()
+	return manifest, nil
+}
+
+//readImage fetches the manifest and config blob of an image, returning its metadata and the raw config
+func (registry *V2registry) readImage(repository, reference string) (ImageMetadata, []byte, error) {
+	metadata := ImageMetadata{Labels: map[string]string{}}
+	mv2, err := registry.ManifestV2(repository, reference)
+	if err != nil {
+		return metadata, nil, err
+	}
+
+	_, payload, err := mv2.Payload()
+	if err != nil {
+		return metadata, nil, err
+	}
+	metadata.Digest = digest.FromBytes(payload).String()
+	metadata.ConfigDigest = mv2.Config.Digest.String()
+	for _, layer := range mv2.Layers {
+		metadata.Size += layer.Size
+	}
+
+	blob, err := registry.DownloadLayer(repository, mv2.Config.Digest)
+	if err != nil {
+		return metadata, nil, err
+	}
+	if blob == nil {
+		return metadata, nil, errors.New("Unable to download image config")
+	}
+	defer blob.Close()
+	body, err := ioutil.ReadAll(blob)
+	if err != nil {
+		return metadata, nil, err
+	}
+
+	config := imageConfig{}
+	if err = json.Unmarshal(body, &config); err != nil {
+		return metadata, nil, err
+	}
+	metadata.Created = config.Created
+	if config.OS != "" {
+		metadata.Platform = config.OS + "/" + config.Architecture
+		if config.Variant != "" {
+			metadata.Platform += "/" + config.Variant
+		}
+	}
+	for key, value := range config.Config.Labels {
+		if key == seedManifestLabel {
+			continue
+		}
+		metadata.Labels[key] = value
+	}
+
+	return metadata, body, nil
+}
@@ -3,12 +3,15 @@ package v2
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"regexp"
 )
 
 var (
 	ErrNoMorePages = errors.New("No more pages")
+	//ErrManifestUnknown is returned when the registry does not have a manifest for the requested reference
+	ErrManifestUnknown = errors.New("Manifest unknown")
 )
 
 func (registry *V2registry) getJson(url string, response interface{}) error {
@@ -45,6 +48,93 @@ func (registry *V2registry) getPaginatedJson(url string, response interface{}) (
 	return getNextLink(resp)
 }
 
+//Scopes of requests not made for a single repository, whose tokens are cached per registry under scopeKey
+const (
+	catalogScope = "registry:catalog:*"
+	baseScope    = "registry:base"
+)
+
+//scopeKey returns the token cache key of a scope of the registry
+func (registry *V2registry) scopeKey(scope string) string {
+	return registry.Hostname + "/" + scope
+}
+
+//authorize adds a token for the given repository to a request, or the registry's credentials if the registry does not
+//hand out tokens
+func (registry *V2registry) authorize(req *http.Request, repository string) error {
+	token, err := registry.GetOrCreateToken(repository, req.URL.String())
+	switch {
+	case err == nil && token.Token != "":
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token.Token))
+	case registry.Username != "" || registry.Password != "":
+		req.SetBasicAuth(registry.Username, registry.Password)
+	case err != nil:
+		return err
+	}
+	return nil
+}
+
+//statusError describes an unsuccessful response, including the message of the first v2 API error in its body
+func statusError(resp *http.Response) error {
+	var body struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if json.NewDecoder(resp.Body).Decode(&body) == nil && len(body.Errors) > 0 {
+		return fmt.Errorf("http: non-successful response (status=%v): %s", resp.StatusCode, body.Errors[0].Message)
+	}
+	return fmt.Errorf("http: non-successful response (status=%v)", resp.StatusCode)
+}
+
+//getAuthorizedPaginatedJson is getPaginatedJson for endpoints needing a token for the given repository or scope
+func (registry *V2registry) getAuthorizedPaginatedJson(repository, url string, response interface{}) (string, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	if err = registry.authorize(req, repository); err != nil {
+		return "", err
+	}
+
+	resp, err := registry.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", statusError(resp)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	err = decoder.Decode(response)
+	if err != nil {
+		return "", err
+	}
+	return getNextLink(resp)
+}
+
+//CheckAuth checks the registry's credentials against the /v2/ base endpoint
+func (registry *V2registry) CheckAuth() error {
+	req, err := http.NewRequest("GET", registry.url("/v2/"), nil)
+	if err != nil {
+		return err
+	}
+	if err = registry.authorize(req, registry.scopeKey(baseScope)); err != nil {
+		return err
+	}
+
+	resp, err := registry.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp)
+	}
+	return nil
+}
+
 // Matches an RFC 5988 (https://tools.ietf.org/html/rfc5988#section-5)
 // Link header. For example,
 //
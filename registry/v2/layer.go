@@ -34,7 +34,8 @@ func (registry *V2registry) DownloadLayer(repository string, digest digest.Diges
 	return resp.Body, nil
 }
 
-func (registry *V2registry) UploadLayer(repository string, digest digest.Digest, content io.Reader) error {
+//UploadLayer pushes a blob of the given size in a single request
+func (registry *V2registry) UploadLayer(repository string, digest digest.Digest, content io.Reader, size int64) error {
 	uploadUrl, err := registry.initiateUpload(repository)
 	if err != nil {
 		return err
@@ -49,17 +50,36 @@ func (registry *V2registry) UploadLayer(repository string, digest digest.Digest,
 	if err != nil {
 		return err
 	}
+	if err = registry.authorizePush(upload, repository); err != nil {
+		return err
+	}
 	upload.Header.Set("Content-Type", "application/octet-stream")
+	upload.ContentLength = size
 
-	_, err = registry.Client.Do(upload)
-	return err
+	resp, err := registry.Client.Do(upload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("http: unable to upload blob %s (status=%v)", digest, resp.StatusCode)
+	}
+	return nil
 }
 
 func (registry *V2registry) HasLayer(repository string, digest digest.Digest) (bool, error) {
 	checkUrl := registry.url("/v2/%s/blobs/%s", repository, digest)
 	// registry.Logf("registry.layer.check url=%s repository=%s digest=%s", checkUrl, repository, digest)
 
-	resp, err := registry.Client.Head(checkUrl)
+	req, err := http.NewRequest("HEAD", checkUrl, nil)
+	if err != nil {
+		return false, err
+	}
+	if err = registry.authorizePush(req, repository); err != nil {
+		return false, err
+	}
+
+	resp, err := registry.Client.Do(req)
 	if resp != nil {
 		defer resp.Body.Close()
 	}
@@ -104,18 +124,33 @@ func (registry *V2registry) initiateUpload(repository string) (*url.URL, error)
 	initiateUrl := registry.url("/v2/%s/blobs/uploads/", repository)
 	// registry.Logf("registry.layer.initiate-upload url=%s repository=%s", initiateUrl, repository)
 
-	resp, err := registry.Client.Post(initiateUrl, "application/octet-stream", nil)
+	req, err := http.NewRequest("POST", initiateUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err = registry.authorizePush(req, repository); err != nil {
+		return nil, err
+	}
+
+	resp, err := registry.Client.Do(req)
 	if resp != nil {
 		defer resp.Body.Close()
 	}
 	if err != nil {
 		return nil, err
 	}
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, fmt.Errorf("http: unable to start upload to %s (status=%v)", repository, resp.StatusCode)
+	}
 
-	location := resp.Header.Get("Location")
-	locationUrl, err := url.Parse(location)
+	// the upload location may be relative to the registry
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return nil, err
+	}
+	base, err := url.Parse(initiateUrl)
 	if err != nil {
 		return nil, err
 	}
-	return locationUrl, nil
+	return base.ResolveReference(location), nil
 }
@@ -85,13 +85,56 @@ func (registry *V2registry) ManifestDigest(repository, reference string) (digest
 	url := registry.url("/v2/%s/manifests/%s", repository, reference)
 	// registry.Logf("registry.manifest.head url=%s repository=%s reference=%s", url, repository, reference)
 
-	resp, err := registry.Client.Head(url)
+	req, err := http.NewRequest("HEAD", url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := registry.GetOrCreateToken(repository, url)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token.Token))
+	req.Header.Set("Accept", manifestV2.MediaTypeManifest)
+
+	resp, err := registry.Client.Do(req)
 	if resp != nil {
 		defer resp.Body.Close()
 	}
 	if err != nil {
 		return "", err
 	}
+	if resp.StatusCode == http.StatusNotFound {
+		return "", ErrManifestUnknown
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("http: non-successful response (status=%v)", resp.StatusCode)
+	}
+
+	// some registries do not return the digest header on HEAD requests; compute it from the manifest instead
+	if resp.Header.Get("Docker-Content-Digest") == "" {
+		req.Method = "GET"
+		getResp, err := registry.Client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer getResp.Body.Close()
+		if getResp.StatusCode == http.StatusNotFound {
+			return "", ErrManifestUnknown
+		}
+		if getResp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("http: non-successful response (status=%v)", getResp.StatusCode)
+		}
+		if d := getResp.Header.Get("Docker-Content-Digest"); d != "" {
+			return digest.ParseDigest(d)
+		}
+		body, err := ioutil.ReadAll(getResp.Body)
+		if err != nil {
+			return "", err
+		}
+		return digest.FromBytes(body), nil
+	}
+
 	return digest.ParseDigest(resp.Header.Get("Docker-Content-Digest"))
 }
 
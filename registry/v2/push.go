@@ -0,0 +1,109 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+//authorizePush adds credentials allowing blobs and manifests to be pushed to a repository. Registries using token
+//auth get a token scoped to pull and push the repository, cached per registry; other registries get basic auth.
+func (registry *V2registry) authorizePush(req *http.Request, repository string) error {
+	key := "push:" + registry.Hostname + "/" + repository
+	if Authtokenmap().Check(key) {
+		token, err := Authtokenmap().Get(key)
+		if err == nil && !token.IsExpired() {
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token.Token))
+			return nil
+		}
+	}
+
+	resp, err := registry.Client.Get(registry.url("/v2/"))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		return nil
+	}
+
+	for _, challenge := range parseAuthHeader(resp.Header) {
+		if challenge.Scheme == "basic" {
+			req.SetBasicAuth(registry.Username, registry.Password)
+			return nil
+		}
+		if challenge.Scheme != "bearer" {
+			continue
+		}
+
+		realm, err := url.Parse(challenge.Parameters["realm"])
+		if err != nil {
+			return err
+		}
+		q := realm.Query()
+		q.Set("service", challenge.Parameters["service"])
+		q.Set("scope", fmt.Sprintf("repository:%s:pull,push", repository))
+		realm.RawQuery = q.Encode()
+
+		authRequest, err := http.NewRequest("GET", realm.String(), nil)
+		if err != nil {
+			return err
+		}
+		if registry.Username != "" || registry.Password != "" {
+			authRequest.SetBasicAuth(registry.Username, registry.Password)
+		}
+		authResponse, err := registry.Client.Do(authRequest)
+		if err != nil {
+			return err
+		}
+		defer authResponse.Body.Close()
+		if authResponse.StatusCode != http.StatusOK {
+			return fmt.Errorf("Unable to get a push token for %s (status=%v)", repository, authResponse.StatusCode)
+		}
+
+		token := APIToken{}
+		if err = json.NewDecoder(authResponse.Body).Decode(&token); err != nil {
+			return err
+		}
+		token.Created = time.Now()
+		if token.ExpiresIn == 0 {
+			token.ExpiresIn = 60
+		}
+		token.Expires = token.Created.Add(time.Second * time.Duration(token.ExpiresIn))
+		Authtokenmap().Set(key, token)
+
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token.Token))
+		return nil
+	}
+
+	return fmt.Errorf("Registry %s does not support a known authentication scheme", registry.Hostname)
+}
+
+//PutManifestV2 pushes a manifest exactly as given, so that its digest is unchanged
+func (registry *V2registry) PutManifestV2(repository, reference, mediaType string, payload []byte) error {
+	url := registry.url("/v2/%s/manifests/%s", repository, reference)
+
+	req, err := http.NewRequest("PUT", url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	if err = registry.authorizePush(req, repository); err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mediaType)
+
+	resp, err := registry.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("http: unable to push manifest for %s (status=%v): %s", repository, resp.StatusCode, body)
+	}
+	return nil
+}
@@ -1,22 +1,27 @@
 package v2
 
 import (
-	"errors"
 	"fmt"
 	"github.com/ngageoint/seed-common/objects"
 	"github.com/ngageoint/seed-common/util"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 )
 
 type V2registry struct {
-	Hostname string
-	Org      string
-	Username string
-	Password string
-	Print    util.PrintCallback
-	Client   *http.Client
+	Hostname       string
+	Org            string
+	Username       string
+	Password       string
+	RepositoryList RepositoryList //repositories scanned instead of the catalog, if set
+	Orgs           OrgList        //namespaces scanned instead of only Org, if set
+	Print          util.PrintCallback
+	Client         *http.Client
+
+	scanned   map[string]ImageMetadata //metadata read along with seed manifests, by repository:reference
+	scannedMu sync.Mutex
 }
 
 func New(url, org, username, password string) (*V2registry, error) {
@@ -39,12 +44,33 @@ func (v2 *V2registry) Name() string {
 	return "V2"
 }
 
+//V2Base returns the registry used to access images through the docker v2 API
+func (v2 *V2registry) V2Base() *V2registry {
+	return v2
+}
+
+//RepositoryPath returns the v2 repository path for an image in an org. Catalog names already include their
+//namespace, so the org is only prefixed to names without it.
+func (v2 *V2registry) RepositoryPath(org, repoName string) string {
+	if org == "" || strings.HasPrefix(repoName, org+"/") {
+		return repoName
+	}
+	return org + "/" + repoName
+}
+
 // func (v2 *V2registry) GetAuthToken() authToken {
 // 	return authtokens[v2.Org]
 // }
 
+//Ping lists the catalog, falling back to checking the credentials against /v2/ for registries that forbid catalog
+//listing
 func (v2 *V2registry) Ping() error {
-	_, err := v2.Repositories()
+	_, err := v2.catalog()
+	if err != nil {
+		if authErr := v2.CheckAuth(); authErr == nil {
+			return nil
+		}
+	}
 	return err
 }
 
@@ -52,8 +78,20 @@ func (v2 *V2registry) Ping() error {
 // 	return v2.Repositories()
 // }
 
-func (v2 *V2registry) Tags(repository string) ([]string, error) {
-	return v2.Tags(repository)
+//SetOrgs makes the registry scan the repositories of several namespaces
+func (v2 *V2registry) SetOrgs(orgs []string) {
+	v2.Orgs = orgs
+}
+
+//orgs returns the namespaces the registry scans: its list of orgs if it has one, otherwise its org
+func (v2 *V2registry) orgs() OrgList {
+	if len(v2.Orgs) > 0 {
+		return v2.Orgs
+	}
+	if v2.Org != "" {
+		return OrgList{v2.Org}
+	}
+	return nil
 }
 
 func (v2 *V2registry) url(pathTemplate string, args ...interface{}) string {
@@ -69,7 +107,7 @@ func (v2 *V2registry) Images() ([]string, error) {
 
 	var images []string
 	for _, repo := range repositories {
-		if !strings.HasSuffix(repo, "-seed") {
+		if !v2.RepositoryList.Selects(repo) || !v2.orgs().Selects(Namespace(repo)) {
 			continue
 		}
 		tags, err := v2.Tags(repo)
@@ -87,8 +125,8 @@ func (v2 *V2registry) Images() ([]string, error) {
 
 func (v2 *V2registry) ImagesWithManifests() ([]objects.Image, error) {
 	imageNames, err := v2.Images()
-	v2.Print("Images found in V2 Registry %s with Org %s: \n %v", v2.Hostname, v2.Org, imageNames)
-	v2.Print("Getting Manifests for %d images in V2 Registry %s with Org %s", len(imageNames), v2.Hostname, v2.Org)
+	v2.Print("Images found in V2 Registry %s with Orgs %v: \n %v", v2.Hostname, v2.orgs(), imageNames)
+	v2.Print("Getting Manifests for %d images in V2 Registry %s with Orgs %v", len(imageNames), v2.Hostname, v2.orgs())
 
 	if err != nil {
 		return nil, err
@@ -110,7 +148,7 @@ func (v2 *V2registry) ImagesWithManifests() ([]objects.Image, error) {
 			continue
 		}
 
-		imageStruct := objects.Image{Name: imgstr, Registry: v2.Hostname, Org: v2.Org, Manifest: manifest}
+		imageStruct := objects.Image{Name: imgstr, Registry: v2.Hostname, Org: Namespace(temp[0]), Manifest: manifest}
 		images = append(images, imageStruct)
 	}
 
@@ -118,18 +156,5 @@ func (v2 *V2registry) ImagesWithManifests() ([]objects.Image, error) {
 }
 
 func (v2 *V2registry) GetImageManifest(repoName, tag string) (string, error) {
-	manifest := ""
-	mv2, err := v2.ManifestV2(repoName, tag)
-	if err == nil {
-		resp, err := v2.DownloadLayer(repoName, mv2.Config.Digest)
-		if err == nil {
-			manifest, err = objects.GetSeedManifestFromBlob(resp)
-		}
-	}
-
-	if err == nil && manifest == "" {
-		err = errors.New("Empty seed manifest!")
-	}
-
-	return manifest, err
+	return v2.SeedManifest(repoName, tag)
 }
@@ -1,6 +1,7 @@
 package v2
 
 import (
+	"fmt"
 	"strings"
 )
 
@@ -16,14 +17,48 @@ type Result struct {
 	Name string
 }
 
+type tagsResponse struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+//Repositories lists the catalog, or the repositories of the registry's repository list if it has one. Glob patterns of
+//the list are matched against the catalog.
 func (registry *V2registry) Repositories() ([]string, error) {
+	if len(registry.RepositoryList) == 0 {
+		return registry.catalog()
+	}
+
+	repos := registry.RepositoryList.Names()
+	if !registry.RepositoryList.HasPatterns() {
+		return repos, nil
+	}
+	catalog, err := registry.catalog()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to match repository patterns against the catalog: %s", err.Error())
+	}
+	listed := map[string]bool{}
+	for _, repo := range repos {
+		listed[repo] = true
+	}
+	for _, repo := range catalog {
+		if !listed[repo] && registry.RepositoryList.Selects(repo) {
+			repos = append(repos, repo)
+		}
+	}
+	return repos, nil
+}
+
+//catalog lists every repository of the registry
+func (registry *V2registry) catalog() ([]string, error) {
 	url := registry.url("/v2/_catalog")
 	repos := make([]string, 0, 10)
 	var err error //We create this here, otherwise url will be rescoped with :=
 	var response repositoriesResponse
 	for {
 		// registry.Logf("registry.repositories url=%s", url)
-		url, err = registry.getPaginatedJson(url, &response)
+		response.Repositories = nil
+		url, err = registry.getAuthorizedPaginatedJson(registry.scopeKey(catalogScope), url, &response)
 		if !strings.HasPrefix(url, "http") {
 			url = registry.Hostname + url
 		}
@@ -40,6 +75,31 @@ func (registry *V2registry) Repositories() ([]string, error) {
 	}
 }
 
+//Tags lists the tags of a repository
+func (registry *V2registry) Tags(repository string) ([]string, error) {
+	url := registry.url("/v2/%s/tags/list", repository)
+	tags := make([]string, 0, 10)
+	var err error
+	var response tagsResponse
+	for {
+		response.Tags = nil
+		url, err = registry.getAuthorizedPaginatedJson(repository, url, &response)
+		if !strings.HasPrefix(url, "http") {
+			url = registry.Hostname + url
+		}
+		switch err {
+		case ErrNoMorePages:
+			tags = append(tags, response.Tags...)
+			return tags, nil
+		case nil:
+			tags = append(tags, response.Tags...)
+			continue
+		default:
+			return nil, err
+		}
+	}
+}
+
 func (registry *V2registry) UserRepositories(user string) ([]string, error) {
 	url := registry.url("/v2/repositories/%s/", user)
 	repos := make([]string, 0, 10)
@@ -0,0 +1,101 @@
+package v2
+
+import (
+	"path"
+	"strings"
+)
+
+//RepositoryList names the repositories of a registry to scan instead of listing its catalog. Entries are repository
+//names or glob patterns as matched by path.Match, so * does not match across a /.
+type RepositoryList []string
+
+//isPattern reports whether a list entry is a glob pattern rather than a repository name
+func isPattern(entry string) bool {
+	return strings.ContainsAny(entry, "*?[")
+}
+
+//Validate checks the glob patterns of the list
+func (l RepositoryList) Validate() error {
+	for _, entry := range l {
+		if _, err := path.Match(entry, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Names returns the repository names of the list, which are scanned without listing the catalog
+func (l RepositoryList) Names() []string {
+	names := []string{}
+	for _, entry := range l {
+		if !isPattern(entry) {
+			names = append(names, entry)
+		}
+	}
+	return names
+}
+
+//HasPatterns reports whether the list has glob patterns, which are matched against the listed catalog
+func (l RepositoryList) HasPatterns() bool {
+	for _, entry := range l {
+		if isPattern(entry) {
+			return true
+		}
+	}
+	return false
+}
+
+//Selects reports whether a repository is scanned. Without a list every seed repository, named with a -seed suffix, is
+//scanned; with a list only the repositories it names or matches are.
+func (l RepositoryList) Selects(repository string) bool {
+	if len(l) == 0 {
+		return strings.HasSuffix(repository, "-seed")
+	}
+	for _, entry := range l {
+		if entry == repository {
+			return true
+		}
+		if matched, _ := path.Match(entry, repository); matched {
+			return true
+		}
+	}
+	return false
+}
+
+//OrgList names the orgs or namespaces whose repositories a registry scans. Entries are namespaces, which include their
+//nested namespaces, or glob patterns as matched by path.Match.
+type OrgList []string
+
+//Validate checks the glob patterns of the list
+func (l OrgList) Validate() error {
+	return RepositoryList(l).Validate()
+}
+
+//HasPatterns reports whether the list has glob patterns
+func (l OrgList) HasPatterns() bool {
+	return RepositoryList(l).HasPatterns()
+}
+
+//Selects reports whether repositories in a namespace are scanned. Without a list every namespace is.
+func (l OrgList) Selects(namespace string) bool {
+	if len(l) == 0 {
+		return true
+	}
+	for _, entry := range l {
+		if namespace == entry || strings.HasPrefix(namespace, entry+"/") {
+			return true
+		}
+		if matched, _ := path.Match(entry, namespace); matched {
+			return true
+		}
+	}
+	return false
+}
+
+//Namespace returns the namespace of a repository, the path before its name
+func Namespace(repository string) string {
+	if i := strings.LastIndex(repository, "/"); i >= 0 {
+		return repository[:i]
+	}
+	return ""
+}
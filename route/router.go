@@ -18,17 +18,50 @@ var handler = map[string]http.HandlerFunc{
 	"ScanRegistries": handlers.Validate([]string{"admin"}, handlers.ScanRegistries),
 	"Registry": handlers.Registry,
 	"ScanRegistry": handlers.Validate([]string{"admin"}, handlers.ScanRegistry),
+	"RegistryStatus": handlers.RegistryStatus,
 	"ListImages": handlers.ListImages,
 	"SearchImages": handlers.SearchImages,
 	"SearchJobs": handlers.SearchJobs,
 	"Image": handlers.Image,
 	"ImageManifest": handlers.ImageManifest,
 	"JITImageManifest": handlers.JITImageManifest,
+	"RegisterImage": handlers.Validate([]string{"admin"}, handlers.RegisterImage),
+	"ExportImageScale": handlers.ExportImageScale,
+	"ExportImageCwl": handlers.ExportImageCwl,
+	"ExportImageK8s": handlers.ExportImageK8s,
+	"ImageRunCommand": handlers.ImageRunCommand,
+	"ImageTagHistory": handlers.ImageTagHistory,
+	"ImageMirrors": handlers.ImageMirrors,
+	"PromoteImage": handlers.Validate([]string{"admin"}, handlers.PromoteImage),
+	"ListOperations": handlers.ListOperations,
+	"Operation": handlers.Operation,
 	"ListJobs": handlers.ListJobs,
+	"JobConflicts": handlers.JobConflicts,
 	"Job": handlers.Job,
 	"JobVersions": handlers.JobVersions,
+	"JobDownstream": handlers.JobDownstream,
+	"JobUpstream": handlers.JobUpstream,
+	"JobPolicy": handlers.JobPolicy,
+	"ValidatePipeline": handlers.ValidatePipeline,
 	"ListJobVersions": handlers.ListJobVersions,
+	"DiffJobVersions": handlers.DiffJobVersions,
 	"JobVersion": handlers.JobVersion,
+	"ExportJobVersionScale": handlers.ExportJobVersionScale,
+	"JobVersionInputSchema": handlers.JobVersionInputSchema,
+	"ValidateJobVersionInputs": handlers.ValidateJobVersionInputs,
+	"Lint": handlers.Lint,
+	"DiffImages": handlers.DiffImages,
+	"Resolve": handlers.Resolve,
+	"Lock": handlers.Lock,
+	"VerifyLock": handlers.VerifyLock,
+	"CreateBundle": handlers.Validate([]string{"admin"}, handlers.CreateBundle),
+	"ImportBundle": handlers.Validate([]string{"admin"}, handlers.ImportBundle),
+	"ExportCatalog": handlers.Validate([]string{"admin"}, handlers.ExportCatalog),
+	"ImportCatalog": handlers.Validate([]string{"admin"}, handlers.ImportCatalog),
+	"NodeProfile": handlers.NodeProfile,
+	"AddNodeProfile": handlers.Validate([]string{"admin"}, handlers.AddNodeProfile),
+	"DeleteNodeProfile": handlers.Validate([]string{"admin"}, handlers.DeleteNodeProfile),
+	"ListNodeProfiles": handlers.ListNodeProfiles,
 	"Login": handlers.Login,
 	"User": handlers.User,
 	"AddUser": handlers.Validate([]string{"admin"}, handlers.AddUser),
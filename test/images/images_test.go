@@ -19,6 +19,7 @@ import (
 	"github.com/ngageoint/seed-common/util"
 	"github.com/ngageoint/seed-silo/models"
 	"github.com/ngageoint/seed-silo/database"
+	"github.com/ngageoint/seed-silo/export"
 	"github.com/ngageoint/seed-silo/route"
 )
 
@@ -105,6 +106,10 @@ func TestSearchImages(t *testing.T) {
 		Maintainer: "John Doe", Email: "jdoe@example.com", MaintOrg: "E-corp",
 		Description: "Reads an HDF5 file and outputs two TIFF images, a CSV and manifest containing cell_count",
 		JobVersion:  "0.1.0", PackageVersion: "0.1.0"}
+	if !strings.HasPrefix(m[0].Digest, "sha256:") || m[0].Size <= 0 {
+		t.Errorf("Expected image to have a digest and size. Got '%v'", m[0])
+	}
+	testImage.Digest, testImage.Size, testImage.Created, testImage.Platform = m[0].Digest, m[0].Size, m[0].Created, m[0].Platform
 	if fmt.Sprint(m[0]) != fmt.Sprint(testImage) {
 		t.Errorf("Expected image to be %v. Got '%v'", testImage, m[0])
 	}
@@ -160,6 +165,254 @@ func TestJITImageManifest(t *testing.T) {
 	}
 }
 
+func TestExportImageScale(t *testing.T) {
+	url := fmt.Sprintf("/images/%d/export/scale", imageID)
+	req, _ := http.NewRequest("GET", url, nil)
+	response := executeRequest(req)
+
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	m := export.ScaleJobTypeV6{}
+	json.Unmarshal(response.Body.Bytes(), &m)
+
+	if m.DockerImage != "docker.io/geointseed/my-job-0.1.0-seed:0.1.0" {
+		t.Errorf("Unexpected docker image %s", m.DockerImage)
+	}
+	if _, ok := m.Configuration.Mounts["MOUNT_PATH"]; !ok {
+		t.Errorf("Expected MOUNT_PATH mount. Got %v", m.Configuration.Mounts)
+	}
+	if _, ok := m.Configuration.Settings["DB_HOST"]; !ok {
+		t.Errorf("Expected DB_HOST setting. Got %v", m.Configuration.Settings)
+	}
+	if m.Manifest == nil {
+		t.Errorf("Expected seed manifest in job type")
+	}
+
+	req, _ = http.NewRequest("GET", url+"?apiVersion=v1", nil)
+	response = executeRequest(req)
+
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+	req, _ = http.NewRequest("GET", "/images/99999/export/scale", nil)
+	response = executeRequest(req)
+
+	checkResponseCode(t, http.StatusNotFound, response.Code)
+}
+
+func TestExportImageCwl(t *testing.T) {
+	url := fmt.Sprintf("/images/%d/export/cwl", imageID)
+	req, _ := http.NewRequest("GET", url, nil)
+	response := executeRequest(req)
+
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	m := map[string]interface{}{}
+	json.Unmarshal(response.Body.Bytes(), &m)
+
+	doc, _ := m["Document"].(map[string]interface{})
+	if doc["cwlVersion"] != "v1.2" || doc["class"] != "CommandLineTool" || doc["id"] != "my-job" {
+		t.Errorf("Unexpected CWL document %v", doc)
+	}
+	args, _ := doc["arguments"].([]interface{})
+	if len(args) != 1 || !strings.Contains(fmt.Sprint(args[0]), "$(inputs.INPUT_FILE.path) $(runtime.outdir)") {
+		t.Errorf("Unexpected CWL arguments %v", args)
+	}
+
+	// the test manifest has a mount and a disk input multiplier
+	warnings, _ := m["Warnings"].([]interface{})
+	if !strings.Contains(fmt.Sprint(warnings), "MOUNT_PATH") || !strings.Contains(fmt.Sprint(warnings), "disk") {
+		t.Errorf("Expected mount and disk warnings. Got %v", warnings)
+	}
+}
+
+func TestCwlEnvNames(t *testing.T) {
+	//seed passes inputs and settings as upper case variables whatever their names
+	seed := objects.Seed{SeedVersion: "1.0.0"}
+	seed.Job.Name = "my-job"
+	seed.Job.Interface.Command = "run ${INPUT_FILE} ${THRESHOLD_VALUE} ${API_KEY} ${OUTPUT_DIR}"
+	seed.Job.Interface.Inputs.Files = []objects.InFile{{Name: "input-file", Required: true}}
+	seed.Job.Interface.Inputs.Json = []objects.InJson{{Name: "threshold_value", Type: "number", Required: true}}
+	seed.Job.Interface.Settings = []objects.Setting{{Name: "api_key"}}
+	result := export.Cwl(export.Source{Reference: "my-job-1.0.0-seed:1.0.0", Seed: seed})
+
+	args := fmt.Sprint(result.Document.Arguments)
+	expected := "run $(inputs['input-file'].path) $(inputs.threshold_value) $(inputs.api_key) $(runtime.outdir)"
+	if !strings.Contains(args, expected) {
+		t.Errorf("Expected CWL arguments with %s. Got %v", expected, args)
+	}
+	doc, _ := json.Marshal(result.Document)
+	for _, envName := range []string{"INPUT_FILE", "THRESHOLD_VALUE", "API_KEY", "OUTPUT_DIR"} {
+		if !strings.Contains(string(doc), `"envName":"`+envName+`"`) {
+			t.Errorf("Expected environment variable %s in CWL document %s", envName, doc)
+		}
+	}
+}
+
+func TestExportImageK8s(t *testing.T) {
+	url := fmt.Sprintf("/images/%d/export/k8s?namespace=seed&storageClass=standard&imagePullSecret=pull", imageID)
+	req, _ := http.NewRequest("GET", url, nil)
+	response := executeRequest(req)
+
+	checkResponseCode(t, http.StatusOK, response.Code)
+	if contentType := response.Header().Get("Content-Type"); contentType != "application/x-yaml" {
+		t.Errorf("Expected content type application/x-yaml. Got %s", contentType)
+	}
+
+	body := response.Body.String()
+	expected := []string{"apiVersion: batch/v1", "kind: Job", "namespace: seed", "storageClassName: standard",
+		"- name: pull", "geointseed/my-job-0.1.0-seed:0.1.0", "cpu: \"1\"", "memory: \"10240Mi\"",
+		"mountPath: /the/container/path", "claimName: my-job-0-1-0-mount-mount-path", "name: DB_HOST", "args:"}
+	for _, e := range expected {
+		if !strings.Contains(body, e) {
+			t.Errorf("Expected %s in kubernetes export. Got %s", e, body)
+		}
+	}
+}
+
+func TestImageRunCommand(t *testing.T) {
+	url := fmt.Sprintf("/images/%d/run-command?input.INPUT_FILE=/data/cells.h5", imageID)
+	req, _ := http.NewRequest("GET", url, nil)
+	response := executeRequest(req)
+
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	var m map[string]interface{}
+	json.Unmarshal(response.Body.Bytes(), &m)
+
+	seedRun, _ := m["SeedRun"].(string)
+	if !strings.HasPrefix(seedRun, "seed run") || !strings.Contains(seedRun, "-i INPUT_FILE=/data/cells.h5") ||
+		!strings.Contains(seedRun, "-m MOUNT_PATH=<MOUNT_PATH>") {
+		t.Errorf("Unexpected seed run command %s", seedRun)
+	}
+	dockerRun, _ := m["DockerRun"].(string)
+	if !strings.Contains(dockerRun, "-v /data/cells.h5:/seed/input/INPUT_FILE:ro") ||
+		!strings.HasSuffix(dockerRun, "/seed/input/INPUT_FILE /seed/output") {
+		t.Errorf("Unexpected docker run command %s", dockerRun)
+	}
+	placeholders, _ := m["Placeholders"].([]interface{})
+	if strings.Contains(fmt.Sprint(placeholders), "INPUT_FILE") || !strings.Contains(fmt.Sprint(placeholders), "DB_HOST") {
+		t.Errorf("Unexpected placeholders %v", placeholders)
+	}
+}
+
+func TestDiffImages(t *testing.T) {
+	url := fmt.Sprintf("/diff?from=%d&to=%d", imageID, imageID)
+	req, _ := http.NewRequest("GET", url, nil)
+	response := executeRequest(req)
+
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	diff := models.ManifestDiff{}
+	json.Unmarshal(response.Body.Bytes(), &diff)
+	if diff.Breaking || len(diff.Changes) != 0 || diff.From.Job != "my-job" {
+		t.Errorf("Expected no changes. Got %v", diff)
+	}
+
+	req, _ = http.NewRequest("GET", url+"&format=text", nil)
+	response = executeRequest(req)
+
+	checkResponseCode(t, http.StatusOK, response.Code)
+	if !strings.HasPrefix(response.Body.String(), "--- my-job 0.1.0 (package 0.1.0)\n+++ my-job") {
+		t.Errorf("Unexpected text diff %s", response.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "/diff?from=1", nil)
+	response = executeRequest(req)
+
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+func TestDiffManifests(t *testing.T) {
+	base := func() objects.Seed {
+		seed := objects.Seed{}
+		seed.Job.Name = "my-job"
+		seed.Job.Timeout = 60
+		seed.Job.Interface.Command = "run ${INPUT_FILE}"
+		seed.Job.Interface.Inputs.Files = []objects.InFile{{Name: "INPUT_FILE", MediaTypes: []string{"image/tiff"},
+			Required: true}}
+		seed.Job.Interface.Inputs.Json = []objects.InJson{{Name: "THRESHOLD", Type: "number"}}
+		seed.Job.Interface.Outputs.Files = []objects.OutFile{{Name: "OUTPUT_TIFF", MediaType: "image/tiff",
+			Pattern: "*.tif", Required: true}}
+		seed.Job.Interface.Outputs.JSON = []objects.OutJson{{Name: "CELL_COUNT", Type: "integer"}}
+		seed.Job.Resources.Scalar = []objects.Scalar{{Name: "cpus", Value: 1}}
+		return seed
+	}
+
+	cases := []struct {
+		name     string
+		change   func(seed *objects.Seed)
+		expected []models.ManifestChange
+	}{
+		{"unchanged", func(seed *objects.Seed) {}, []models.ManifestChange{}},
+		{"optional input added", func(seed *objects.Seed) {
+			seed.Job.Interface.Inputs.Files = append(seed.Job.Interface.Inputs.Files, objects.InFile{Name: "MASK"})
+		}, []models.ManifestChange{{Kind: models.ChangeAdded, Section: models.SectionInput, Name: "MASK"}}},
+		{"required input added", func(seed *objects.Seed) {
+			seed.Job.Interface.Inputs.Json = append(seed.Job.Interface.Inputs.Json,
+				objects.InJson{Name: "MODE", Type: "string", Required: true})
+		}, []models.ManifestChange{{Kind: models.ChangeAdded, Section: models.SectionJsonInput, Name: "MODE",
+			Breaking: true}}},
+		{"input removed", func(seed *objects.Seed) {
+			seed.Job.Interface.Inputs.Files = nil
+		}, []models.ManifestChange{{Kind: models.ChangeRemoved, Section: models.SectionInput, Name: "INPUT_FILE",
+			Breaking: true}}},
+		{"input media types widened", func(seed *objects.Seed) {
+			seed.Job.Interface.Inputs.Files[0].MediaTypes = []string{"image/tiff", "image/png"}
+		}, []models.ManifestChange{{Kind: models.ChangeChanged, Section: models.SectionInput, Name: "INPUT_FILE",
+			Field: "mediaTypes", From: "image/tiff", To: "image/tiff, image/png"}}},
+		{"input media types narrowed", func(seed *objects.Seed) {
+			seed.Job.Interface.Inputs.Files[0].MediaTypes = []string{"image/png"}
+		}, []models.ManifestChange{{Kind: models.ChangeChanged, Section: models.SectionInput, Name: "INPUT_FILE",
+			Field: "mediaTypes", From: "image/tiff", To: "image/png", Breaking: true}}},
+		{"json input type changed", func(seed *objects.Seed) {
+			seed.Job.Interface.Inputs.Json[0].Type = "string"
+		}, []models.ManifestChange{{Kind: models.ChangeChanged, Section: models.SectionJsonInput, Name: "THRESHOLD",
+			Field: "type", From: "number", To: "string", Breaking: true}}},
+		{"output added", func(seed *objects.Seed) {
+			seed.Job.Interface.Outputs.Files = append(seed.Job.Interface.Outputs.Files,
+				objects.OutFile{Name: "LOG", MediaType: "text/plain"})
+		}, []models.ManifestChange{{Kind: models.ChangeAdded, Section: models.SectionOutput, Name: "LOG"}}},
+		{"output removed", func(seed *objects.Seed) {
+			seed.Job.Interface.Outputs.JSON = nil
+		}, []models.ManifestChange{{Kind: models.ChangeRemoved, Section: models.SectionJsonOutput, Name: "CELL_COUNT",
+			Breaking: true}}},
+		{"output made optional", func(seed *objects.Seed) {
+			seed.Job.Interface.Outputs.Files[0].Required = false
+		}, []models.ManifestChange{{Kind: models.ChangeChanged, Section: models.SectionOutput, Name: "OUTPUT_TIFF",
+			Field: "required", From: "true", To: "false", Breaking: true}}},
+		{"output pattern changed", func(seed *objects.Seed) {
+			seed.Job.Interface.Outputs.Files[0].Pattern = "*.tiff"
+		}, []models.ManifestChange{{Kind: models.ChangeChanged, Section: models.SectionOutput, Name: "OUTPUT_TIFF",
+			Field: "pattern", From: "*.tif", To: "*.tiff"}}},
+		{"resource increased", func(seed *objects.Seed) {
+			seed.Job.Resources.Scalar[0].Value = 2
+		}, []models.ManifestChange{{Kind: models.ChangeChanged, Section: models.SectionResource, Name: "cpus",
+			Field: "value", From: "1", To: "2", Breaking: true}}},
+		{"timeout shortened", func(seed *objects.Seed) {
+			seed.Job.Timeout = 30
+		}, []models.ManifestChange{{Kind: models.ChangeChanged, Section: models.SectionJob, Name: "my-job",
+			Field: "timeout", From: "60", To: "30", Breaking: true}}},
+	}
+
+	for _, c := range cases {
+		to := base()
+		c.change(&to)
+		changes := models.DiffManifests(base(), to)
+		if fmt.Sprint(changes) != fmt.Sprint(c.expected) {
+			t.Errorf("%s: DiffManifests returned %v, expected %v", c.name, changes, c.expected)
+		}
+
+		diff := models.NewManifestDiff(models.DiffSide{}, models.DiffSide{}, base(), to)
+		breaking := false
+		for _, e := range c.expected {
+			breaking = breaking || e.Breaking
+		}
+		if diff.Breaking != breaking {
+			t.Errorf("%s: Expected breaking to be %v. Got %v", c.name, breaking, diff.Breaking)
+		}
+	}
+}
+
 func TestListImages(t *testing.T) {
 	payload := []byte(``)
 	req, _ := http.NewRequest("GET", "/images", bytes.NewBuffer(payload))
@@ -168,6 +421,332 @@ func TestListImages(t *testing.T) {
 	checkResponseCode(t, http.StatusOK, response.Code)
 }
 
+func TestImageMetadata(t *testing.T) {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/images/%d", imageID), nil)
+	response := executeRequest(req)
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	img := models.Image{}
+	json.Unmarshal(response.Body.Bytes(), &img)
+	if !strings.HasPrefix(img.Digest, "sha256:") || !strings.HasPrefix(img.ConfigDigest, "sha256:") {
+		t.Errorf("Expected manifest and config digests. Got '%s' and '%s'", img.Digest, img.ConfigDigest)
+	}
+	if img.Size <= 0 || img.Created == "" || !strings.HasPrefix(img.Platform, "linux/") {
+		t.Errorf("Expected size, created time and platform. Got %d, '%s' and '%s'", img.Size, img.Created, img.Platform)
+	}
+	if _, ok := img.Labels["com.ngageoint.seed.manifest"]; ok {
+		t.Errorf("Expected the seed manifest label to be left out of the labels")
+	}
+
+	req, _ = http.NewRequest("GET", "/images?digest="+img.Digest, nil)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusOK, response.Code)
+	images := []models.SimpleImage{}
+	json.Unmarshal(response.Body.Bytes(), &images)
+	if len(images) == 0 || images[0].Digest != img.Digest {
+		t.Errorf("Expected images with digest %s. Got %v", img.Digest, images)
+	}
+
+	req, _ = http.NewRequest("GET", "/images?license=no-such-license", nil)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusOK, response.Code)
+	images = []models.SimpleImage{}
+	json.Unmarshal(response.Body.Bytes(), &images)
+	if len(images) != 0 {
+		t.Errorf("Expected no images with an unknown license. Got %d", len(images))
+	}
+
+	req, _ = http.NewRequest("GET", "/images?sort=newest", nil)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusOK, response.Code)
+	images = []models.SimpleImage{}
+	json.Unmarshal(response.Body.Bytes(), &images)
+	for i := 1; i < len(images); i++ {
+		if images[i].Created != "" && images[i].Created > images[i-1].Created {
+			t.Errorf("Expected images sorted newest first. Got %s before %s", images[i-1].Created, images[i].Created)
+		}
+	}
+
+	req, _ = http.NewRequest("GET", "/images?sort=bogus", nil)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+func TestImageTags(t *testing.T) {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/images/%d", imageID), nil)
+	response := executeRequest(req)
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	img := models.Image{}
+	json.Unmarshal(response.Body.Bytes(), &img)
+	if img.CanonicalId == 0 || len(img.Tags) == 0 {
+		t.Errorf("Expected a canonical image and tags. Got %d and %v", img.CanonicalId, img.Tags)
+	}
+	found := false
+	for _, tag := range img.Tags {
+		found = found || tag == "0.1.0"
+	}
+	if !found {
+		t.Errorf("Expected tags to include 0.1.0. Got %v", img.Tags)
+	}
+
+	// simulate the tag having pointed at another manifest in the previous scan
+	previous := img
+	previous.Digest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	models.RecordTagChanges(db, []models.Image{previous}, []models.Image{img})
+
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/images/%d/tag-history", imageID), nil)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	history := []models.TagChange{}
+	json.Unmarshal(response.Body.Bytes(), &history)
+	if len(history) != 1 || history[0].Tag != "0.1.0" || history[0].FromDigest != previous.Digest ||
+		history[0].ToDigest != img.Digest {
+		t.Errorf("Expected one change of tag 0.1.0 to %s. Got %v", img.Digest, history)
+	}
+
+	req, _ = http.NewRequest("GET", "/images?canonical=true", nil)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusOK, response.Code)
+	images := []models.SimpleImage{}
+	json.Unmarshal(response.Body.Bytes(), &images)
+	digests := map[string]bool{}
+	for _, image := range images {
+		repo, _ := models.SplitImageName(image.Name)
+		key := repo + "@" + image.Digest
+		if image.Digest != "" && digests[key] {
+			t.Errorf("Expected one canonical image for %s", key)
+		}
+		digests[key] = true
+	}
+}
+
+func TestCanonicalImages(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	other := "sha256:" + strings.Repeat("b", 64)
+	image := func(id int, name, digest string) models.SimpleImage {
+		return models.SimpleImage{ID: id, RegistryId: 1, Org: "seed", Name: "seed/my-job:" + name, Digest: digest}
+	}
+
+	// preferCanonical picks version tags over moving tags, then the image found first
+	cases := []struct {
+		name     string
+		images   []models.SimpleImage
+		expected []int
+	}{
+		{"version tag over latest", []models.SimpleImage{image(1, "latest", digest), image(2, "1.0.0", digest)}, []int{2}},
+		{"version tag found first", []models.SimpleImage{image(1, "1.0.0", digest), image(2, "latest", digest)}, []int{1}},
+		{"two version tags", []models.SimpleImage{image(2, "1.0.1", digest), image(1, "1.0.0", digest)}, []int{1}},
+		{"two moving tags", []models.SimpleImage{image(3, "stable", digest), image(2, "latest", digest)}, []int{2}},
+		{"different manifests", []models.SimpleImage{image(1, "1.0.0", digest), image(2, "latest", other)}, []int{1, 2}},
+		{"no digests", []models.SimpleImage{image(1, "1.0.0", ""), image(2, "latest", "")}, []int{1, 2}},
+		{"other registry", []models.SimpleImage{image(1, "1.0.0", digest),
+			{ID: 2, RegistryId: 2, Org: "seed", Name: "seed/my-job:1.0.0", Digest: digest}}, []int{1, 2}},
+		{"other repository", []models.SimpleImage{image(1, "1.0.0", digest),
+			{ID: 2, RegistryId: 1, Org: "seed", Name: "seed/other-job:1.0.0", Digest: digest}}, []int{1, 2}},
+	}
+
+	for _, c := range cases {
+		ids := []int{}
+		for _, img := range models.CanonicalImages(c.images) {
+			ids = append(ids, img.ID)
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(c.expected) {
+			t.Errorf("%s: Expected canonical images %v. Got %v", c.name, c.expected, ids)
+		}
+	}
+}
+
+func TestImageAliases(t *testing.T) {
+	dir, err := ioutil.TempDir("", "silo-aliases")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	adb, err := sql.Open("sqlite3", dir+"/silo.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer adb.Close()
+	models.CreateImageTable(adb, "sqlite")
+
+	digest := "sha256:" + strings.Repeat("a", 64)
+	image := func(tag, digest string) models.Image {
+		return models.Image{RegistryId: 1, Org: "seed", FullName: "seed/my-job:" + tag, Digest: digest}
+	}
+	models.StoreImages(adb, []models.Image{image("latest", digest), image("1.0.0", digest), image("stable", digest),
+		image("0.9.0", "sha256:"+strings.Repeat("b", 64)), image("dev", "")}, "sqlite")
+	images := models.ReadImages(adb)
+
+	cases := []struct {
+		image     models.Image
+		canonical int
+		tags      []string
+	}{
+		{images[0], images[1].ID, []string{"1.0.0", "latest", "stable"}},
+		{images[1], images[1].ID, []string{"1.0.0", "latest", "stable"}},
+		{images[3], images[3].ID, []string{"0.9.0"}},
+		{images[4], images[4].ID, []string{"dev"}},
+	}
+	for _, c := range cases {
+		canonical, tags := models.ImageAliases(adb, c.image)
+		if canonical != c.canonical || fmt.Sprint(tags) != fmt.Sprint(c.tags) {
+			t.Errorf("Expected %s to have canonical image %d and tags %v. Got %d and %v", c.image.FullName,
+				c.canonical, c.tags, canonical, tags)
+		}
+	}
+}
+
+func TestCollapseMirrors(t *testing.T) {
+	defer os.Unsetenv("SILO_REGISTRY_ORDER")
+	registries := []models.RegistryInfo{{ID: 1, Name: "hub", Url: "https://hub.example.com"},
+		{ID: 2, Name: "mirror", Url: "https://mirror.example.com"}}
+
+	digest := "sha256:" + strings.Repeat("a", 64)
+	image := func(id, registryId int, tag, digest string) models.SimpleImage {
+		return models.SimpleImage{ID: id, RegistryId: registryId, Org: "seed", Name: "seed/my-job:" + tag, Digest: digest}
+	}
+	// the mirror's latest tag is listed before its version tag pointing to the same manifest
+	images := []models.SimpleImage{
+		image(1, 1, "1.0.0", digest),
+		image(2, 2, "latest", digest),
+		image(3, 2, "1.0.0", digest),
+		image(4, 2, "0.9.0", "sha256:"+strings.Repeat("b", 64)),
+		image(5, 1, "dev", ""),
+		image(6, 2, "dev", ""),
+	}
+
+	// preferMirror ranks registries by SILO_REGISTRY_ORDER, then the registry added first, then the canonical tag
+	cases := []struct {
+		order    string
+		expected string //hits with the image ids of their mirrors, preferred first
+	}{
+		{"", "[1:[1 3] 4:[4] 5:[5] 6:[6]]"},
+		{"hub", "[1:[1 3] 4:[4] 5:[5] 6:[6]]"},
+		{"mirror", "[3:[3 1] 4:[4] 5:[5] 6:[6]]"},
+		{"mirror.example.com", "[3:[3 1] 4:[4] 5:[5] 6:[6]]"},
+		{"https://mirror.example.com/, hub", "[3:[3 1] 4:[4] 5:[5] 6:[6]]"},
+		{"unknown, mirror", "[3:[3 1] 4:[4] 5:[5] 6:[6]]"},
+		{"unknown", "[1:[1 3] 4:[4] 5:[5] 6:[6]]"},
+	}
+
+	for _, c := range cases {
+		os.Setenv("SILO_REGISTRY_ORDER", c.order)
+		ranks := models.RegistryRanks(registries, models.RegistryOrder())
+		hits := []string{}
+		for _, hit := range models.CollapseMirrors(images, ranks) {
+			mirrors := []int{}
+			for i, m := range hit.Mirrors {
+				if m.Preferred != (i == 0) {
+					t.Errorf("%q: Expected only the first mirror of %d to be preferred. Got %v", c.order, hit.ID, hit.Mirrors)
+				}
+				mirrors = append(mirrors, m.ImageId)
+			}
+			hits = append(hits, fmt.Sprintf("%d:%v", hit.ID, mirrors))
+		}
+		if fmt.Sprint(hits) != c.expected {
+			t.Errorf("%q: Expected %s. Got %v", c.order, c.expected, hits)
+		}
+	}
+}
+
+func TestImageMirrors(t *testing.T) {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/images/%d/mirrors", imageID), nil)
+	response := executeRequest(req)
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	mirrors := []models.Mirror{}
+	json.Unmarshal(response.Body.Bytes(), &mirrors)
+	if len(mirrors) != 1 || mirrors[0].RegistryId != 1 || !mirrors[0].Preferred {
+		t.Errorf("Expected the image to only be in registry 1. Got %v", mirrors)
+	}
+
+	req, _ = http.NewRequest("GET", "/images/search/my-job-0.1.0?collapseMirrors=true", nil)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	images := []models.SimpleImage{}
+	json.Unmarshal(response.Body.Bytes(), &images)
+	if len(images) == 0 || len(images[0].Mirrors) != 1 || images[0].Mirrors[0].ImageId != images[0].ID {
+		t.Errorf("Expected collapsed results to list their mirrors. Got %v", images)
+	}
+
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/images/%d/mirrors", 100000), nil)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusNotFound, response.Code)
+}
+
+func TestPromoteImage(t *testing.T) {
+	payload := []byte(`{"RegistryId": 100000}`)
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/images/%d/promote", imageID), bytes.NewBuffer(payload))
+	response := executeRequest(req)
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+
+	req, _ = http.NewRequest("POST", fmt.Sprintf("/images/%d/promote", imageID), bytes.NewBuffer(payload))
+	req.Header.Set("Authorization", "Token: "+token)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusNotFound, response.Code)
+	var m map[string]string
+	json.Unmarshal(response.Body.Bytes(), &m)
+	if m["error"] != "No registry found with that ID" {
+		t.Errorf("Expected error to be 'No registry found with that ID'. Got '%v'", m["error"])
+	}
+
+	req, _ = http.NewRequest("POST", fmt.Sprintf("/images/%d/promote", 100000), bytes.NewBuffer(payload))
+	req.Header.Set("Authorization", "Token: "+token)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusNotFound, response.Code)
+
+	req, _ = http.NewRequest("GET", "/operations", nil)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/operations/%d", 100000), nil)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusNotFound, response.Code)
+}
+
+func TestRegisterImage(t *testing.T) {
+	payload := []byte(`{"Image": "registry.example.com/geointseed/vendor-job-seed:1.0.0", "Manifest": {"seedVersion": "1.0.0",
+		"job": {"name": "vendor-job", "jobVersion": "1.0.0", "packageVersion": "1.0.0", "title": "Vendor job",
+		"description": "Registered by hand", "maintainer": {"name": "Jane Doe", "email": "jdoe@example.com"},
+		"timeout": 10, "interface": {"command": "run"}}}}`)
+	req, _ := http.NewRequest("POST", "/images/register", bytes.NewBuffer(payload))
+	response := executeRequest(req)
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+
+	req, _ = http.NewRequest("POST", "/images/register", bytes.NewBuffer([]byte(`{"Image": ""}`)))
+	req.Header.Set("Authorization", "Token: "+token)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+	req, _ = http.NewRequest("POST", "/images/register", bytes.NewBuffer(payload))
+	req.Header.Set("Authorization", "Token: "+token)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	registered := models.SimpleImage{}
+	json.Unmarshal(response.Body.Bytes(), &registered)
+	if registered.Name != "geointseed/vendor-job-seed:1.0.0" || registered.Registry != "registry.example.com" ||
+		registered.Org != "geointseed" || registered.JobName != "vendor-job" {
+		t.Errorf("Unexpected registered image %v", registered)
+	}
+
+	//registered images survive a full rescan
+	req, _ = http.NewRequest("GET", "/registries/scan", nil)
+	req.Header.Set("Authorization", "Token: "+token)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusAccepted, response.Code)
+
+	req, _ = http.NewRequest("GET", "/jobs/search/vendor-job", nil)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusOK, response.Code)
+	if !strings.Contains(response.Body.String(), "vendor-job") {
+		t.Errorf("Expected registered job vendor-job after rescan. Got %s", response.Body.String())
+	}
+}
+
 func get_images() bool {
 	clearTablePG()
 	clearTable()
@@ -188,6 +767,8 @@ func clearTable() {
 	db.Exec("DELETE FROM sqlite_sequence")
 	db.Exec("DELETE FROM Job")
 	db.Exec("DELETE FROM JobVersion")
+	db.Exec("DELETE FROM TagHistory")
+	db.Exec("DELETE FROM Operation")
 }
 
 func clearTablePG() {
@@ -196,6 +777,8 @@ func clearTablePG() {
 	db.Exec("TRUNCATE SiloUser RESTART IDENTITY CASCADE")
 	db.Exec("TRUNCATE Job RESTART IDENTITY CASCADE")
 	db.Exec("TRUNCATE JobVersion RESTART IDENTITY CASCADE")
+	db.Exec("TRUNCATE TagHistory RESTART IDENTITY CASCADE")
+	db.Exec("TRUNCATE Operation RESTART IDENTITY CASCADE")
 }
 
 func executeRequest(req *http.Request) *httptest.ResponseRecorder {
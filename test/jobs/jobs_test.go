@@ -1,6 +1,7 @@
 package handlers_jobs_test
 
 import (
+	"archive/tar"
 	"bytes"
 	"database/sql"
 	"encoding/json"
@@ -11,10 +12,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/ngageoint/seed-common/objects"
 	"github.com/ngageoint/seed-common/util"
 	"github.com/ngageoint/seed-silo/database"
 	"github.com/ngageoint/seed-silo/models"
@@ -182,6 +186,368 @@ func TestListJobs(t *testing.T) {
 	}
 }
 
+func TestJobConflicts(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/jobs/conflicts", nil)
+	response := executeRequest(req)
+
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	// the test registry is the only place my-job is published
+	conflicts := []models.JobConflict{}
+	json.Unmarshal(response.Body.Bytes(), &conflicts)
+	for _, c := range conflicts {
+		if c.Name == "my-job" {
+			t.Errorf("Unexpected conflict %v", c)
+		}
+	}
+}
+
+//catalogDB creates an empty catalog apart from the test database
+func catalogDB(t *testing.T, path string) *sql.DB {
+	cdb, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	models.CreateImageTable(cdb, "sqlite")
+	models.CreateRegistryTable(cdb, "sqlite")
+	models.CreateJobTable(cdb, "sqlite")
+	models.CreateJobVersionTable(cdb, "sqlite")
+	models.CreateInterfaceTables(cdb, "sqlite")
+	models.CreateResourceTable(cdb, "sqlite")
+	models.CreatePolicyTable(cdb, "sqlite")
+	models.CreateTagHistoryTable(cdb, "sqlite")
+	return cdb
+}
+
+//mergePolicyDB creates a catalog of same-named jobs published by two maintainers in two orgs of one registry and one
+//org of another, merged under the given SILO_JOB_MERGE_POLICY
+func mergePolicyDB(t *testing.T, dir, policy string) *sql.DB {
+	pdb := catalogDB(t, dir+"/"+policy+".db")
+
+	image := func(registryId int, registry, org, email, input string) models.Image {
+		seed := objects.Seed{SeedVersion: "1.0.0"}
+		seed.Job.Name = "my-job"
+		seed.Job.JobVersion = "1.0.0"
+		seed.Job.PackageVersion = "1.0.0"
+		seed.Job.Maintainer.Email = email
+		seed.Job.Interface.Inputs.Json = []objects.InJson{{Name: input, Type: "string", Required: true}}
+		manifest, _ := json.Marshal(seed)
+		return models.Image{RegistryId: registryId, Registry: registry, Org: org, Email: email,
+			FullName: org + "/my-job-1.0.0-seed:1.0.0", Manifest: string(manifest), Seed: seed,
+			Digest: "sha256:" + strings.Repeat(input, 64)}
+	}
+	images := []models.Image{
+		image(1, "docker.io", "alpha", "alpha@example.com", "a"),
+		image(1, "docker.io", "beta", "beta@example.com", "b"),
+		image(2, "quay.io", "alpha", "alpha@example.com", "c"),
+	}
+
+	os.Setenv("SILO_JOB_MERGE_POLICY", policy)
+	if err := models.MergeImages(pdb, images, "sqlite"); err != nil {
+		t.Fatal(err)
+	}
+	return pdb
+}
+
+func TestJobMergePolicies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "silo-merge-policy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Unsetenv("SILO_JOB_MERGE_POLICY")
+
+	cases := []struct {
+		policy     string
+		namespaces []string
+	}{
+		{models.MergeGlobal, []string{""}},
+		{models.MergeRegistry, []string{"docker.io", "quay.io"}},
+		{models.MergeOrg, []string{"docker.io/alpha", "docker.io/beta", "quay.io/alpha"}},
+		{models.MergeMaintainer, []string{"alpha@example.com", "beta@example.com"}},
+	}
+
+	for _, c := range cases {
+		pdb := mergePolicyDB(t, dir, c.policy)
+
+		jobs, err := models.ReadJobsByName(pdb, "my-job")
+		if err != nil || len(jobs) != len(c.namespaces) {
+			t.Errorf("%s: Expected %d jobs named my-job, got %v (%v)", c.policy, len(c.namespaces), jobs, err)
+		}
+		for _, ns := range c.namespaces {
+			if _, err := models.ReadJobByName(pdb, ns, "my-job"); err != nil {
+				t.Errorf("%s: Expected my-job in namespace %q: %v", c.policy, ns, err)
+			}
+		}
+
+		// a job version's manifest and resolution must come from its own images, not a same-named job elsewhere
+		for _, jv := range models.ReadJobVersions(pdb) {
+			inputs := map[string]bool{}
+			ids := map[int]bool{}
+			for _, id := range models.GetJobVersionImageIds(pdb, jv.ID) {
+				img, _ := models.ReadImage(pdb, id)
+				inputs[img.Seed.Job.Interface.Inputs.Json[0].Name] = true
+				ids[id] = true
+			}
+			manifest, err := models.JobVersionManifest(pdb, jv)
+			if err != nil || !inputs[manifest.Job.Interface.Inputs.Json[0].Name] {
+				t.Errorf("%s: Job version %d manifest %v does not come from its images (%v)", c.policy, jv.ID,
+					manifest.Job.Interface, err)
+			}
+			res, err := models.ResolveJob(pdb, models.JobVersionQuery(jv))
+			if err != nil || !ids[res.ImageId] {
+				t.Errorf("%s: Job version %d resolved to image %d outside its images %v (%v)", c.policy, jv.ID,
+					res.ImageId, ids, err)
+			}
+		}
+
+		// a job name is resolved within its namespace and only without one if no other namespace uses it
+		res, err := models.ResolveJob(pdb, models.ResolveQuery{Job: "my-job"})
+		if _, ambiguous := err.(models.AmbiguousJobError); ambiguous != (len(c.namespaces) > 1) {
+			t.Errorf("%s: Resolving my-job without a namespace returned %v, %v", c.policy, res.ImageId, err)
+		}
+		for _, ns := range c.namespaces {
+			job, _ := models.ReadJobByName(pdb, ns, "my-job")
+			res, err := models.ResolveJob(pdb, models.ResolveQuery{Job: "my-job", Namespace: ns})
+			if err != nil || res.JobId != job.ID {
+				t.Errorf("%s: Resolving my-job in namespace %q returned job %d, expected %d (%v)", c.policy, ns,
+					res.JobId, job.ID, err)
+			}
+		}
+		_, err = models.ResolveJob(pdb, models.ResolveQuery{Job: "my-job", Namespace: "missing"})
+		if err != models.ErrNoMatch {
+			t.Errorf("%s: Resolving my-job in a missing namespace returned %v", c.policy, err)
+		}
+
+		// the maintainers disagree, so the three registries and orgs conflict whatever the policy
+		conflicts := models.JobConflicts(pdb)
+		if len(conflicts) != 1 || conflicts[0].Name != "my-job" || len(conflicts[0].Sources) != 3 {
+			t.Errorf("%s: Expected one conflict for my-job across 3 sources, got %v", c.policy, conflicts)
+		} else {
+			for _, src := range conflicts[0].Sources {
+				job, err := models.ReadJob(pdb, src.JobId)
+				if err != nil || job.Namespace != src.Namespace {
+					t.Errorf("%s: Conflict source %v does not match job %v", c.policy, src, job)
+				}
+			}
+		}
+		pdb.Close()
+	}
+}
+
+func TestMirrorNamespaces(t *testing.T) {
+	dir, err := ioutil.TempDir("", "silo-mirrors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Unsetenv("SILO_JOB_MERGE_POLICY")
+	defer os.Unsetenv("SILO_REGISTRY_ORDER")
+
+	digest := "sha256:" + strings.Repeat("a", 64)
+	image := func(registryId int, registry, org, tag, digest string) models.Image {
+		seed := objects.Seed{SeedVersion: "1.0.0"}
+		seed.Job.Name = "my-job"
+		seed.Job.JobVersion = "1.0.0"
+		seed.Job.PackageVersion = "1.0.0"
+		manifest, _ := json.Marshal(seed)
+		return models.Image{RegistryId: registryId, Registry: registry, Org: org, FullName: org + "/my-job-seed:" + tag,
+			Manifest: string(manifest), Seed: seed, Digest: digest}
+	}
+	// the same manifest in both registries, and a rebuild only pushed to the mirror
+	images := []models.Image{
+		image(1, "hub.example.com", "alpha", "1.0.0", digest),
+		image(2, "mirror.example.com", "beta", "1.0.0", digest),
+		image(2, "mirror.example.com", "beta", "1.0.0-rebuild", "sha256:"+strings.Repeat("b", 64)),
+	}
+
+	cases := []struct {
+		policy     string
+		order      string
+		namespace  string //of the job holding the shared manifest
+		namespaces []string
+	}{
+		{models.MergeOrg, "", "hub.example.com/alpha", []string{"hub.example.com/alpha", "mirror.example.com/beta"}},
+		{models.MergeOrg, "mirror", "mirror.example.com/beta", []string{"mirror.example.com/beta"}},
+		{models.MergeOrg, "https://mirror.example.com", "mirror.example.com/beta", []string{"mirror.example.com/beta"}},
+		{models.MergeRegistry, "hub,mirror", "hub.example.com", []string{"hub.example.com", "mirror.example.com"}},
+		{models.MergeRegistry, "mirror,hub", "mirror.example.com", []string{"mirror.example.com"}},
+		{models.MergeGlobal, "mirror", "", []string{""}},
+	}
+
+	for i, c := range cases {
+		mdb := catalogDB(t, fmt.Sprintf("%s/mirrors-%d.db", dir, i))
+		models.AddRegistryLite(mdb, models.RegistryInfo{Name: "hub", Url: "https://hub.example.com"})
+		models.AddRegistryLite(mdb, models.RegistryInfo{Name: "mirror", Url: "https://mirror.example.com"})
+		os.Setenv("SILO_JOB_MERGE_POLICY", c.policy)
+		os.Setenv("SILO_REGISTRY_ORDER", c.order)
+		if err := models.MergeImages(mdb, images, "sqlite"); err != nil {
+			t.Fatal(err)
+		}
+
+		namespaces := []string{}
+		for _, job := range models.ReadJobs(mdb) {
+			namespaces = append(namespaces, job.Namespace)
+		}
+		sort.Strings(namespaces)
+		if fmt.Sprint(namespaces) != fmt.Sprint(c.namespaces) {
+			t.Errorf("%s %q: Expected jobs in namespaces %q. Got %q", c.policy, c.order, c.namespaces, namespaces)
+		}
+
+		stored := models.ReadImages(mdb)
+		job, err := models.ReadJob(mdb, stored[0].JobId)
+		if err != nil || stored[0].JobId != stored[1].JobId || job.Namespace != c.namespace {
+			t.Errorf("%s %q: Expected both mirrors in the job of namespace %q. Got jobs %d and %d in %q (%v)", c.policy,
+				c.order, c.namespace, stored[0].JobId, stored[1].JobId, job.Namespace, err)
+		}
+		mdb.Close()
+	}
+}
+
+func TestMigrateJobTables(t *testing.T) {
+	dir, err := ioutil.TempDir("", "silo-migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	mdb, err := sql.Open("sqlite3", dir+"/silo.db?_foreign_keys=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mdb.Close()
+
+	//a catalog stored before jobs had namespaces, when job names had to be unique
+	for _, statement := range []string{
+		`CREATE TABLE Job(id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL UNIQUE, latest_job_version TEXT,
+			latest_package_version TEXT, title TEXT, maintainer TEXT, email TEXT, maint_org TEXT, description TEXT)`,
+		`CREATE TABLE JobVersion(id INTEGER PRIMARY KEY AUTOINCREMENT, job_name TEXT, job_id INTEGER NOT NULL,
+			job_version TEXT, latest_package_version TEXT, UNIQUE(job_name, job_version),
+			CONSTRAINT fk_inv_job_id FOREIGN KEY (job_id) REFERENCES Job (id) ON DELETE CASCADE)`,
+		`INSERT INTO Job (name, latest_job_version, latest_package_version) VALUES ('my-job', '1.0.0', '1.0.0')`,
+		`INSERT INTO JobVersion (job_name, job_id, job_version, latest_package_version)
+			VALUES ('my-job', 1, '1.0.0', '1.0.0')`,
+	} {
+		if _, err = mdb.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	models.CreateImageTable(mdb, "sqlite")
+	models.CreateRegistryTable(mdb, "sqlite")
+	models.CreatePolicyTable(mdb, "sqlite")
+	id, _ := models.AddRegistryLite(mdb, models.RegistryInfo{Name: "hub", Url: "https://hub.example.com"})
+	models.StoreImages(mdb, []models.Image{{RegistryId: id, JobId: 1, JobVersionId: 1, FullName: "my-job-1.0.0-seed:1.0.0",
+		ShortName: "my-job", JobVersion: "1.0.0", PackageVersion: "1.0.0", Manifest: "{}"}}, "sqlite")
+
+	models.CreateJobTable(mdb, "sqlite")
+	models.CreateJobVersionTable(mdb, "sqlite")
+
+	images := models.ReadImages(mdb)
+	if len(images) != 1 || images[0].JobId != 1 || images[0].JobVersionId != 1 {
+		t.Errorf("Images after migrating are %v, expected to keep job 1 and job version 1\n", images)
+	}
+	var namespace, name string
+	if err = mdb.QueryRow("SELECT namespace, name FROM Job WHERE id=1").Scan(&namespace, &name); err != nil ||
+		namespace != "" || name != "my-job" {
+		t.Errorf("Job 1 after migrating is %q %q, %v, expected my-job in the global namespace\n", namespace, name, err)
+	}
+	var versions int
+	mdb.QueryRow("SELECT COUNT(*) FROM JobVersion WHERE job_id=1").Scan(&versions)
+	if versions != 1 {
+		t.Errorf("Job 1 has %d versions after migrating, expected 1\n", versions)
+	}
+
+	//jobs of the same name are allowed in other namespaces, with versions of their own
+	if _, err = mdb.Exec(`INSERT INTO Job (name, namespace) VALUES ('my-job', 'docker.io')`); err != nil {
+		t.Errorf("Adding a job of the same name in another namespace returned %v\n", err)
+	}
+	if _, err = mdb.Exec(`INSERT INTO JobVersion (job_name, job_id, job_version) VALUES ('my-job', 2, '1.0.0')`); err != nil {
+		t.Errorf("Adding the same job version to the other job returned %v\n", err)
+	}
+
+	//migrating again changes nothing
+	models.CreateJobTable(mdb, "sqlite")
+	if images = models.ReadImages(mdb); len(images) != 1 || images[0].JobId != 1 {
+		t.Errorf("Images after migrating again are %v\n", images)
+	}
+}
+
+func TestJobPolicy(t *testing.T) {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/jobs/%d/policy", JobID), nil)
+	response := executeRequest(req)
+
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	findings := []models.PolicyFinding{}
+	json.Unmarshal(response.Body.Bytes(), &findings)
+	for _, f := range findings {
+		if f.JobId != JobID || f.JobName != "my-job" || f.Message == "" {
+			t.Errorf("Unexpected policy finding %v", f)
+		}
+	}
+
+	req, _ = http.NewRequest("GET", "/jobs/99999/policy", nil)
+	response = executeRequest(req)
+
+	checkResponseCode(t, http.StatusNotFound, response.Code)
+}
+
+func TestCheckVersionPolicy(t *testing.T) {
+	image := func(id int, jobVersion, packageVersion string, inputs ...string) models.Image {
+		img := models.Image{ID: id, JobId: 1, ShortName: "my-job", JobVersion: jobVersion, PackageVersion: packageVersion}
+		img.Seed.Job.Name = "my-job"
+		img.Seed.Job.JobVersion = jobVersion
+		img.Seed.Job.PackageVersion = packageVersion
+		for _, in := range inputs {
+			img.Seed.Job.Interface.Inputs.Files = append(img.Seed.Job.Interface.Inputs.Files,
+				objects.InFile{Name: in, Required: true})
+		}
+		return img
+	}
+
+	cases := []struct {
+		name     string
+		images   []models.Image
+		expected []string
+	}{
+		{"input added in minor version", []models.Image{image(1, "1.0.0", "1.0.0", "A"),
+			image(2, "1.1.0", "1.0.0", "A", "B")},
+			[]string{models.PolicyBreakingWithoutMajor + "/" + models.PolicyError + "/2"}},
+		{"input removed in major version", []models.Image{image(1, "1.0.0", "1.0.0", "A", "B"),
+			image(2, "2.0.0", "1.0.0", "A")}, []string{}},
+		{"input removed in minor version before 1.0.0", []models.Image{image(1, "0.1.0", "1.0.0", "A", "B"),
+			image(2, "0.2.0", "1.0.0", "A")}, []string{}},
+		{"input removed in patch version before 1.0.0", []models.Image{image(1, "0.1.0", "1.0.0", "A", "B"),
+			image(2, "0.1.1", "1.0.0", "A")},
+			[]string{models.PolicyBreakingWithoutMajor + "/" + models.PolicyError + "/2"}},
+		{"new job version with the same manifest", []models.Image{image(1, "1.0.0", "1.0.0", "A"),
+			image(2, "1.0.1", "1.0.0", "A")},
+			[]string{models.PolicyIdenticalManifest + "/" + models.PolicyWarning + "/2"}},
+		{"breaking change in package", []models.Image{image(1, "1.0.0", "1.0.0", "A"),
+			image(2, "1.0.0", "1.0.1", "A", "B")},
+			[]string{models.PolicyInterfaceInPackage + "/" + models.PolicyError + "/2"}},
+		{"unsorted images", []models.Image{image(3, "1.1.0", "1.0.0", "A"), image(1, "1.0.0", "1.0.0", "A"),
+			image(2, "1.0.0", "1.0.1", "A")},
+			[]string{models.PolicyIdenticalManifest + "/" + models.PolicyWarning + "/3"}},
+	}
+
+	for _, c := range cases {
+		first := c.images[0].ID
+		findings := []string{}
+		for _, f := range models.CheckVersionPolicy(c.images) {
+			if f.JobId != 1 || f.JobName != "my-job" || f.Message == "" {
+				t.Errorf("%s: Unexpected policy finding %v", c.name, f)
+			}
+			findings = append(findings, fmt.Sprintf("%s/%s/%d", f.Type, f.Severity, f.ImageId))
+		}
+		if fmt.Sprint(findings) != fmt.Sprint(c.expected) {
+			t.Errorf("%s: CheckVersionPolicy returned %v, expected %v", c.name, findings, c.expected)
+		}
+		if c.images[0].ID != first {
+			t.Errorf("%s: CheckVersionPolicy reordered the images passed to it", c.name)
+		}
+	}
+}
+
 func TestJobVersion(t *testing.T) {
 	payload := []byte(``)
 
@@ -193,6 +559,7 @@ func TestJobVersion(t *testing.T) {
 
 	m := models.JobVersion{}
 	json.Unmarshal(response.Body.Bytes(), &m)
+	m.Resources = nil
 
 	testImage := models.SimpleImage{ID: imageID, RegistryId: 1, Name: "my-job-0.1.0-seed:0.1.0",
 		Registry: "docker.io", Org: "geointseed", JobName: "my-job", Title: "My first job",
@@ -201,7 +568,7 @@ func TestJobVersion(t *testing.T) {
 		JobVersion:  "0.1.0", PackageVersion: "0.1.0"}
 
 	testJobVersion := models.JobVersion{ID: JVID, JobId: JobID, JobName: "my-job", JobVersion: "0.1.0", LatestPackageVersion: "0.1.0"}
-	testJobVersion.Images = append(testJobVersion.Images, testImage)
+	testJobVersion.Images = append(testJobVersion.Images, withMetadata(t, testImage, m.Images))
 
 	mStr := fmt.Sprintf("%v", m)
 	testStr := fmt.Sprintf("%v", testJobVersion)
@@ -229,7 +596,9 @@ func TestJobVersions(t *testing.T) {
 		JobVersion:  "0.1.0", PackageVersion: "0.1.0"}
 
 	testJobVersion := models.JobVersion{ID: JVID, JobId: JobID, JobName: "my-job", JobVersion: "0.1.0", LatestPackageVersion: "0.1.0"}
-	testJobVersion.Images = append(testJobVersion.Images, testImage)
+	testJobVersion.Images = append(testJobVersion.Images, withMetadata(t, testImage, m[0].Images))
+
+	m[0].Resources = nil
 
 	mStr := fmt.Sprintf("%v", m[0])
 	testStr := fmt.Sprintf("%v", testJobVersion)
@@ -250,6 +619,7 @@ func TestListJobVersions(t *testing.T) {
 	json.Unmarshal(response.Body.Bytes(), &jvs)
 
 	m := jvs[JVID-1]
+	m.Resources = nil
 
 	testImage := models.SimpleImage{ID: imageID, RegistryId: 1, Name: "my-job-0.1.0-seed:0.1.0",
 		Registry: "docker.io", Org: "geointseed", JobName: "my-job", Title: "My first job",
@@ -258,7 +628,7 @@ func TestListJobVersions(t *testing.T) {
 		JobVersion:  "0.1.0", PackageVersion: "0.1.0"}
 
 	testJobVersion := models.JobVersion{ID: JVID, JobId: JobID, JobName: "my-job", JobVersion: "0.1.0", LatestPackageVersion: "0.1.0"}
-	testJobVersion.Images = append(testJobVersion.Images, testImage)
+	testJobVersion.Images = append(testJobVersion.Images, withMetadata(t, testImage, m.Images))
 
 	mStr := fmt.Sprintf("%v", m)
 	testStr := fmt.Sprintf("%v", testJobVersion)
@@ -267,6 +637,622 @@ func TestListJobVersions(t *testing.T) {
 	}
 }
 
+func TestListJobsByInterface(t *testing.T) {
+	tests := []struct {
+		query string
+		found bool
+	}{
+		{"acceptsMediaType=image/x-hdf5-image", true},
+		{"acceptsMediaType=image/*", true},
+		{"acceptsMediaType=application/geo%2Bjson", false},
+		{"producesMediaType=image/tiff", true},
+		{"producesMediaType=text/*&setting=DB_HOST", true},
+		{"producesMediaType=application/geo%2Bjson", false},
+		{"setting=DB_HOST&mount=MOUNT_PATH", true},
+		{"mount=/the/container/path", true},
+		{"setting=NOT_A_SETTING", false},
+	}
+
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", "/jobs?"+test.query, nil)
+		response := executeRequest(req)
+
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		jobs := []models.Job{}
+		json.Unmarshal(response.Body.Bytes(), &jobs)
+
+		found := false
+		for _, job := range jobs {
+			found = found || job.ID == JobID
+		}
+		if found != test.found {
+			t.Errorf("Expected my-job found to be %v for %s. Got %v", test.found, test.query, found)
+		}
+	}
+}
+
+func TestJobDownstream(t *testing.T) {
+	for _, direction := range []string{"downstream", "upstream"} {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/jobs/%d/%s", JobID, direction), nil)
+		response := executeRequest(req)
+
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		jobs := []models.CompatibleJob{}
+		json.Unmarshal(response.Body.Bytes(), &jobs)
+
+		for _, job := range jobs {
+			if job.JobId == JobID || len(job.Connections) == 0 {
+				t.Errorf("Unexpected %s job %v", direction, job)
+			}
+		}
+
+		req, _ = http.NewRequest("GET", "/jobs/99999/"+direction, nil)
+		response = executeRequest(req)
+
+		checkResponseCode(t, http.StatusNotFound, response.Code)
+	}
+}
+
+func TestValidatePipeline(t *testing.T) {
+	validate := func(payload string) models.PipelineValidation {
+		req, _ := http.NewRequest("POST", "/pipelines/validate", bytes.NewBuffer([]byte(payload)))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		result := models.PipelineValidation{}
+		json.Unmarshal(response.Body.Bytes(), &result)
+		return result
+	}
+	hasIssue := func(result models.PipelineValidation, issueType string) bool {
+		for _, issue := range result.Issues {
+			if issue.Type == issueType {
+				return true
+			}
+		}
+		return false
+	}
+
+	// no outputs of my-job are accepted by its own input
+	result := validate(`{"Steps": [{"Name": "a", "Job": "my-job", "Version": "0.1.0"}, {"Name": "b", "Job": "my-job"}]}`)
+	if result.Valid || len(result.Steps) != 2 || !hasIssue(result, models.IssueUnmatchedInput) {
+		t.Errorf("Expected unmatched input. Got %v", result)
+	}
+
+	result = validate(`{"Steps": [{"Name": "a", "Job": "my-job"}, {"Name": "b", "Job": "my-job"}],
+		"Connections": [{"From": "a", "Output": "output_file_tiffs", "To": "b", "Input": "INPUT_FILE"}]}`)
+	if result.Valid || !hasIssue(result, models.IssueMediaTypeMismatch) || !hasIssue(result, models.IssueMultiplicityConflict) {
+		t.Errorf("Expected media type and multiplicity issues. Got %v", result)
+	}
+
+	result = validate(`{"Steps": [{"Name": "a", "Job": "my-job"}, {"Name": "b", "Job": "my-job"}],
+		"Connections": [{"From": "a", "Output": "output_file_csv", "To": "b", "Input": "INPUT_FILE"},
+		{"From": "b", "Output": "output_file_csv", "To": "a", "Input": "INPUT_FILE"}]}`)
+	if !hasIssue(result, models.IssueCycle) {
+		t.Errorf("Expected cycle. Got %v", result)
+	}
+
+	result = validate(`{"Steps": [{"Name": "a", "Job": "not-a-job"}]}`)
+	if result.Valid || !hasIssue(result, models.IssueUnresolvedJob) {
+		t.Errorf("Expected unresolved job. Got %v", result)
+	}
+}
+
+func TestFitsNodeProfile(t *testing.T) {
+	profiles := map[string]string{
+		"big":  `{"name": "big", "resources": {"cpus": 64, "mem": 1048576, "sharedMem": 1048576, "disk": 1048576}}`,
+		"tiny": `{"name": "tiny", "resources": {"cpus": 0.01}}`,
+	}
+	for _, profile := range profiles {
+		req, _ := http.NewRequest("POST", "/node-profiles/add", bytes.NewBuffer([]byte(profile)))
+		req.Header.Set("Authorization", "Token: "+token)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusCreated, response.Code)
+	}
+
+	fits := func(query string) (bool, models.Job) {
+		req, _ := http.NewRequest("GET", "/jobs?"+query, nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		jobs := []models.Job{}
+		json.Unmarshal(response.Body.Bytes(), &jobs)
+		for _, job := range jobs {
+			if job.ID == JobID {
+				return true, job
+			}
+		}
+		return false, models.Job{}
+	}
+
+	found, job := fits("fits=big")
+	if !found {
+		t.Fatalf("Expected my-job to fit on big node profile")
+	}
+	declared := false
+	for _, jv := range job.JobVersions {
+		declared = declared || len(jv.Resources) > 0
+	}
+	if !declared {
+		t.Fatalf("Expected my-job to declare resources. Got %v", job.JobVersions)
+	}
+	if found, _ = fits("fits=tiny"); found {
+		t.Errorf("Expected my-job not to fit on tiny node profile")
+	}
+
+	req, _ := http.NewRequest("GET", "/jobs?fits=big&inputSize=abc", nil)
+	response := executeRequest(req)
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+	req, _ = http.NewRequest("GET", "/jobs?fits=not-a-profile", nil)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusNotFound, response.Code)
+}
+
+func TestResourcesFit(t *testing.T) {
+	profile := models.NodeProfile{Name: "small", Resources: map[string]float64{"cpus": 2, "mem": 1024}}
+	cases := []struct {
+		resources []models.ScalarResource
+		inputSize float64
+		expected  bool
+	}{
+		{nil, 0, true},
+		{[]models.ScalarResource{{Name: "cpus", Value: 2}, {Name: "mem", Value: 512}}, 0, true},
+		{[]models.ScalarResource{{Name: "cpus", Value: 4}}, 0, false},
+		{[]models.ScalarResource{{Name: "mem", Value: 512, InputMultiplier: 2}}, 256, true},
+		{[]models.ScalarResource{{Name: "mem", Value: 512, InputMultiplier: 2}}, 257, false},
+		{[]models.ScalarResource{{Name: "gpus", Value: 1}}, 0, false},
+		{[]models.ScalarResource{{Name: "gpus", Value: 0}}, 0, true},
+	}
+
+	for _, c := range cases {
+		if fit := models.ResourcesFit(c.resources, profile, c.inputSize); fit != c.expected {
+			t.Errorf("ResourcesFit(%v, %v) returned %v, expected %v", c.resources, c.inputSize, fit, c.expected)
+		}
+	}
+}
+
+func TestDiffJobVersions(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/job-versions", nil)
+	response := executeRequest(req)
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	jvs := []models.JobVersion{}
+	json.Unmarshal(response.Body.Bytes(), &jvs)
+	other := 0
+	for _, jv := range jvs {
+		if jv.JobName == "my-job" && jv.JobVersion == "1.0.0" {
+			other = jv.ID
+		}
+	}
+	if other == 0 {
+		t.Fatalf("Expected my-job 1.0.0 to be scanned. Got %v", jvs)
+	}
+
+	diff := func(from, to int) models.ManifestDiff {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/job-versions/diff?from=%d&to=%d", from, to), nil)
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		result := models.ManifestDiff{}
+		json.Unmarshal(response.Body.Bytes(), &result)
+		return result
+	}
+
+	result := diff(JVID, JVID)
+	if result.Breaking || len(result.Changes) != 0 || result.From.JobVersionId != JVID {
+		t.Errorf("Expected no changes between a job version and itself. Got %v", result)
+	}
+
+	result = diff(JVID, other)
+	if result.From.JobVersion != "0.1.0" || result.To.JobVersion != "1.0.0" || result.To.JobVersionId != other {
+		t.Errorf("Expected a diff from my-job 0.1.0 to 1.0.0. Got %v", result)
+	}
+
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/job-versions/diff?from=%d&to=999999", JVID), nil)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusNotFound, response.Code)
+
+	req, _ = http.NewRequest("GET", "/job-versions/diff?from=abc", nil)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+func TestExportJobVersionScale(t *testing.T) {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/job-versions/%d/export/scale?apiVersion=v6", JVID), nil)
+	response := executeRequest(req)
+
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	m := map[string]interface{}{}
+	json.Unmarshal(response.Body.Bytes(), &m)
+
+	if m["docker_image"] != "docker.io/geointseed/my-job-0.1.0-seed:0.1.0" {
+		t.Errorf("Unexpected docker image %v", m["docker_image"])
+	}
+
+	req, _ = http.NewRequest("GET", "/job-versions/99999/export/scale", nil)
+	response = executeRequest(req)
+
+	checkResponseCode(t, http.StatusNotFound, response.Code)
+}
+
+func TestJobVersionInputSchema(t *testing.T) {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/job-versions/%d/input-schema", JVID), nil)
+	response := executeRequest(req)
+
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	m := map[string]interface{}{}
+	json.Unmarshal(response.Body.Bytes(), &m)
+
+	if m["$schema"] != models.JsonSchemaDraft {
+		t.Errorf("Unexpected schema draft %v", m["$schema"])
+	}
+	properties, _ := m["properties"].(map[string]interface{})
+	inputs, _ := properties["inputs"].(map[string]interface{})
+	if fmt.Sprint(inputs["required"]) != "[INPUT_FILE]" {
+		t.Errorf("Expected INPUT_FILE to be required. Got %v", inputs["required"])
+	}
+}
+
+func TestValidateJobVersionInputs(t *testing.T) {
+	url := fmt.Sprintf("/job-versions/%d/validate-inputs", JVID)
+	payload := []byte(`{"inputs": {"INPUT_FILE": "/data/cells.h5"}, "json": {"INPUT_JSON": {}}, "settings": {"DB_HOST": "db"}}`)
+	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(payload))
+	response := executeRequest(req)
+
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	m := models.InputValidation{}
+	json.Unmarshal(response.Body.Bytes(), &m)
+	if !m.Valid {
+		t.Errorf("Expected inputs to be valid. Got %v", m.Errors)
+	}
+
+	payload = []byte(`{"inputs": {"INPUT_FILE": ["/data/cells.h5"], "OTHER": "x"}}`)
+	req, _ = http.NewRequest("POST", url, bytes.NewBuffer(payload))
+	response = executeRequest(req)
+
+	m = models.InputValidation{}
+	json.Unmarshal(response.Body.Bytes(), &m)
+	errors := fmt.Sprint(m.Errors)
+	if m.Valid || !strings.Contains(errors, "/inputs/INPUT_FILE") || !strings.Contains(errors, "/inputs/OTHER") ||
+		!strings.Contains(errors, "/settings") {
+		t.Errorf("Expected INPUT_FILE, OTHER and settings errors. Got %v", m.Errors)
+	}
+}
+
+func TestLint(t *testing.T) {
+	manifest, _ := ioutil.ReadFile("../seed.manifest.json")
+	req, _ := http.NewRequest("POST", "/lint", bytes.NewBuffer(manifest))
+	response := executeRequest(req)
+
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	m := models.LintResult{}
+	json.Unmarshal(response.Body.Bytes(), &m)
+	if !m.Valid || !strings.Contains(fmt.Sprint(m.Warnings), "already-published") {
+		t.Errorf("Expected a valid, already published manifest. Got %v", m)
+	}
+
+	// escaped label strings are accepted too
+	label := strings.Replace(`{"seedVersion": "1.0.0", "job": {"name": "My Job", "jobVersion": "1.0",
+		"interface": {"settings": [{"name": "DB_PASSWORD"}]}, "errors": [{"code": 1, "name": "a"}, {"code": 1, "name": "b"}]}}`,
+		`"`, `\"`, -1)
+	req, _ = http.NewRequest("POST", "/lint", bytes.NewBufferString(label))
+	response = executeRequest(req)
+
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	m = models.LintResult{}
+	json.Unmarshal(response.Body.Bytes(), &m)
+	errors := fmt.Sprint(m.Errors)
+	warnings := fmt.Sprint(m.Warnings)
+	if m.Valid || !strings.Contains(errors, "/job/name") || !strings.Contains(errors, "/job/jobVersion") ||
+		!strings.Contains(warnings, "secret-setting") || !strings.Contains(warnings, "duplicate-error-code") ||
+		!strings.Contains(warnings, "missing-email") {
+		t.Errorf("Unexpected lint result %v", m)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	payload := []byte(``)
+
+	req, _ := http.NewRequest("GET", "/resolve?job=my-job&version=^0.1", bytes.NewBuffer(payload))
+	response := executeRequest(req)
+
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	m := models.Resolution{}
+	json.Unmarshal(response.Body.Bytes(), &m)
+
+	if m.JobVersion != "0.1.0" || m.PackageVersion != "0.1.0" || m.Name != "my-job-0.1.0-seed:0.1.0" {
+		t.Errorf("Expected my-job-0.1.0-seed:0.1.0. Got '%v'", m.Name)
+	}
+	if !strings.HasPrefix(m.Reference, "docker.io/geointseed/my-job-0.1.0-seed:0.1.0") {
+		t.Errorf("Unexpected reference '%v'", m.Reference)
+	}
+
+	req, _ = http.NewRequest("GET", "/resolve?job=my-job&version=latest", bytes.NewBuffer(payload))
+	response = executeRequest(req)
+
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	m = models.Resolution{}
+	json.Unmarshal(response.Body.Bytes(), &m)
+
+	if m.JobVersion != "1.0.0" {
+		t.Errorf("Expected latest job version to be 1.0.0. Got '%v'", m.JobVersion)
+	}
+
+	req, _ = http.NewRequest("GET", "/resolve?job=my-job&version=^9.0", bytes.NewBuffer(payload))
+	response = executeRequest(req)
+
+	checkResponseCode(t, http.StatusNotFound, response.Code)
+
+	req, _ = http.NewRequest("GET", "/resolve?version=^1.0", bytes.NewBuffer(payload))
+	response = executeRequest(req)
+
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+	req, _ = http.NewRequest("GET", "/resolve?job=my-job&version=>=1.2.3.4", bytes.NewBuffer(payload))
+	response = executeRequest(req)
+
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+func TestLock(t *testing.T) {
+	payload := []byte(`{"Jobs": [{"Name": "my-job", "Version": "^0.1", "PackageVersion": "latest"}]}`)
+
+	req, _ := http.NewRequest("POST", "/lock", bytes.NewBuffer(payload))
+	response := executeRequest(req)
+
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	lockfile := models.Lockfile{}
+	json.Unmarshal(response.Body.Bytes(), &lockfile)
+
+	if len(lockfile.Entries) != 1 {
+		t.Fatalf("Expected 1 lock entry. Got %d", len(lockfile.Entries))
+	}
+	entry := lockfile.Entries[0]
+	if entry.JobVersion != "0.1.0" || entry.Name != "my-job-0.1.0-seed:0.1.0" || entry.Digest == "" {
+		t.Errorf("Unexpected lock entry %v", entry)
+	}
+
+	verify := func(lockfile models.Lockfile) models.LockVerification {
+		body, _ := json.Marshal(lockfile)
+		req, _ := http.NewRequest("POST", "/lock/verify", bytes.NewBuffer(body))
+		response := executeRequest(req)
+		checkResponseCode(t, http.StatusOK, response.Code)
+
+		result := models.LockVerification{}
+		json.Unmarshal(response.Body.Bytes(), &result)
+		return result
+	}
+
+	result := verify(lockfile)
+	if !result.Verified || result.Entries[0].Status != models.LockOk {
+		t.Errorf("Expected lockfile to verify. Got %v", result)
+	}
+
+	// the pinned digest can still be pulled after its tag is gone
+	lockfile.Entries[0].Name = "my-job-0.1.0-seed:does-not-exist"
+	result = verify(lockfile)
+	if !result.Verified || result.Entries[0].Status != models.LockDrifted {
+		t.Errorf("Expected drifted entry. Got %v", result)
+	}
+
+	lockfile.Entries[0].Digest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	result = verify(lockfile)
+	if result.Verified || result.Entries[0].Status != models.LockMissing {
+		t.Errorf("Expected missing entry. Got %v", result)
+	}
+
+	payload = []byte(`{"Jobs": [{"Name": "my-job", "Version": "^9.0"}]}`)
+	req, _ = http.NewRequest("POST", "/lock", bytes.NewBuffer(payload))
+	response = executeRequest(req)
+
+	checkResponseCode(t, http.StatusNotFound, response.Code)
+}
+
+func TestBundle(t *testing.T) {
+	payload := []byte(fmt.Sprintf(`{"JobVersions": [%d]}`, JVID))
+	req, _ := http.NewRequest("POST", "/bundles", bytes.NewBuffer(payload))
+	response := executeRequest(req)
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+
+	req, _ = http.NewRequest("POST", "/bundles", bytes.NewBuffer(payload))
+	req.Header.Set("Authorization", "Token: "+token)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	catalog := models.BundleCatalog{}
+	files := map[string]bool{}
+	tr := tar.NewReader(response.Body)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+		files[header.Name] = true
+		if header.Name == models.BundleCatalogFile {
+			json.NewDecoder(tr).Decode(&catalog)
+		}
+	}
+	if len(catalog.Images) != 1 || catalog.Images[0].Ref != "my-job-0.1.0-seed:0.1.0" || catalog.Images[0].Digest == "" {
+		t.Fatalf("Unexpected bundle catalog %v", catalog)
+	}
+	for _, name := range []string{"oci/oci-layout", "oci/index.json", catalog.Images[0].SeedManifest,
+		"oci/blobs/sha256/" + strings.TrimPrefix(catalog.Images[0].Digest, "sha256:")} {
+		if !files[name] {
+			t.Errorf("Expected bundle to contain %s", name)
+		}
+	}
+
+	req, _ = http.NewRequest("POST", "/bundles", bytes.NewBuffer([]byte(`{"JobVersions": [100000]}`)))
+	req.Header.Set("Authorization", "Token: "+token)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusNotFound, response.Code)
+
+	req, _ = http.NewRequest("POST", "/bundles", bytes.NewBuffer([]byte(`{"JobVersions": []}`)))
+	req.Header.Set("Authorization", "Token: "+token)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+	req, _ = http.NewRequest("POST", "/bundles/import?registry=100000", bytes.NewBuffer([]byte(`not a bundle`)))
+	req.Header.Set("Authorization", "Token: "+token)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusNotFound, response.Code)
+
+	registries, _ := models.GetRegistries(db)
+	req, _ = http.NewRequest("POST", fmt.Sprintf("/bundles/import?registry=%d", registries[0].ID),
+		bytes.NewBuffer([]byte(`not a bundle`)))
+	req.Header.Set("Authorization", "Token: "+token)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+func TestCatalog(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/catalog/export", nil)
+	response := executeRequest(req)
+	checkResponseCode(t, http.StatusUnauthorized, response.Code)
+
+	req, _ = http.NewRequest("GET", "/catalog/export", nil)
+	req.Header.Set("Authorization", "Token: "+token)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	export := response.Body.String()
+	kinds := map[string]int{}
+	for i, line := range strings.Split(strings.TrimSpace(export), "\n") {
+		record := models.CatalogRecord{}
+		json.Unmarshal([]byte(line), &record)
+		if i == 0 && (record.Kind != models.CatalogHeader || record.SchemaVersion != models.CatalogSchemaVersion) {
+			t.Errorf("Expected catalog header. Got %s", line)
+		}
+		if record.Kind == models.CatalogUser && strings.Contains(line, "password") {
+			t.Errorf("Expected user without password. Got %s", line)
+		}
+		kinds[record.Kind]++
+	}
+	images := kinds[models.CatalogImage]
+	if kinds[models.CatalogRegistry] != 1 || images == 0 || kinds[models.CatalogJob] == 0 || kinds[models.CatalogUser] == 0 {
+		t.Errorf("Unexpected catalog records %v", kinds)
+	}
+
+	req, _ = http.NewRequest("POST", "/catalog/import", bytes.NewBufferString(export))
+	req.Header.Set("Authorization", "Token: "+token)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	result := models.CatalogImportResult{}
+	json.Unmarshal(response.Body.Bytes(), &result)
+	if result.Mode != models.CatalogMerge || result.Images != images || result.RegistriesAdded != 0 || result.UsersAdded != 0 {
+		t.Errorf("Unexpected import result %v", result)
+	}
+	if count := len(models.ReadImages(db)); count != images {
+		t.Errorf("Expected %d images after merging the catalog into itself. Got %d", images, count)
+	}
+
+	req, _ = http.NewRequest("POST", "/catalog/import?mode=replace", bytes.NewBufferString(`{"Kind":"header","SchemaVersion":2}`))
+	req.Header.Set("Authorization", "Token: "+token)
+	response = executeRequest(req)
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
+}
+
+func TestImportCatalogReplace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "silo-catalog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	image := func(registryId int, registry, name string) models.Image {
+		seed := objects.Seed{SeedVersion: "1.0.0"}
+		seed.Job.Name = name
+		seed.Job.JobVersion = "1.0.0"
+		seed.Job.PackageVersion = "1.0.0"
+		manifest, _ := json.Marshal(seed)
+		return models.Image{RegistryId: registryId, Registry: registry, Org: "seed",
+			FullName: "seed/" + name + "-1.0.0-seed:1.0.0", Manifest: string(manifest), Seed: seed}
+	}
+	newCatalog := func(name string, registries ...string) *sql.DB {
+		cdb := catalogDB(t, dir+"/"+name+".db")
+		models.CreateUser(cdb, "sqlite", "admin", "spicy-pickles17!")
+		images := []models.Image{}
+		for _, reg := range registries {
+			id, _ := models.AddRegistryLite(cdb, models.RegistryInfo{Name: reg, Url: "https://" + reg + ".example.com",
+				Username: name})
+			images = append(images, image(id, reg+".example.com", name+"-"+reg+"-job"))
+		}
+		models.MergeImages(cdb, images, "sqlite")
+		return cdb
+	}
+	current := newCatalog("current", "old", "hub")
+	defer current.Close()
+	source := newCatalog("source", "new", "hub")
+	defer source.Close()
+	models.AddUserLite(source, models.SiloUser{Username: "analyst", Password: "spicy-pickles17!", Role: "user"})
+
+	var export bytes.Buffer
+	if err = models.ExportCatalog(source, &export); err != nil {
+		t.Fatal(err)
+	}
+
+	// an image whose registry is missing from the export is rejected before anything changes
+	lines := strings.Split(strings.TrimSpace(export.String()), "\n")
+	broken := []string{}
+	for _, line := range lines {
+		if !strings.Contains(line, `"Kind":"registry"`) || !strings.Contains(line, `"Name":"new"`) {
+			broken = append(broken, line)
+		}
+	}
+	_, err = models.ImportCatalog(current, "sqlite", strings.NewReader(strings.Join(broken, "\n")), models.CatalogReplace)
+	if err == nil || len(models.ReadImages(current)) != 2 {
+		t.Errorf("Expected the import of an image without its registry to fail leaving 2 images. Got %v and %d images",
+			err, len(models.ReadImages(current)))
+	}
+
+	result, err := models.ImportCatalog(current, "sqlite", &export, models.CatalogReplace)
+	if err != nil || result.Mode != models.CatalogReplace || result.RegistriesAdded != 1 || result.RegistriesRemoved != 1 ||
+		result.Images != 2 || result.UsersAdded != 1 {
+		t.Errorf("Unexpected replace result %v (%v)", result, err)
+	}
+
+	registries, _ := models.GetRegistries(current)
+	names := []string{}
+	for _, reg := range registries {
+		names = append(names, reg.Name+":"+reg.Username)
+	}
+	sort.Strings(names)
+	// the hub registry is kept with its credentials, the new one is added without any
+	if fmt.Sprint(names) != "[hub:current new:]" {
+		t.Errorf("Expected the hub and new registries. Got %v", names)
+	}
+	images := []string{}
+	for _, img := range models.ReadImages(current) {
+		images = append(images, img.ShortName)
+	}
+	sort.Strings(images)
+	if fmt.Sprint(images) != "[source-hub-job source-new-job]" {
+		t.Errorf("Expected only the images of the export. Got %v", images)
+	}
+	jobs := []string{}
+	for _, job := range models.ReadJobs(current) {
+		jobs = append(jobs, job.Name)
+	}
+	sort.Strings(jobs)
+	if fmt.Sprint(jobs) != fmt.Sprint(images) {
+		t.Errorf("Expected jobs rebuilt from the imported images. Got %v", jobs)
+	}
+	if _, err = models.GetUserByName(current, "analyst"); err != nil {
+		t.Errorf("Expected the analyst user to be added: %v", err)
+	}
+}
+
 func get_images() bool {
 	clearTablePG()
 	clearTable()
@@ -287,6 +1273,8 @@ func clearTable() {
 	db.Exec("DELETE FROM sqlite_sequence")
 	db.Exec("DELETE FROM Job")
 	db.Exec("DELETE FROM JobVersion")
+	db.Exec("DELETE FROM NodeProfile")
+	db.Exec("DELETE FROM PolicyFinding")
 }
 
 func clearTablePG() {
@@ -295,6 +1283,31 @@ func clearTablePG() {
 	db.Exec("TRUNCATE SiloUser RESTART IDENTITY CASCADE")
 	db.Exec("TRUNCATE Job RESTART IDENTITY CASCADE")
 	db.Exec("TRUNCATE JobVersion RESTART IDENTITY CASCADE")
+	db.Exec("TRUNCATE NodeProfile RESTART IDENTITY CASCADE")
+}
+
+//withMetadata checks that the first actual image has well-formed registry metadata and copies it to the expected
+//image, since it changes whenever the image is rebuilt
+func withMetadata(t *testing.T, expected models.SimpleImage, actual []models.SimpleImage) models.SimpleImage {
+	if len(actual) == 0 {
+		return expected
+	}
+	img := actual[0]
+	if !strings.HasPrefix(img.Digest, "sha256:") || len(img.Digest) != len("sha256:")+64 {
+		t.Errorf("Expected a sha256 manifest digest. Got %q", img.Digest)
+	}
+	if img.Size <= 0 {
+		t.Errorf("Expected a positive image size. Got %d", img.Size)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, img.Created); err != nil {
+		t.Errorf("Expected an RFC 3339 created time. Got %q", img.Created)
+	}
+	if parts := strings.Split(img.Platform, "/"); len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		t.Errorf("Expected an os/architecture platform. Got %q", img.Platform)
+	}
+	expected.Digest, expected.Size = img.Digest, img.Size
+	expected.Created, expected.Platform = img.Created, img.Platform
+	return expected
 }
 
 func executeRequest(req *http.Request) *httptest.ResponseRecorder {
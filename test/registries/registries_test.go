@@ -181,6 +181,14 @@ func TestAddRegistry(t *testing.T) {
 	response = executeRequest(req)
 
 	checkResponseCode(t, http.StatusBadRequest, response.Code)
+
+	// docker hub lists one org at a time, so org patterns are rejected
+	payload = []byte(`{"name":"dockerhub-teams", "url":"https://hub.docker.com", "org":"geointseed", "orgs":["team-*"]}`)
+	req, _ = http.NewRequest("POST", "/registries/add", bytes.NewBuffer(payload))
+	req.Header.Set("Authorization", "Token: "+token)
+	response = executeRequest(req)
+
+	checkResponseCode(t, http.StatusBadRequest, response.Code)
 }
 
 func TestDeleteRegistry(t *testing.T) {
@@ -256,10 +264,23 @@ func TestScanRegistry(t *testing.T) {
 		Maintainer: "John Doe", Email: "jdoe@example.com", MaintOrg: "E-corp",
 		Description: "Reads an HDF5 file and outputs two TIFF images, a CSV and manifest containing cell_count",
 		JobVersion:  "0.1.0", PackageVersion: "0.1.0"}
+	actual := images[imID-1]
+	testImage.Digest, testImage.Size, testImage.Created, testImage.Platform = actual.Digest, actual.Size, actual.Created, actual.Platform
 	if fmt.Sprint(images[imID-1]) != fmt.Sprint(testImage) {
 		t.Errorf("Expected image to be %v. Got '%v'", testImage, images[imID-1])
 	}
 
+	req, _ = http.NewRequest("GET", "/registries/1/status", bytes.NewBuffer(payload))
+	response = executeRequest(req)
+
+	checkResponseCode(t, http.StatusOK, response.Code)
+
+	status := models.RegistryStatus{}
+	json.Unmarshal(response.Body.Bytes(), &status)
+	if status.Registry.Name != "dockerhub" || status.Images != len(images) || status.Jobs == 0 {
+		t.Errorf("Unexpected registry status %v", status)
+	}
+
 	req, _ = http.NewRequest("GET", "/registries/test/scan", bytes.NewBuffer(payload))
 	req.Header.Set("Authorization", "Token: "+token)
 	response = executeRequest(req)
@@ -267,6 +288,55 @@ func TestScanRegistry(t *testing.T) {
 	checkResponseCode(t, http.StatusBadRequest, response.Code)
 }
 
+func TestUpstreamSiloRegistry(t *testing.T) {
+	clearTablePG()
+	clearTable()
+
+	addRegistry()
+
+	payload := []byte(``)
+	req, _ := http.NewRequest("GET", "/registries/1/scan", bytes.NewBuffer(payload))
+	req.Header.Set("Authorization", "Token: "+token)
+	response := executeRequest(req)
+
+	checkResponseCode(t, 202, response.Code)
+
+	upstream := httptest.NewServer(router)
+	defer upstream.Close()
+
+	payload = []byte(`{"name":"upstream", "url":"silo+` + upstream.URL + `", "org":"geointseed", "username":"admin", "password": "spicy-pickles17!"}`)
+	req, _ = http.NewRequest("POST", "/registries/add", bytes.NewBuffer(payload))
+	req.Header.Set("Authorization", "Token: "+token)
+	response = executeRequest(req)
+
+	checkResponseCode(t, http.StatusCreated, response.Code)
+
+	req, _ = http.NewRequest("GET", "/registries/2/scan", nil)
+	req.Header.Set("Authorization", "Token: "+token)
+	response = executeRequest(req)
+
+	checkResponseCode(t, 202, response.Code)
+
+	req, _ = http.NewRequest("GET", "/images", nil)
+	response = executeRequest(req)
+
+	images := []models.SimpleImage{}
+	json.Unmarshal(response.Body.Bytes(), &images)
+
+	found := false
+	for _, img := range images {
+		if img.RegistryId == 2 && img.Name == "my-job-0.1.0-seed:0.1.0" {
+			found = true
+			if img.Registry != "docker.io" || img.Org != "geointseed" || img.JobName != "my-job" {
+				t.Errorf("Expected upstream attribution docker.io/geointseed for my-job. Got %v", img)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected my-job-0.1.0-seed:0.1.0 to be scanned from the upstream silo. Got %v", images)
+	}
+}
+
 func TestListRegistries(t *testing.T) {
 	clearTablePG()
 	clearTable()
@@ -292,6 +362,7 @@ func clearTable() {
 	db.Exec("DELETE FROM sqlite_sequence")
 	db.Exec("DELETE FROM Job")
 	db.Exec("DELETE FROM JobVersion")
+	db.Exec("DELETE FROM PolicyFinding")
 }
 
 func clearTablePG() {